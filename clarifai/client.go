@@ -65,8 +65,26 @@ type V2ClientInterface interface {
 	// Annotation methods used in handler
 	ListAnnotations(ctx context.Context, in *pb.ListAnnotationsRequest, opts ...grpc.CallOption) (*pb.MultiAnnotationResponse, error)
 	GetAnnotation(ctx context.Context, in *pb.GetAnnotationRequest, opts ...grpc.CallOption) (*pb.SingleAnnotationResponse, error)
+	PostAnnotationsSearches(ctx context.Context, in *pb.PostAnnotationsSearchesRequest, opts ...grpc.CallOption) (*pb.MultiSearchResponse, error)
+	PostAnnotations(ctx context.Context, in *pb.PostAnnotationsRequest, opts ...grpc.CallOption) (*pb.MultiAnnotationResponse, error)
+	PatchAnnotations(ctx context.Context, in *pb.PatchAnnotationsRequest, opts ...grpc.CallOption) (*pb.MultiAnnotationResponse, error)
+	DeleteAnnotation(ctx context.Context, in *pb.DeleteAnnotationRequest, opts ...grpc.CallOption) (*statuspb.BaseResponse, error)
 	// Add PostInputs for the new tool
 	PostInputs(ctx context.Context, in *pb.PostInputsRequest, opts ...grpc.CallOption) (*pb.MultiInputResponse, error)
+	// Input mutation methods for delete_inputs and patch_input
+	PatchInputs(ctx context.Context, in *pb.PatchInputsRequest, opts ...grpc.CallOption) (*pb.MultiInputResponse, error)
+	DeleteInputs(ctx context.Context, in *pb.DeleteInputsRequest, opts ...grpc.CallOption) (*statuspb.BaseResponse, error)
+	// Dataset methods for the dataset management tools
+	PostDatasets(ctx context.Context, in *pb.PostDatasetsRequest, opts ...grpc.CallOption) (*pb.MultiDatasetResponse, error)
+	PostDatasetInputs(ctx context.Context, in *pb.PostDatasetInputsRequest, opts ...grpc.CallOption) (*pb.MultiDatasetInputResponse, error)
+	DeleteDatasetInputs(ctx context.Context, in *pb.DeleteDatasetInputsRequest, opts ...grpc.CallOption) (*statuspb.BaseResponse, error)
+	PostDatasetVersions(ctx context.Context, in *pb.PostDatasetVersionsRequest, opts ...grpc.CallOption) (*pb.MultiDatasetVersionResponse, error)
+	// Model training methods for the fine-tuning tools
+	PostModels(ctx context.Context, in *pb.PostModelsRequest, opts ...grpc.CallOption) (*pb.SingleModelResponse, error)
+	PostModelVersions(ctx context.Context, in *pb.PostModelVersionsRequest, opts ...grpc.CallOption) (*pb.SingleModelResponse, error)
+	GetModelVersion(ctx context.Context, in *pb.GetModelVersionRequest, opts ...grpc.CallOption) (*pb.SingleModelVersionResponse, error)
+	PostModelVersionEvaluations(ctx context.Context, in *pb.PostModelVersionEvaluationsRequest, opts ...grpc.CallOption) (*pb.MultiEvalMetricsResponse, error)
+	GetModelVersionEvaluation(ctx context.Context, in *pb.GetModelVersionEvaluationRequest, opts ...grpc.CallOption) (*pb.SingleEvalMetricsResponse, error)
 	// Add other methods here if they become needed by the server
 }
 
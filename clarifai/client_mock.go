@@ -4,6 +4,7 @@ import (
 	"context"
 
 	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	statuspb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api/status"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 )
@@ -19,7 +20,23 @@ type MockV2Client struct {
 	// Add fields for new interface methods
 	ListAnnotationsFunc func(ctx context.Context, in *pb.ListAnnotationsRequest, opts ...grpc.CallOption) (*pb.MultiAnnotationResponse, error)
 	GetAnnotationFunc   func(ctx context.Context, in *pb.GetAnnotationRequest, opts ...grpc.CallOption) (*pb.SingleAnnotationResponse, error)
+	PostAnnotationsSearchesFunc func(ctx context.Context, in *pb.PostAnnotationsSearchesRequest, opts ...grpc.CallOption) (*pb.MultiSearchResponse, error)
+	PostAnnotationsFunc  func(ctx context.Context, in *pb.PostAnnotationsRequest, opts ...grpc.CallOption) (*pb.MultiAnnotationResponse, error)
+	PatchAnnotationsFunc func(ctx context.Context, in *pb.PatchAnnotationsRequest, opts ...grpc.CallOption) (*pb.MultiAnnotationResponse, error)
+	DeleteAnnotationFunc func(ctx context.Context, in *pb.DeleteAnnotationRequest, opts ...grpc.CallOption) (*statuspb.BaseResponse, error)
 	PostInputsFunc      func(ctx context.Context, in *pb.PostInputsRequest, opts ...grpc.CallOption) (*pb.MultiInputResponse, error) // Added for PostInputs
+	PatchInputsFunc     func(ctx context.Context, in *pb.PatchInputsRequest, opts ...grpc.CallOption) (*pb.MultiInputResponse, error)
+	DeleteInputsFunc    func(ctx context.Context, in *pb.DeleteInputsRequest, opts ...grpc.CallOption) (*statuspb.BaseResponse, error)
+	PostDatasetsFunc        func(ctx context.Context, in *pb.PostDatasetsRequest, opts ...grpc.CallOption) (*pb.MultiDatasetResponse, error)
+	PostDatasetInputsFunc   func(ctx context.Context, in *pb.PostDatasetInputsRequest, opts ...grpc.CallOption) (*pb.MultiDatasetInputResponse, error)
+	DeleteDatasetInputsFunc func(ctx context.Context, in *pb.DeleteDatasetInputsRequest, opts ...grpc.CallOption) (*statuspb.BaseResponse, error)
+	PostDatasetVersionsFunc func(ctx context.Context, in *pb.PostDatasetVersionsRequest, opts ...grpc.CallOption) (*pb.MultiDatasetVersionResponse, error)
+
+	PostModelsFunc                  func(ctx context.Context, in *pb.PostModelsRequest, opts ...grpc.CallOption) (*pb.SingleModelResponse, error)
+	PostModelVersionsFunc           func(ctx context.Context, in *pb.PostModelVersionsRequest, opts ...grpc.CallOption) (*pb.SingleModelResponse, error)
+	GetModelVersionFunc             func(ctx context.Context, in *pb.GetModelVersionRequest, opts ...grpc.CallOption) (*pb.SingleModelVersionResponse, error)
+	PostModelVersionEvaluationsFunc func(ctx context.Context, in *pb.PostModelVersionEvaluationsRequest, opts ...grpc.CallOption) (*pb.MultiEvalMetricsResponse, error)
+	GetModelVersionEvaluationFunc   func(ctx context.Context, in *pb.GetModelVersionEvaluationRequest, opts ...grpc.CallOption) (*pb.SingleEvalMetricsResponse, error)
 }
 
 // Ensure MockV2Client implements the V2ClientInterface.
@@ -106,6 +123,141 @@ func (m *MockV2Client) PostInputs(ctx context.Context, in *pb.PostInputsRequest,
 	return &pb.MultiInputResponse{}, nil
 }
 
+// PatchInputs calls the mock function or returns default values.
+func (m *MockV2Client) PatchInputs(ctx context.Context, in *pb.PatchInputsRequest, opts ...grpc.CallOption) (*pb.MultiInputResponse, error) {
+	if m.PatchInputsFunc != nil {
+		return m.PatchInputsFunc(ctx, in, opts...)
+	}
+	// Default mock behavior
+	return &pb.MultiInputResponse{}, nil
+}
+
+// DeleteInputs calls the mock function or returns default values.
+func (m *MockV2Client) DeleteInputs(ctx context.Context, in *pb.DeleteInputsRequest, opts ...grpc.CallOption) (*statuspb.BaseResponse, error) {
+	if m.DeleteInputsFunc != nil {
+		return m.DeleteInputsFunc(ctx, in, opts...)
+	}
+	// Default mock behavior
+	return &statuspb.BaseResponse{}, nil
+}
+
+// PostAnnotationsSearches calls the mock function or returns default values.
+func (m *MockV2Client) PostAnnotationsSearches(ctx context.Context, in *pb.PostAnnotationsSearchesRequest, opts ...grpc.CallOption) (*pb.MultiSearchResponse, error) {
+	if m.PostAnnotationsSearchesFunc != nil {
+		return m.PostAnnotationsSearchesFunc(ctx, in, opts...)
+	}
+	// Default mock behavior
+	return &pb.MultiSearchResponse{}, nil
+}
+
+// PostAnnotations calls the mock function or returns default values.
+func (m *MockV2Client) PostAnnotations(ctx context.Context, in *pb.PostAnnotationsRequest, opts ...grpc.CallOption) (*pb.MultiAnnotationResponse, error) {
+	if m.PostAnnotationsFunc != nil {
+		return m.PostAnnotationsFunc(ctx, in, opts...)
+	}
+	// Default mock behavior
+	return &pb.MultiAnnotationResponse{}, nil
+}
+
+// PatchAnnotations calls the mock function or returns default values.
+func (m *MockV2Client) PatchAnnotations(ctx context.Context, in *pb.PatchAnnotationsRequest, opts ...grpc.CallOption) (*pb.MultiAnnotationResponse, error) {
+	if m.PatchAnnotationsFunc != nil {
+		return m.PatchAnnotationsFunc(ctx, in, opts...)
+	}
+	// Default mock behavior
+	return &pb.MultiAnnotationResponse{}, nil
+}
+
+// DeleteAnnotation calls the mock function or returns default values.
+func (m *MockV2Client) DeleteAnnotation(ctx context.Context, in *pb.DeleteAnnotationRequest, opts ...grpc.CallOption) (*statuspb.BaseResponse, error) {
+	if m.DeleteAnnotationFunc != nil {
+		return m.DeleteAnnotationFunc(ctx, in, opts...)
+	}
+	// Default mock behavior
+	return &statuspb.BaseResponse{}, nil
+}
+
+// PostDatasets calls the mock function or returns default values.
+func (m *MockV2Client) PostDatasets(ctx context.Context, in *pb.PostDatasetsRequest, opts ...grpc.CallOption) (*pb.MultiDatasetResponse, error) {
+	if m.PostDatasetsFunc != nil {
+		return m.PostDatasetsFunc(ctx, in, opts...)
+	}
+	// Default mock behavior
+	return &pb.MultiDatasetResponse{}, nil
+}
+
+// PostDatasetInputs calls the mock function or returns default values.
+func (m *MockV2Client) PostDatasetInputs(ctx context.Context, in *pb.PostDatasetInputsRequest, opts ...grpc.CallOption) (*pb.MultiDatasetInputResponse, error) {
+	if m.PostDatasetInputsFunc != nil {
+		return m.PostDatasetInputsFunc(ctx, in, opts...)
+	}
+	// Default mock behavior
+	return &pb.MultiDatasetInputResponse{}, nil
+}
+
+// DeleteDatasetInputs calls the mock function or returns default values.
+func (m *MockV2Client) DeleteDatasetInputs(ctx context.Context, in *pb.DeleteDatasetInputsRequest, opts ...grpc.CallOption) (*statuspb.BaseResponse, error) {
+	if m.DeleteDatasetInputsFunc != nil {
+		return m.DeleteDatasetInputsFunc(ctx, in, opts...)
+	}
+	// Default mock behavior
+	return &statuspb.BaseResponse{}, nil
+}
+
+// PostDatasetVersions calls the mock function or returns default values.
+func (m *MockV2Client) PostDatasetVersions(ctx context.Context, in *pb.PostDatasetVersionsRequest, opts ...grpc.CallOption) (*pb.MultiDatasetVersionResponse, error) {
+	if m.PostDatasetVersionsFunc != nil {
+		return m.PostDatasetVersionsFunc(ctx, in, opts...)
+	}
+	// Default mock behavior
+	return &pb.MultiDatasetVersionResponse{}, nil
+}
+
+// PostModels calls the mock function or returns default values.
+func (m *MockV2Client) PostModels(ctx context.Context, in *pb.PostModelsRequest, opts ...grpc.CallOption) (*pb.SingleModelResponse, error) {
+	if m.PostModelsFunc != nil {
+		return m.PostModelsFunc(ctx, in, opts...)
+	}
+	// Default mock behavior
+	return &pb.SingleModelResponse{}, nil
+}
+
+// PostModelVersions calls the mock function or returns default values.
+func (m *MockV2Client) PostModelVersions(ctx context.Context, in *pb.PostModelVersionsRequest, opts ...grpc.CallOption) (*pb.SingleModelResponse, error) {
+	if m.PostModelVersionsFunc != nil {
+		return m.PostModelVersionsFunc(ctx, in, opts...)
+	}
+	// Default mock behavior
+	return &pb.SingleModelResponse{}, nil
+}
+
+// GetModelVersion calls the mock function or returns default values.
+func (m *MockV2Client) GetModelVersion(ctx context.Context, in *pb.GetModelVersionRequest, opts ...grpc.CallOption) (*pb.SingleModelVersionResponse, error) {
+	if m.GetModelVersionFunc != nil {
+		return m.GetModelVersionFunc(ctx, in, opts...)
+	}
+	// Default mock behavior
+	return &pb.SingleModelVersionResponse{}, nil
+}
+
+// PostModelVersionEvaluations calls the mock function or returns default values.
+func (m *MockV2Client) PostModelVersionEvaluations(ctx context.Context, in *pb.PostModelVersionEvaluationsRequest, opts ...grpc.CallOption) (*pb.MultiEvalMetricsResponse, error) {
+	if m.PostModelVersionEvaluationsFunc != nil {
+		return m.PostModelVersionEvaluationsFunc(ctx, in, opts...)
+	}
+	// Default mock behavior
+	return &pb.MultiEvalMetricsResponse{}, nil
+}
+
+// GetModelVersionEvaluation calls the mock function or returns default values.
+func (m *MockV2Client) GetModelVersionEvaluation(ctx context.Context, in *pb.GetModelVersionEvaluationRequest, opts ...grpc.CallOption) (*pb.SingleEvalMetricsResponse, error) {
+	if m.GetModelVersionEvaluationFunc != nil {
+		return m.GetModelVersionEvaluationFunc(ctx, in, opts...)
+	}
+	// Default mock behavior
+	return &pb.SingleEvalMetricsResponse{}, nil
+}
+
 // Helper to create a context with expected metadata for testing PostModelOutputs calls
 func ContextWithMockAuth(pat string) context.Context {
 	md := metadata.Pairs("Authorization", "Key "+pat)
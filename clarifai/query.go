@@ -0,0 +1,300 @@
+package clarifai
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	statuspb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Input status names accepted by status:, besides raw status code names.
+var queryStatusCodes = map[string]statuspb.StatusCode{
+	"processed":   statuspb.StatusCode_INPUT_DOWNLOAD_SUCCESS,
+	"pending":     statuspb.StatusCode_INPUT_DOWNLOAD_PENDING,
+	"in_progress": statuspb.StatusCode_INPUT_DOWNLOAD_IN_PROGRESS,
+	"failed":      statuspb.StatusCode_INPUT_DOWNLOAD_FAILED,
+}
+
+// Fields accepted before ':'. Other words ending in ':' (e.g. "note:" or "https:") are plain text.
+var queryFields = map[string]bool{"concept": true, "dataset": true, "status": true, "geo": true, "text": true}
+
+// Radius units of geo:within and the GeoLimit type each maps to.
+var queryGeoUnits = map[string]string{
+	"km":  "withinKilometers",
+	"mi":  "withinMiles",
+	"deg": "withinDegrees",
+	"rad": "withinRadians",
+}
+
+// ParseQuery parses the search syntax of ?query= parameters and search tools into a Query.
+// Terms are separated by whitespace and combined with AND; the AND keyword is optional.
+//
+//	concept:cat                  inputs labelled cat
+//	metadata.source=web          metadata key (nested keys with dots) equal to a value
+//	dataset:train                inputs in a dataset
+//	status:processed             input status: processed, pending, in_progress, failed or a status code name
+//	geo:within(lat,lon,10km)     within a radius (km, mi, deg or rad; km by default)
+//	geo:box(lat1,lon1,lat2,lon2) inside a bounding box
+//	~concept:cat                 rank by a concept instead of filtering by it
+//	red car, text:"red car"      rank by similarity to text (plain words form one text rank)
+//
+// NOT or a leading '-' negates the following term. Values with spaces are quoted. Words with an
+// unknown prefix before ':', such as "note:" or URLs, are plain words. A query of plain words
+// only is the same text search ListInputs always did.
+func ParseQuery(query string) (*pb.Query, error) {
+	terms, err := splitQueryTerms(query)
+	if err != nil {
+		return nil, err
+	}
+	result := &pb.Query{}
+	var words []string
+	negate := false
+	for i, term := range terms {
+		switch term {
+		case "AND":
+			if negate || i == 0 || i == len(terms)-1 || terms[i-1] == "AND" {
+				return nil, fmt.Errorf("invalid query: AND must stand between two terms")
+			}
+			continue
+		case "OR":
+			return nil, fmt.Errorf("invalid query: OR is not supported; terms are always combined with AND")
+		case "NOT":
+			if negate || i == len(terms)-1 {
+				return nil, fmt.Errorf("invalid query: NOT must be followed by a term")
+			}
+			negate = true
+			continue
+		}
+		if strings.HasPrefix(term, "-") && len(term) > 1 {
+			if negate {
+				return nil, fmt.Errorf("invalid query term %q: negated twice", term)
+			}
+			negate, term = true, term[1:]
+		}
+		if err := addQueryTerm(result, term, negate, &words); err != nil {
+			return nil, fmt.Errorf("invalid query term %q: %w", term, err)
+		}
+		negate = false
+	}
+	if len(words) > 0 {
+		text := &pb.Rank{Annotation: &pb.Annotation{Data: &pb.Data{Text: &pb.Text{Raw: strings.Join(words, " ")}}}}
+		result.Ranks = append([]*pb.Rank{text}, result.Ranks...)
+	}
+	if len(result.Filters) == 0 && len(result.Ranks) == 0 {
+		return nil, fmt.Errorf("invalid query: no search terms")
+	}
+	return result, nil
+}
+
+// splitQueryTerms splits at whitespace outside quotes and parentheses.
+func splitQueryTerms(query string) ([]string, error) {
+	var terms []string
+	var current strings.Builder
+	inQuotes, depth := false, 0
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+		case r == '(':
+			depth++
+		case r == ')':
+			if depth == 0 {
+				return nil, fmt.Errorf("invalid query: unbalanced ')'")
+			}
+			depth--
+		case (r == ' ' || r == '\t' || r == '\n') && depth == 0:
+			if current.Len() > 0 {
+				terms = append(terms, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("invalid query: unterminated quote")
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("invalid query: unbalanced '('")
+	}
+	if current.Len() > 0 {
+		terms = append(terms, current.String())
+	}
+	return terms, nil
+}
+
+// addQueryTerm adds one (possibly negated) term to query. Plain words are collected in words.
+func addQueryTerm(query *pb.Query, term string, negate bool, words *[]string) error {
+	rank := strings.HasPrefix(term, "~")
+	if rank {
+		term = term[1:]
+	}
+
+	if strings.HasPrefix(term, "metadata.") {
+		key, value, ok := strings.Cut(strings.TrimPrefix(term, "metadata."), "=")
+		if !ok || key == "" {
+			return fmt.Errorf("expected metadata.<key>=<value>")
+		}
+		if rank {
+			return fmt.Errorf("only concept and text terms can rank")
+		}
+		metadata, err := queryMetadata(key, value)
+		if err != nil {
+			return err
+		}
+		query.Filters = append(query.Filters, &pb.Filter{Negate: negate, Annotation: &pb.Annotation{Data: &pb.Data{Metadata: metadata}}})
+		return nil
+	}
+
+	field, value, ok := strings.Cut(term, ":")
+	if !ok || strings.HasPrefix(term, `"`) || !queryFields[field] {
+		if rank {
+			return fmt.Errorf("~ needs a concept: or text: term")
+		}
+		text := unquoteQueryValue(term)
+		if negate {
+			query.Ranks = append(query.Ranks, &pb.Rank{Negate: true, Annotation: &pb.Annotation{Data: &pb.Data{Text: &pb.Text{Raw: text}}}})
+		} else {
+			*words = append(*words, text)
+		}
+		return nil
+	}
+	value = unquoteQueryValue(value)
+	if value == "" {
+		return fmt.Errorf("missing value")
+	}
+	if rank && field != "concept" && field != "text" {
+		return fmt.Errorf("only concept and text terms can rank")
+	}
+
+	switch field {
+	case "text":
+		query.Ranks = append(query.Ranks, &pb.Rank{Negate: negate, Annotation: &pb.Annotation{Data: &pb.Data{Text: &pb.Text{Raw: value}}}})
+	case "concept":
+		annotation := &pb.Annotation{Data: &pb.Data{Concepts: []*pb.Concept{{Id: value, Value: 1}}}}
+		if rank {
+			query.Ranks = append(query.Ranks, &pb.Rank{Negate: negate, Annotation: annotation})
+		} else {
+			query.Filters = append(query.Filters, &pb.Filter{Negate: negate, Annotation: annotation})
+		}
+	case "dataset":
+		query.Filters = append(query.Filters, &pb.Filter{Negate: negate, Input: &pb.Input{DatasetIds: []string{value}}})
+	case "status":
+		code, ok := queryStatusCodes[strings.ToLower(value)]
+		if !ok {
+			named, known := statuspb.StatusCode_value[strings.ToUpper(value)]
+			if !known {
+				return fmt.Errorf("unknown status %q (use processed, pending, in_progress, failed or a status code name)", value)
+			}
+			code = statuspb.StatusCode(named)
+		}
+		query.Filters = append(query.Filters, &pb.Filter{Negate: negate, Input: &pb.Input{Status: &statuspb.Status{Code: code}}})
+	case "geo":
+		geo, err := queryGeo(value)
+		if err != nil {
+			return err
+		}
+		query.Filters = append(query.Filters, &pb.Filter{Negate: negate, Annotation: &pb.Annotation{Data: &pb.Data{Geo: geo}}})
+	}
+	return nil
+}
+
+// queryMetadata builds the metadata {"a": {"b": value}} for the key path "a.b".
+func queryMetadata(keyPath, raw string) (*structpb.Struct, error) {
+	var value interface{}
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		value = unquoteQueryValue(raw)
+	case raw == "true" || raw == "false":
+		value = raw == "true"
+	case raw == "null":
+		value = nil
+	default:
+		if number, err := strconv.ParseFloat(raw, 64); err == nil {
+			value = number
+		} else {
+			value = raw
+		}
+	}
+	keys := strings.Split(keyPath, ".")
+	for i := len(keys) - 1; i >= 0; i-- {
+		if keys[i] == "" {
+			return nil, fmt.Errorf("empty metadata key in %q", keyPath)
+		}
+		value = map[string]interface{}{keys[i]: value}
+	}
+	return structpb.NewStruct(value.(map[string]interface{}))
+}
+
+// queryGeo parses within(lat,lon,radius[unit]) and box(lat1,lon1,lat2,lon2).
+func queryGeo(value string) (*pb.Geo, error) {
+	name, rest, ok := strings.Cut(value, "(")
+	if !ok || !strings.HasSuffix(rest, ")") {
+		return nil, fmt.Errorf("expected geo:within(lat,lon,radius) or geo:box(lat1,lon1,lat2,lon2)")
+	}
+	args := strings.Split(strings.TrimSuffix(rest, ")"), ",")
+	for i := range args {
+		args[i] = strings.TrimSpace(args[i])
+	}
+	switch name {
+	case "within":
+		if len(args) != 3 {
+			return nil, fmt.Errorf("geo:within takes lat, lon and radius")
+		}
+		point, err := queryGeoPoint(args[0], args[1])
+		if err != nil {
+			return nil, err
+		}
+		radius, unit := args[2], "km"
+		for suffix := range queryGeoUnits {
+			if strings.HasSuffix(radius, suffix) {
+				radius, unit = strings.TrimSuffix(radius, suffix), suffix
+				break
+			}
+		}
+		distance, err := strconv.ParseFloat(strings.TrimSpace(radius), 32)
+		if err != nil || distance <= 0 {
+			return nil, fmt.Errorf("invalid radius %q", args[2])
+		}
+		return &pb.Geo{GeoPoint: point, GeoLimit: &pb.GeoLimit{Type: queryGeoUnits[unit], Value: float32(distance)}}, nil
+	case "box":
+		if len(args) != 4 {
+			return nil, fmt.Errorf("geo:box takes lat1, lon1, lat2 and lon2")
+		}
+		first, err := queryGeoPoint(args[0], args[1])
+		if err != nil {
+			return nil, err
+		}
+		second, err := queryGeoPoint(args[2], args[3])
+		if err != nil {
+			return nil, err
+		}
+		return &pb.Geo{GeoBox: []*pb.GeoBoxedPoint{{GeoPoint: first}, {GeoPoint: second}}}, nil
+	default:
+		return nil, fmt.Errorf("unknown geo function %q (use within or box)", name)
+	}
+}
+
+func queryGeoPoint(rawLat, rawLon string) (*pb.GeoPoint, error) {
+	lat, err := strconv.ParseFloat(rawLat, 32)
+	if err != nil || lat < -90 || lat > 90 {
+		return nil, fmt.Errorf("invalid latitude %q", rawLat)
+	}
+	lon, err := strconv.ParseFloat(rawLon, 32)
+	if err != nil || lon < -180 || lon > 180 {
+		return nil, fmt.Errorf("invalid longitude %q", rawLon)
+	}
+	return &pb.GeoPoint{Latitude: float32(lat), Longitude: float32(lon)}, nil
+}
+
+// unquoteQueryValue strips surrounding double quotes.
+func unquoteQueryValue(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,144 @@
+package clarifai
+
+import (
+	"strings"
+	"testing"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	statuspb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestParseQuery(t *testing.T) {
+	conceptFilter := func(id string, negate bool) *pb.Filter {
+		return &pb.Filter{Negate: negate, Annotation: &pb.Annotation{Data: &pb.Data{Concepts: []*pb.Concept{{Id: id, Value: 1}}}}}
+	}
+	metadataFilter := func(fields map[string]interface{}) *pb.Filter {
+		metadata, err := structpb.NewStruct(fields)
+		if err != nil {
+			t.Fatalf("bad test metadata: %v", err)
+		}
+		return &pb.Filter{Annotation: &pb.Annotation{Data: &pb.Data{Metadata: metadata}}}
+	}
+	textRank := func(text string, negate bool) *pb.Rank {
+		return &pb.Rank{Negate: negate, Annotation: &pb.Annotation{Data: &pb.Data{Text: &pb.Text{Raw: text}}}}
+	}
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected *pb.Query
+	}{
+		{
+			name:     "Plain words are one text rank",
+			query:    "red  sports car",
+			expected: &pb.Query{Ranks: []*pb.Rank{textRank("red sports car", false)}},
+		},
+		{
+			name:     "Unknown fields are plain words",
+			query:    "note: red car https://example.com/a.jpg concept:cat",
+			expected: &pb.Query{Filters: []*pb.Filter{conceptFilter("cat", false)}, Ranks: []*pb.Rank{textRank("note: red car https://example.com/a.jpg", false)}},
+		},
+		{
+			name:     "Concepts with AND and NOT",
+			query:    "concept:cat AND NOT concept:dog -concept:bird",
+			expected: &pb.Query{Filters: []*pb.Filter{conceptFilter("cat", false), conceptFilter("dog", true), conceptFilter("bird", true)}},
+		},
+		{
+			name:  "Metadata values and nested keys",
+			query: `metadata.source=web metadata.info.count=3 metadata.ok=true metadata.label="two words" metadata.id="42"`,
+			expected: &pb.Query{Filters: []*pb.Filter{
+				metadataFilter(map[string]interface{}{"source": "web"}),
+				metadataFilter(map[string]interface{}{"info": map[string]interface{}{"count": 3.0}}),
+				metadataFilter(map[string]interface{}{"ok": true}),
+				metadataFilter(map[string]interface{}{"label": "two words"}),
+				metadataFilter(map[string]interface{}{"id": "42"}),
+			}},
+		},
+		{
+			name:  "Dataset and status",
+			query: "dataset:train status:processed NOT status:INPUT_DOWNLOAD_FAILED",
+			expected: &pb.Query{Filters: []*pb.Filter{
+				{Input: &pb.Input{DatasetIds: []string{"train"}}},
+				{Input: &pb.Input{Status: &statuspb.Status{Code: statuspb.StatusCode_INPUT_DOWNLOAD_SUCCESS}}},
+				{Negate: true, Input: &pb.Input{Status: &statuspb.Status{Code: statuspb.StatusCode_INPUT_DOWNLOAD_FAILED}}},
+			}},
+		},
+		{
+			name:  "Geo radius and box",
+			query: "geo:within(40.5, -74, 10mi) geo:box(1,2,3,4)",
+			expected: &pb.Query{Filters: []*pb.Filter{
+				{Annotation: &pb.Annotation{Data: &pb.Data{Geo: &pb.Geo{
+					GeoPoint: &pb.GeoPoint{Latitude: 40.5, Longitude: -74},
+					GeoLimit: &pb.GeoLimit{Type: "withinMiles", Value: 10},
+				}}}},
+				{Annotation: &pb.Annotation{Data: &pb.Data{Geo: &pb.Geo{GeoBox: []*pb.GeoBoxedPoint{
+					{GeoPoint: &pb.GeoPoint{Latitude: 1, Longitude: 2}},
+					{GeoPoint: &pb.GeoPoint{Latitude: 3, Longitude: 4}},
+				}}}}},
+			}},
+		},
+		{
+			name:  "Ranks combined with filters",
+			query: `sunset ~concept:beach concept:outdoor NOT "city lights" text:"golden hour"`,
+			expected: &pb.Query{
+				Filters: []*pb.Filter{conceptFilter("outdoor", false)},
+				Ranks: []*pb.Rank{
+					textRank("sunset", false),
+					{Annotation: &pb.Annotation{Data: &pb.Data{Concepts: []*pb.Concept{{Id: "beach", Value: 1}}}}},
+					textRank("city lights", true),
+					textRank("golden hour", false),
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := ParseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) error: %v", tc.query, err)
+			}
+			if !proto.Equal(query, tc.expected) {
+				t.Errorf("ParseQuery(%q) = %v, want %v", tc.query, query, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	testCases := []struct {
+		query   string
+		message string
+	}{
+		{"", "no search terms"},
+		{"AND", "AND must stand between two terms"},
+		{"concept:cat AND", "AND must stand between two terms"},
+		{"concept:cat OR concept:dog", "OR is not supported"},
+		{"concept:cat NOT", "NOT must be followed by a term"},
+		{"NOT -concept:cat", "negated twice"},
+		{"~color:red", "~ needs a concept: or text: term"},
+		{"concept:", "missing value"},
+		{"metadata.source", "expected metadata.<key>=<value>"},
+		{"metadata..a=1", "empty metadata key"},
+		{"~dataset:train", "only concept and text terms can rank"},
+		{"status:lost", `unknown status "lost"`},
+		{"geo:within(91,0,1km)", "invalid latitude"},
+		{"geo:within(0,0,-1)", "invalid radius"},
+		{"geo:near(0,0)", `unknown geo function "near"`},
+		{`concept:"cat`, "unterminated quote"},
+		{"geo:within(0,0,1", "unbalanced '('"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			_, err := ParseQuery(tc.query)
+			if err == nil {
+				t.Fatalf("ParseQuery(%q) succeeded, want error containing %q", tc.query, tc.message)
+			}
+			if !strings.Contains(err.Error(), tc.message) {
+				t.Errorf("ParseQuery(%q) error = %q, want it to contain %q", tc.query, err.Error(), tc.message)
+			}
+		})
+	}
+}
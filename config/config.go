@@ -7,23 +7,50 @@ import (
 	"log/slog" // Import slog
 	"os"
 	"strings" // For log level parsing
+	"time"
+
+	"clarifai-mcp-server-local/utils"
 )
 
 // Config holds the application configuration.
 type Config struct {
-	Pat         string     // Clarifai Personal Access Token
-	OutputPath  string     // Directory to save large generated images
-	GrpcAddr    string     // Clarifai gRPC API address
+	Pat           string     // Clarifai Personal Access Token
+	OutputPath    string     // Directory to save large generated images
+	GrpcAddr      string     // Clarifai gRPC API address
 	LogLevel      slog.Level // Use slog.Level type
 	TimeoutSec    int        // gRPC call timeout in seconds
 	DefaultUserID string     // Optional: Default User ID for listing resources
 	DefaultAppID  string     // Optional: Default App ID for listing resources
 	logLevelStr   string     // Temporary storage for the flag string
+
+	ImageReturnMode     string // How generated images are returned: auto, path, inline, both or resource
+	InlineImageMaxBytes int    // In auto mode, images up to this size are returned inline
+	OutputNameTemplate  string // File name template for saved images, see utils.RenderOutputName
+
+	// Output retention, see utils.RetentionPolicy. Zero disables a limit.
+	OutputMaxMB           int64
+	OutputMaxAge          time.Duration
+	OutputMaxFiles        int
+	OutputCleanupInterval time.Duration // How often retention runs after the startup pass
+
+	ReadOnly bool // Refuse tools that create, change or delete Clarifai data
 }
 
+// ImageReturnModes lists the accepted values for -image-return-mode and the per-call return_mode argument.
+var ImageReturnModes = []string{"auto", "path", "inline", "both", "resource"}
+
 // ErrPatMissing indicates the required PAT flag was not provided.
 var ErrPatMissing = errors.New("required flag -pat (Clarifai Personal Access Token) is missing")
 
+// ErrInvalidOutputNameTemplate indicates -output-name-template cannot be rendered.
+var ErrInvalidOutputNameTemplate = errors.New("invalid -output-name-template")
+
+// ErrNegativeRetention indicates one of the -output-max-* or -output-cleanup-interval flags is negative.
+var ErrNegativeRetention = errors.New("output retention limits must not be negative")
+
+// ErrInvalidImageReturnMode indicates -image-return-mode is not one of ImageReturnModes.
+var ErrInvalidImageReturnMode = errors.New("invalid -image-return-mode")
+
 // LoadConfig loads configuration from command-line flags.
 // It returns an error if the required -pat flag is missing.
 func LoadConfig() (*Config, error) {
@@ -41,6 +68,14 @@ func LoadConfig() (*Config, error) {
 	fs.IntVar(&cfg.TimeoutSec, "timeout", 120, "gRPC call timeout in seconds")
 	fs.StringVar(&cfg.DefaultUserID, "default-user-id", "", "Default User ID for listing resources without a specific URI (optional)")
 	fs.StringVar(&cfg.DefaultAppID, "default-app-id", "", "Default App ID for listing resources without a specific URI (optional)")
+	fs.StringVar(&cfg.ImageReturnMode, "image-return-mode", "auto", "How generated images are returned: auto (inline if small, else saved path), path, inline, both, or resource (embedded resource with file URI)")
+	fs.IntVar(&cfg.InlineImageMaxBytes, "inline-image-max-bytes", 10*1024, "In auto mode, largest image returned inline instead of saved to disk")
+	fs.Int64Var(&cfg.OutputMaxMB, "output-max-mb", 0, "Delete the oldest saved outputs once the output path holds more than this many MB (0 = unlimited)")
+	fs.DurationVar(&cfg.OutputMaxAge, "output-max-age", 0, "Delete saved outputs older than this, e.g. 168h (0 = keep forever)")
+	fs.IntVar(&cfg.OutputMaxFiles, "output-max-files", 0, "Keep at most this many saved outputs, deleting the oldest (0 = unlimited)")
+	fs.DurationVar(&cfg.OutputCleanupInterval, "output-cleanup-interval", time.Hour, "How often output retention runs after startup (0 = startup only)")
+	fs.BoolVar(&cfg.ReadOnly, "read-only", false, "Refuse all tools that create, change or delete Clarifai data (uploads, patches, deletes)")
+	fs.StringVar(&cfg.OutputNameTemplate, "output-name-template", utils.DefaultOutputNameTemplate, "File name template for saved images, relative to -output-path. Placeholders: {date} {time} {timestamp} {model} {prompt_slug} {seed} {index} {rand}; use '/' for subdirectories, e.g. '{date}/{model}_{seed}_{index}'")
 
 	// Parse the flags from os.Args[1:]
 	err := fs.Parse(os.Args[1:])
@@ -68,6 +103,25 @@ func LoadConfig() (*Config, error) {
 		cfg.OutputPath = os.TempDir()
 	}
 
+	validMode := false
+	for _, mode := range ImageReturnModes {
+		if cfg.ImageReturnMode == mode {
+			validMode = true
+			break
+		}
+	}
+	if !validMode {
+		return nil, fmt.Errorf("%w %q, expected one of %s", ErrInvalidImageReturnMode, cfg.ImageReturnMode, strings.Join(ImageReturnModes, ", "))
+	}
+
+	if cfg.OutputMaxMB < 0 || cfg.OutputMaxAge < 0 || cfg.OutputMaxFiles < 0 || cfg.OutputCleanupInterval < 0 {
+		return nil, ErrNegativeRetention
+	}
+
+	if err := utils.ValidateOutputNameTemplate(cfg.OutputNameTemplate); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOutputNameTemplate, err)
+	}
+
 	// Basic validation (PAT is required)
 	if cfg.Pat == "" {
 		// fs.Usage() // Optionally print usage for the specific flag set
@@ -76,3 +130,12 @@ func LoadConfig() (*Config, error) {
 
 	return cfg, nil
 }
+
+// RetentionPolicy returns the output retention limits configured by the -output-max-* flags.
+func (c *Config) RetentionPolicy() utils.RetentionPolicy {
+	return utils.RetentionPolicy{
+		MaxTotalBytes: c.OutputMaxMB << 20,
+		MaxAge:        c.OutputMaxAge,
+		MaxFiles:      c.OutputMaxFiles,
+	}
+}
@@ -37,6 +37,8 @@ func TestLoadConfig(t *testing.T) {
 				"-grpc-addr", "localhost:443",
 				"-log-level", "DEBUG",
 				"-timeout", "60",
+				"-image-return-mode", "both",
+				"-inline-image-max-bytes", "2048",
 			},
 			expectedCfg: &Config{
 				Pat:         "test-pat-123",
@@ -45,6 +47,9 @@ func TestLoadConfig(t *testing.T) {
 				LogLevel:    slog.LevelDebug,
 				TimeoutSec:  60,
 				logLevelStr: "DEBUG", // Internal field also set
+
+				ImageReturnMode:     "both",
+				InlineImageMaxBytes: 2048,
 			},
 			expectedError: nil,
 		},
@@ -60,6 +65,9 @@ func TestLoadConfig(t *testing.T) {
 				LogLevel:    slog.LevelInfo,         // Default
 				TimeoutSec:  120,                    // Default
 				logLevelStr: "INFO",                 // Default internal field
+
+				ImageReturnMode:     "auto",    // Default
+				InlineImageMaxBytes: 10 * 1024, // Default
 			},
 			expectedError: nil,
 		},
@@ -84,6 +92,9 @@ func TestLoadConfig(t *testing.T) {
 				LogLevel:    slog.LevelInfo, // Should default to INFO
 				TimeoutSec:  120,
 				logLevelStr: "TRACE",
+
+				ImageReturnMode:     "auto",
+				InlineImageMaxBytes: 10 * 1024,
 			},
 			expectedError: nil,
 		},
@@ -100,9 +111,59 @@ func TestLoadConfig(t *testing.T) {
 				LogLevel:    slog.LevelWarn, // Check WARN level
 				TimeoutSec:  120,
 				logLevelStr: "WARN",
+
+				ImageReturnMode:     "auto",
+				InlineImageMaxBytes: 10 * 1024,
 			},
 			expectedError: nil,
 		},
+		{
+			name: "Read-only mode",
+			args: []string{
+				"-pat", "test-pat-ro",
+				"-read-only",
+			},
+			expectedCfg: &Config{
+				Pat:         "test-pat-ro",
+				OutputPath:  defaultTempDir,
+				GrpcAddr:    "api.clarifai.com:443",
+				LogLevel:    slog.LevelInfo,
+				TimeoutSec:  120,
+				logLevelStr: "INFO",
+
+				ImageReturnMode:     "auto",
+				InlineImageMaxBytes: 10 * 1024,
+				ReadOnly:            true,
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Invalid image return mode",
+			args: []string{
+				"-pat", "test-pat-mode",
+				"-image-return-mode", "email",
+			},
+			expectedCfg:   nil,
+			expectedError: ErrInvalidImageReturnMode,
+		},
+		{
+			name: "Invalid output name template",
+			args: []string{
+				"-pat", "test-pat-template",
+				"-output-name-template", "../{seed}",
+			},
+			expectedCfg:   nil,
+			expectedError: ErrInvalidOutputNameTemplate,
+		},
+		{
+			name: "Negative retention limit",
+			args: []string{
+				"-pat", "test-pat-retention",
+				"-output-max-files", "-1",
+			},
+			expectedCfg:   nil,
+			expectedError: ErrNegativeRetention,
+		},
 		// Note: Testing flag parsing errors (like "-pat") is tricky because
 		// flag.ContinueOnError prints to os.Stderr and doesn't return a distinct error type easily.
 		// We rely on the required -pat check for the main error path.
@@ -143,6 +204,15 @@ func TestLoadConfig(t *testing.T) {
 				if cfg.TimeoutSec != tc.expectedCfg.TimeoutSec {
 					t.Errorf("Expected TimeoutSec '%d', got '%d'", tc.expectedCfg.TimeoutSec, cfg.TimeoutSec)
 				}
+				if cfg.ImageReturnMode != tc.expectedCfg.ImageReturnMode {
+					t.Errorf("Expected ImageReturnMode '%s', got '%s'", tc.expectedCfg.ImageReturnMode, cfg.ImageReturnMode)
+				}
+				if cfg.InlineImageMaxBytes != tc.expectedCfg.InlineImageMaxBytes {
+					t.Errorf("Expected InlineImageMaxBytes '%d', got '%d'", tc.expectedCfg.InlineImageMaxBytes, cfg.InlineImageMaxBytes)
+				}
+				if cfg.ReadOnly != tc.expectedCfg.ReadOnly {
+					t.Errorf("Expected ReadOnly '%v', got '%v'", tc.expectedCfg.ReadOnly, cfg.ReadOnly)
+				}
 			} else if tc.expectedError != nil && err == nil {
 				t.Errorf("Expected error '%v', but got nil config", tc.expectedError)
 			} else if tc.expectedError == nil && err != nil {
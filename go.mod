@@ -4,7 +4,9 @@ go 1.22.12
 
 require (
 	github.com/Clarifai/clarifai-go-grpc v0.0.0-20250408192826-56683635e737
+	github.com/ledongthuc/pdf v0.0.0-20240201131950-da5b75280b06
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/image v0.24.0
 	google.golang.org/grpc v1.71.1
 	google.golang.org/protobuf v1.36.4
 )
@@ -16,7 +18,7 @@ require (
 	github.com/stretchr/objx v0.5.2 // indirect
 	golang.org/x/net v0.34.0 // indirect
 	golang.org/x/sys v0.29.0 // indirect
-	golang.org/x/text v0.21.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
 	google.golang.org/genproto v0.0.0-20201106154455-f9bfe239b0ba // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
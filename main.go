@@ -2,17 +2,15 @@ package main
 
 import (
 	"context"
-	"fmt"       // Re-added for Fprintf
-	"log/slog"  // Import slog
-	"math/rand" // Added for filename generation
-	"os"        // Needed for joining paths
-	"time" // Added for filename generation
+	"fmt"      // Re-added for Fprintf
+	"log/slog" // Import slog
+	"os"       // Needed for joining paths
 
 	"clarifai-mcp-server-local/clarifai" // Import the new clarifai package
 	"clarifai-mcp-server-local/config"   // Import the new config package
 	"clarifai-mcp-server-local/mcp"      // Import the new mcp package
 	"clarifai-mcp-server-local/tools"    // Import the new tools package
-	// "clarifai-mcp-server-local/utils" // No longer needed directly here
+	"clarifai-mcp-server-local/utils"
 	// gRPC related imports (Some might be removed if no longer directly used here)
 	// "google.golang.org/grpc" // No longer needed directly here
 	// "google.golang.org/grpc/codes" // No longer needed directly here
@@ -126,16 +124,15 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Enforce output retention at startup and periodically (no-op unless a limit is configured)
+	utils.StartRetention(ctx, cfg.OutputPath, cfg.RetentionPolicy(), cfg.OutputCleanupInterval)
+
 	// Use the StdioServer implementation from the mcp package
 	server := mcp.NewStdioServer(os.Stdin, os.Stdout)
 	server.Start(ctx) // Start reader/writer goroutines
 
 	// Main processing loop (reading from channel)
 	go func() {
-		// Seed random number generator for filenames (used by utils.SaveImage)
-		// TODO: Consider moving seeding to main or using crypto/rand for uniqueness if critical
-		rand.Seed(time.Now().UnixNano())
-
 		for request := range server.ReadChannel() {
 			// Handle the request using the tools handler
 			responsePtr := toolHandler.HandleRequest(request)
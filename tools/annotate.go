@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+)
+
+// annotateArgumentSchema returns the JSON schema property for the optional 'annotate' argument.
+func annotateArgumentSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"annotate": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Optional: Draw detected bounding boxes, labels and segmentation masks onto the input image, save it to the output path and return it as image content. Respects min_confidence and top_k.",
+		},
+	}
+}
+
+// annotatedImageContent draws the regions of the first output onto imageBytes, saves the result
+// to the configured output directory and returns the MCP content items describing it.
+func (h *Handler) annotatedImageContent(imageBytes []byte, resp *pb.MultiOutputResponse, opts formatOptions, errCtx map[string]string) ([]map[string]any, *mcp.RPCError) {
+	regions := selectRegions(resp.GetOutputs()[0].GetData().GetRegions(), opts)
+	if len(regions) == 0 {
+		h.logger.Debug("No regions to annotate")
+		return []map[string]any{{"type": "text", "text": "No regions matched the filters; annotated image was not created."}}, nil
+	}
+
+	img, _, err := utils.DecodeImage(imageBytes)
+	if err != nil {
+		h.logger.Error("Failed to decode input image for annotation", "error", err)
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to annotate image: %v", err), Data: errCtx}
+	}
+
+	annotations := make([]utils.RegionAnnotation, 0, len(regions))
+	for _, region := range regions {
+		annotation := utils.RegionAnnotation{
+			BoundingBox: region.GetRegionInfo().GetBoundingBox(),
+			Mask:        region.GetRegionInfo().GetMask().GetImage().GetBase64(),
+		}
+		if label := regionLabel(region); label != "" {
+			annotation.Label = fmt.Sprintf("%s %.2f", label, regionConfidence(region))
+		}
+		annotations = append(annotations, annotation)
+	}
+
+	annotated, err := utils.AnnotateRegions(img, annotations)
+	if err != nil {
+		h.logger.Error("Failed to draw annotations", "error", err)
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to annotate image: %v", err), Data: errCtx}
+	}
+	pngBytes, err := utils.EncodePNG(annotated)
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to annotate image: %v", err), Data: errCtx}
+	}
+
+	content := []map[string]any{
+		{"type": "image", "data": base64.StdEncoding.EncodeToString(pngBytes), "mimeType": "image/png"},
+	}
+	if h.outputPath != "" {
+		savedPath, saveErr := utils.SaveAnnotatedImage(h.outputPath, pngBytes)
+		if saveErr != nil {
+			h.logger.Error("Failed to save annotated image", "error", saveErr)
+			return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to save annotated image to disk: %v", saveErr), Data: errCtx}
+		}
+		content = append(content, map[string]any{"type": "text", "text": "Annotated image saved to: " + savedPath})
+	}
+	h.logger.Debug("Annotated image created", "regions", len(annotations), "size_bytes", len(pngBytes))
+	return content, nil
+}
@@ -0,0 +1,350 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const minPolygonPoints = 3
+
+// annotationArgumentSchema returns the properties describing an annotation's labels and region.
+// Without a region the concepts label the whole input.
+func annotationArgumentSchema() map[string]interface{} {
+	point := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"row": map[string]interface{}{"type": "number"},
+			"col": map[string]interface{}{"type": "number"},
+		},
+		"required": []string{"row", "col"},
+	}
+	return map[string]interface{}{
+		"concepts": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": "Concept IDs the annotation labels as present.",
+		},
+		"negative_concepts": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": "Optional: Concept IDs the annotation labels as absent.",
+		},
+		"bounding_box": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"top_row":    map[string]interface{}{"type": "number"},
+				"left_col":   map[string]interface{}{"type": "number"},
+				"bottom_row": map[string]interface{}{"type": "number"},
+				"right_col":  map[string]interface{}{"type": "number"},
+			},
+			"required":    []string{"top_row", "left_col", "bottom_row", "right_col"},
+			"description": "Optional: Box region in coordinates normalized to 0-1 of the image height (rows) and width (cols).",
+		},
+		"polygon": map[string]interface{}{
+			"type":        "array",
+			"items":       point,
+			"description": fmt.Sprintf("Optional: Polygon region of at least %d points, normalized to 0-1 like bounding_box.", minPolygonPoints),
+		},
+		"span": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"char_start": map[string]interface{}{"type": "integer"},
+				"char_end":   map[string]interface{}{"type": "integer"},
+				"raw_text":   map[string]interface{}{"type": "string"},
+			},
+			"required":    []string{"char_start", "char_end"},
+			"description": "Optional: Text span region of a text input; char_end is exclusive.",
+		},
+	}
+}
+
+// parseAnnotationData builds the annotation data from the concept and region arguments.
+// Concepts go on the region when one of bounding_box, polygon or span is given, else on the input.
+func parseAnnotationData(args map[string]interface{}) (*pb.Data, *mcp.RPCError) {
+	opts, rpcErr := parseInputOptions(map[string]interface{}{
+		"concepts":          args["concepts"],
+		"negative_concepts": args["negative_concepts"],
+	})
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if len(opts.Concepts) == 0 {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: give at least one of 'concepts' or 'negative_concepts'"}
+	}
+
+	var regionInfo *pb.RegionInfo
+	setRegion := func(name string, info *pb.RegionInfo) *mcp.RPCError {
+		if regionInfo != nil {
+			return invalidParam(name, "cannot be combined with another region; give one of 'bounding_box', 'polygon' or 'span'")
+		}
+		regionInfo = info
+		return nil
+	}
+	if raw, present := args["bounding_box"]; present && raw != nil {
+		box, rpcErr := parseBoundingBox(raw)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		if rpcErr := setRegion("bounding_box", &pb.RegionInfo{BoundingBox: box}); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	if raw, present := args["polygon"]; present && raw != nil {
+		polygon, rpcErr := parsePolygon(raw)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		if rpcErr := setRegion("polygon", &pb.RegionInfo{Polygon: polygon}); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	if raw, present := args["span"]; present && raw != nil {
+		span, rpcErr := parseSpan(raw)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		if rpcErr := setRegion("span", &pb.RegionInfo{Span: span}); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+
+	if regionInfo == nil {
+		return &pb.Data{Concepts: opts.Concepts}, nil
+	}
+	return &pb.Data{Regions: []*pb.Region{{RegionInfo: regionInfo, Data: &pb.Data{Concepts: opts.Concepts}}}}, nil
+}
+
+// normalizedCoordinate reads a required coordinate of obj that must lie within 0-1.
+func normalizedCoordinate(obj map[string]interface{}, key, param string) (float32, *mcp.RPCError) {
+	value, ok, rpcErr := floatArg(obj, key)
+	if rpcErr != nil {
+		return 0, invalidParam(param, "must be a number")
+	}
+	if !ok {
+		return 0, invalidParam(param, "is required")
+	}
+	if value < 0 || value > 1 {
+		return 0, invalidParam(param, fmt.Sprintf("must be normalized to 0-1, got %g (divide pixel coordinates by the image size)", value))
+	}
+	return float32(value), nil
+}
+
+func parseBoundingBox(raw interface{}) (*pb.BoundingBox, *mcp.RPCError) {
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, invalidParam("bounding_box", "must be an object with top_row, left_col, bottom_row and right_col")
+	}
+	var coords [4]float32
+	for i, key := range []string{"top_row", "left_col", "bottom_row", "right_col"} {
+		value, rpcErr := normalizedCoordinate(obj, key, "bounding_box."+key)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		coords[i] = value
+	}
+	box := &pb.BoundingBox{TopRow: coords[0], LeftCol: coords[1], BottomRow: coords[2], RightCol: coords[3]}
+	if box.TopRow >= box.BottomRow || box.LeftCol >= box.RightCol {
+		return nil, invalidParam("bounding_box", "must have top_row < bottom_row and left_col < right_col")
+	}
+	return box, nil
+}
+
+func parsePolygon(raw interface{}) (*pb.Polygon, *mcp.RPCError) {
+	list, ok := raw.([]interface{})
+	if !ok || len(list) < minPolygonPoints {
+		return nil, invalidParam("polygon", fmt.Sprintf("must be a list of at least %d points", minPolygonPoints))
+	}
+	polygon := &pb.Polygon{}
+	for i, item := range list {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, invalidParam(fmt.Sprintf("polygon[%d]", i), "must be an object with row and col")
+		}
+		row, rpcErr := normalizedCoordinate(obj, "row", fmt.Sprintf("polygon[%d].row", i))
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		col, rpcErr := normalizedCoordinate(obj, "col", fmt.Sprintf("polygon[%d].col", i))
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		polygon.Points = append(polygon.Points, &pb.Point{Row: row, Col: col})
+	}
+	return polygon, nil
+}
+
+func parseSpan(raw interface{}) (*pb.Span, *mcp.RPCError) {
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, invalidParam("span", "must be an object with char_start and char_end")
+	}
+	start, okStart, rpcErr := intArg(obj, "char_start")
+	if rpcErr != nil || !okStart || start < 0 {
+		return nil, invalidParam("span.char_start", "must be a non-negative integer")
+	}
+	end, okEnd, rpcErr := intArg(obj, "char_end")
+	if rpcErr != nil || !okEnd || end <= start {
+		return nil, invalidParam("span.char_end", "must be an integer greater than char_start")
+	}
+	span := &pb.Span{CharStart: uint32(start), CharEnd: uint32(end)}
+	if rawText, present := obj["raw_text"]; present && rawText != nil {
+		text, ok := rawText.(string)
+		if !ok {
+			return nil, invalidParam("span.raw_text", "must be a string")
+		}
+		if len([]rune(text)) != end-start {
+			return nil, invalidParam("span.raw_text", fmt.Sprintf("has %d characters but the span covers %d", len([]rune(text)), end-start))
+		}
+		span.RawText = text
+	}
+	return span, nil
+}
+
+// annotationIDArgs reads the required input_id and, when wantAnnotation is set, annotation_id.
+func annotationIDArgs(args map[string]interface{}, wantAnnotation bool) (string, string, *mcp.RPCError) {
+	inputID, ok := args["input_id"].(string)
+	if !ok || !clarifaiIDPattern.MatchString(inputID) {
+		return "", "", &mcp.RPCError{Code: -32602, Message: "Invalid params: missing or invalid 'input_id'"}
+	}
+	annotationID, _ := args["annotation_id"].(string)
+	if (wantAnnotation || annotationID != "") && !clarifaiIDPattern.MatchString(annotationID) {
+		return "", "", &mcp.RPCError{Code: -32602, Message: "Invalid params: missing or invalid 'annotation_id'"}
+	}
+	return inputID, annotationID, nil
+}
+
+// callCreateAnnotation labels an input, or a region of it, with concepts.
+func (h *Handler) callCreateAnnotation(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callCreateAnnotation tool")
+
+	inputID, annotationID, rpcErr := annotationIDArgs(args, false)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	data, rpcErr := parseAnnotationData(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":    "create_annotation",
+		"inputID": inputID,
+		"userID":  userAppIDSet.UserId,
+		"appID":   userAppIDSet.AppId,
+	}
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, rpcErr
+	}
+	defer cancel()
+	created, err := h.clarifaiClient.PostAnnotations(ctx, userAppIDSet, []*pb.Annotation{{Id: annotationID, InputId: inputID, Data: data}}, h.logger)
+	if err != nil {
+		return nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+	if len(created) == 0 {
+		return nil, &mcp.RPCError{Code: -32000, Message: "Clarifai returned no annotation", Data: errCtx}
+	}
+	h.logger.Info("Created annotation", "input_id", inputID, "annotation_id", created[0].GetId())
+	return protoResult(fmt.Sprintf("Created annotation %s on input %s.", created[0].GetId(), inputID), created[0], errCtx)
+}
+
+// callUpdateAnnotation changes the concepts or region of an existing annotation.
+func (h *Handler) callUpdateAnnotation(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callUpdateAnnotation tool")
+
+	inputID, annotationID, rpcErr := annotationIDArgs(args, true)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	action := patchActionOverwrite
+	if raw, present := args["action"]; present && raw != nil {
+		action, _ = raw.(string)
+		if action != patchActionMerge && action != patchActionOverwrite && action != patchActionRemove {
+			return nil, invalidParam("action", "must be 'merge', 'overwrite' or 'remove'")
+		}
+	}
+	data, rpcErr := parseAnnotationData(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":         "update_annotation",
+		"inputID":      inputID,
+		"annotationID": annotationID,
+		"action":       action,
+		"userID":       userAppIDSet.UserId,
+		"appID":        userAppIDSet.AppId,
+	}
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, rpcErr
+	}
+	defer cancel()
+	updated, err := h.clarifaiClient.PatchAnnotations(ctx, userAppIDSet, []*pb.Annotation{{Id: annotationID, InputId: inputID, Data: data}}, action, h.logger)
+	if err != nil {
+		return nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+	h.logger.Info("Updated annotation", "input_id", inputID, "annotation_id", annotationID, "action", action)
+	text := fmt.Sprintf("Updated annotation %s on input %s (%s).", annotationID, inputID, action)
+	if len(updated) == 0 {
+		return map[string]interface{}{"content": []map[string]any{{"type": "text", "text": text}}}, nil
+	}
+	return protoResult(text, updated[0], errCtx)
+}
+
+// callDeleteAnnotation deletes one annotation of an input.
+func (h *Handler) callDeleteAnnotation(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callDeleteAnnotation tool")
+
+	inputID, annotationID, rpcErr := annotationIDArgs(args, true)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":         "delete_annotation",
+		"inputID":      inputID,
+		"annotationID": annotationID,
+		"userID":       userAppIDSet.UserId,
+		"appID":        userAppIDSet.AppId,
+	}
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, rpcErr
+	}
+	defer cancel()
+	if err := h.clarifaiClient.DeleteAnnotation(ctx, userAppIDSet, inputID, annotationID, h.logger); err != nil {
+		return nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+	h.logger.Info("Deleted annotation", "input_id", inputID, "annotation_id", annotationID)
+	return map[string]interface{}{
+		"content": []map[string]any{{"type": "text", "text": fmt.Sprintf("Deleted annotation %s of input %s.", annotationID, inputID)}},
+	}, nil
+}
+
+// protoResult returns a summary line plus a Clarifai resource, such as the annotation created, as JSON.
+func protoResult(text string, message proto.Message, errCtx map[string]string) (interface{}, *mcp.RPCError) {
+	messageJSON, err := protojson.MarshalOptions{Indent: "  "}.Marshal(message)
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to marshal result: %v", err), Data: errCtx}
+	}
+	return map[string]interface{}{
+		"content": []map[string]any{
+			{"type": "text", "text": text},
+			{"type": "text", "text": string(messageJSON)},
+		},
+	}, nil
+}
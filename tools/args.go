@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"clarifai-mcp-server-local/mcp"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Tool arguments arrive as decoded JSON, so numbers are float64 and lists are []interface{}.
+// These helpers centralise the type assertions and produce consistent -32602 errors.
+
+// invalidParam builds the standard invalid-params error for a tool argument.
+func invalidParam(name, reason string) *mcp.RPCError {
+	return &mcp.RPCError{Code: -32602, Message: fmt.Sprintf("Invalid params: '%s' %s", name, reason)}
+}
+
+// floatArg reads an optional numeric argument. ok is false when the argument is absent.
+func floatArg(args map[string]interface{}, name string) (value float64, ok bool, rpcErr *mcp.RPCError) {
+	raw, present := args[name]
+	if !present || raw == nil {
+		return 0, false, nil
+	}
+	switch v := raw.(type) {
+	case float64:
+		return v, true, nil
+	case float32:
+		return float64(v), true, nil
+	case int:
+		return float64(v), true, nil
+	case int64:
+		return float64(v), true, nil
+	default:
+		return 0, false, invalidParam(name, "must be a number")
+	}
+}
+
+// intArg reads an optional integer argument. Fractional values are rejected.
+func intArg(args map[string]interface{}, name string) (value int, ok bool, rpcErr *mcp.RPCError) {
+	f, ok, rpcErr := floatArg(args, name)
+	if rpcErr != nil || !ok {
+		return 0, ok, rpcErr
+	}
+	if f != float64(int(f)) {
+		return 0, false, invalidParam(name, "must be an integer")
+	}
+	return int(f), true, nil
+}
+
+// boolArg reads an optional boolean argument, returning false when absent.
+func boolArg(args map[string]interface{}, name string) (bool, *mcp.RPCError) {
+	raw, present := args[name]
+	if !present || raw == nil {
+		return false, nil
+	}
+	v, ok := raw.(bool)
+	if !ok {
+		return false, invalidParam(name, "must be a boolean")
+	}
+	return v, nil
+}
+
+// mergeProperties combines JSON schema property maps so shared argument groups can be
+// attached to several tool definitions. Later maps win on key collisions.
+func mergeProperties(props ...map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for _, p := range props {
+		for k, v := range p {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// stringListArg reads an optional list of strings. A single comma-separated string is also accepted.
+// Empty entries are dropped.
+func stringListArg(args map[string]interface{}, name string) ([]string, *mcp.RPCError) {
+	raw, present := args[name]
+	if !present || raw == nil {
+		return nil, nil
+	}
+	var values []string
+	switch v := raw.(type) {
+	case string:
+		values = strings.Split(v, ",")
+	case []string:
+		values = v
+	case []interface{}:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, invalidParam(name, "must be a list of strings")
+			}
+			values = append(values, s)
+		}
+	default:
+		return nil, invalidParam(name, "must be a list of strings")
+	}
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result, nil
+}
+
+// idArg reads a required Clarifai ID argument such as dataset_id or model_id.
+func idArg(args map[string]interface{}, name string) (string, *mcp.RPCError) {
+	id, _ := args[name].(string)
+	if id == "" {
+		return "", &mcp.RPCError{Code: -32602, Message: fmt.Sprintf("Invalid params: missing or invalid '%s'", name)}
+	}
+	if !clarifaiIDPattern.MatchString(id) {
+		return "", invalidParam(name, "must be 1-255 letters, digits, '-' or '_'")
+	}
+	return id, nil
+}
+
+// structArg reads an optional JSON object argument, such as metadata, as a protobuf Struct.
+func structArg(args map[string]interface{}, name string) (*structpb.Struct, *mcp.RPCError) {
+	raw, present := args[name]
+	if !present || raw == nil {
+		return nil, nil
+	}
+	fields, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, invalidParam(name, "must be an object")
+	}
+	value, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, invalidParam(name, fmt.Sprintf("is not valid JSON: %v", err))
+	}
+	return value, nil
+}
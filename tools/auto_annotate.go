@@ -0,0 +1,541 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"clarifai-mcp-server-local/clarifai"
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	statuspb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	defaultAutoAnnotateConfidence = 0.5
+	autoAnnotateBatchSize         = 32 // Inputs per PostModelOutputs call
+	defaultAutoAnnotateMaxInputs  = 100
+	maxAutoAnnotateMaxInputs      = 1000
+	// autoAnnotateInfoKey tags the annotations auto_annotate creates with the model (user/app/model)
+	// in their annotation_info, so later runs skip the inputs that model already annotated.
+	autoAnnotateInfoKey = "auto_annotate_model"
+)
+
+// Annotation statuses auto_annotate can give the annotations it creates.
+var autoAnnotateStatuses = map[string]statuspb.StatusCode{
+	"awaiting_review": statuspb.StatusCode_ANNOTATION_AWAITING_REVIEW,
+	"pending":         statuspb.StatusCode_ANNOTATION_PENDING,
+	"success":         statuspb.StatusCode_ANNOTATION_SUCCESS,
+}
+
+// AutoAnnotateReport summarises an auto_annotate run.
+type AutoAnnotateReport struct {
+	ModelID          string         `json:"modelId"`
+	AnnotationStatus string         `json:"annotationStatus"`
+	DryRun           bool           `json:"dryRun,omitempty"`
+	Inputs           int            `json:"inputs"`
+	AnnotatedInputs  int            `json:"annotatedInputs"`
+	Annotations      int            `json:"annotations"`
+	PerConcept       map[string]int `json:"perConcept"`
+	NotFound         []string       `json:"notFound,omitempty"`
+	AlreadyAnnotated int            `json:"alreadyAnnotated,omitempty"` // Inputs skipped because the model annotated them before
+	Truncated        bool           `json:"truncated,omitempty"`        // More inputs matched than max_inputs
+	NextOffset       int            `json:"nextOffset,omitempty"`       // Pass as 'offset' to continue a truncated run
+	Failures         []InputFailure `json:"failures,omitempty"`
+}
+
+// InputFailure records an input a tool could not process.
+type InputFailure struct {
+	InputID string `json:"inputId"`
+	Error   string `json:"error"`
+}
+
+// autoAnnotateArgumentSchema returns the JSON schema properties of auto_annotate.
+func autoAnnotateArgumentSchema() map[string]interface{} {
+	return mergeProperties(map[string]interface{}{
+		"model_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Model to run. Defaults to general-image-detection.",
+		},
+		"model_user_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Owner of the model. Defaults like model lookups of the inference tools.",
+		},
+		"model_app_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: App of the model. Defaults like model lookups of the inference tools.",
+		},
+		"input_ids": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": "Optional: IDs of the inputs to annotate. Give either this or 'dataset_id'/'query'.",
+		},
+		"dataset_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Annotate the inputs of this dataset.",
+		},
+		"concepts": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": "Optional: Only keep predictions of these concepts (IDs or names). Defaults to all.",
+		},
+		"min_confidence": map[string]interface{}{
+			"type":        "number",
+			"description": fmt.Sprintf("Optional: Only keep predictions with at least this confidence (0-1). Defaults to %g.", defaultAutoAnnotateConfidence),
+		},
+		"annotation_status": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{"awaiting_review", "pending", "success"},
+			"description": "Optional: Status of the new annotations. Defaults to 'awaiting_review' so they can be reviewed before use.",
+		},
+		"max_inputs": map[string]interface{}{
+			"type":        "integer",
+			"description": fmt.Sprintf("Optional: Annotate at most this many inputs (max %d). Defaults to %d.", maxAutoAnnotateMaxInputs, defaultAutoAnnotateMaxInputs),
+		},
+		"offset": map[string]interface{}{
+			"type":        "integer",
+			"description": "Optional: With 'dataset_id'/'query', pass over this many matching inputs first. A run stopped by max_inputs returns the offset to continue from as nextOffset. Defaults to 0.",
+		},
+		"dry_run": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Optional: Run the model and report the annotations without creating them. Defaults to false.",
+		},
+	}, inputQueryArgumentSchema("Optional: Annotate the inputs matching this search query."), appContextArgumentSchema())
+}
+
+// callAutoAnnotate pre-labels inputs: it runs a model over them and stores the predictions
+// above a confidence threshold as annotations, by default awaiting review.
+func (h *Handler) callAutoAnnotate(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callAutoAnnotate tool")
+
+	ids, rpcErr := stringListArg(args, "input_ids")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	for _, id := range ids {
+		if !clarifaiIDPattern.MatchString(id) {
+			return nil, invalidParam("input_ids", fmt.Sprintf("contains invalid input ID %q", id))
+		}
+	}
+	query, rpcErr := parseDatasetQuery(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if (len(ids) == 0) == (query == nil) {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: give either 'input_ids' or 'dataset_id'/'query', not both"}
+	}
+	conceptFilter, rpcErr := stringListArg(args, "concepts")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	minConfidence := defaultAutoAnnotateConfidence
+	if value, ok, rpcErr := floatArg(args, "min_confidence"); rpcErr != nil {
+		return nil, rpcErr
+	} else if ok {
+		if value < 0 || value > 1 {
+			return nil, invalidParam("min_confidence", "must be between 0 and 1")
+		}
+		minConfidence = value
+	}
+	statusName := "awaiting_review"
+	if raw, present := args["annotation_status"]; present && raw != nil {
+		statusName, _ = raw.(string)
+		if _, ok := autoAnnotateStatuses[statusName]; !ok {
+			return nil, invalidParam("annotation_status", "must be 'awaiting_review', 'pending' or 'success'")
+		}
+	}
+	maxInputs := defaultAutoAnnotateMaxInputs
+	if value, ok, rpcErr := intArg(args, "max_inputs"); rpcErr != nil {
+		return nil, rpcErr
+	} else if ok {
+		if value < 1 || value > maxAutoAnnotateMaxInputs {
+			return nil, invalidParam("max_inputs", fmt.Sprintf("must be between 1 and %d", maxAutoAnnotateMaxInputs))
+		}
+		maxInputs = value
+	}
+	if len(ids) > maxInputs {
+		return nil, invalidParam("input_ids", fmt.Sprintf("lists %d inputs, more than max_inputs (%d)", len(ids), maxInputs))
+	}
+	offset := 0
+	if value, ok, rpcErr := intArg(args, "offset"); rpcErr != nil {
+		return nil, rpcErr
+	} else if ok {
+		if value < 0 {
+			return nil, invalidParam("offset", "must not be negative")
+		}
+		if len(ids) > 0 {
+			return nil, invalidParam("offset", "can only be used with 'dataset_id'/'query'")
+		}
+		offset = value
+	}
+	dryRun, rpcErr := boolArg(args, "dry_run")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	modelUserID, _ := args["model_user_id"].(string)
+	modelAppID, _ := args["model_app_id"].(string)
+	modelID, _ := args["model_id"].(string)
+	modelUserID, modelAppID, modelID = h.resolveDetectionModelIDs(modelUserID, modelAppID, modelID)
+	errCtx := map[string]string{
+		"tool":    "auto_annotate",
+		"userID":  userAppIDSet.UserId,
+		"appID":   userAppIDSet.AppId,
+		"modelID": modelID,
+	}
+
+	report := AutoAnnotateReport{ModelID: modelID, AnnotationStatus: statusName, DryRun: dryRun, PerConcept: map[string]int{}}
+	modelRef := modelUserID + "/" + modelAppID + "/" + modelID
+	var inputs []*pb.Input
+	if len(ids) > 0 {
+		inputs, report.NotFound, rpcErr = h.inputsByID(userAppIDSet, ids, errCtx)
+		if rpcErr == nil {
+			inputs, report.AlreadyAnnotated, rpcErr = h.withoutModelAnnotations(userAppIDSet, inputs, modelRef, errCtx)
+		}
+	} else {
+		inputs, report.AlreadyAnnotated, report.NextOffset, rpcErr = h.searchUnannotatedInputs(userAppIDSet, query, offset, maxInputs, modelRef, errCtx)
+		report.Truncated = report.NextOffset > 0
+	}
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	report.Inputs = len(inputs)
+
+	modelIDSet := &pb.UserAppIDSet{UserId: modelUserID, AppId: modelAppID}
+	status := &statuspb.Status{Code: autoAnnotateStatuses[statusName]}
+	info, err := structpb.NewStruct(map[string]interface{}{autoAnnotateInfoKey: modelRef})
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to build annotation info: %v", err), Data: errCtx}
+	}
+	annotatedInputs := map[string]bool{}
+	for start := 0; start < len(inputs); start += autoAnnotateBatchSize {
+		end := start + autoAnnotateBatchSize
+		if end > len(inputs) {
+			end = len(inputs)
+		}
+		batch := inputs[start:end]
+		outputs, err := h.predictInputs(modelIDSet, modelID, batch)
+		if err != nil {
+			h.logger.Warn("Prediction failed", "model_id", modelID, "inputs", len(batch), "error", err)
+			for _, input := range batch {
+				report.Failures = append(report.Failures, InputFailure{InputID: input.GetId(), Error: err.Error()})
+			}
+			continue
+		}
+
+		var annotations []*pb.Annotation
+		for i, input := range batch {
+			output := outputs[i]
+			if output == nil || (output.GetStatus() != nil && output.GetStatus().GetCode() != statuspb.StatusCode_SUCCESS) {
+				report.Failures = append(report.Failures, InputFailure{InputID: input.GetId(), Error: fmt.Sprintf("no prediction: %s", output.GetStatus().GetDescription())})
+				continue
+			}
+			annotations = append(annotations, predictionAnnotations(input.GetId(), output.GetData(), conceptFilter, float32(minConfidence), status, info)...)
+		}
+		if len(annotations) == 0 {
+			continue
+		}
+		if !dryRun {
+			ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+			if rpcErr != nil {
+				rpcErr.Data = errCtx
+				return nil, rpcErr
+			}
+			_, err := h.clarifaiClient.PostAnnotations(ctx, userAppIDSet, annotations, h.logger)
+			cancel()
+			if err != nil {
+				h.logger.Warn("Creating annotations failed", "annotations", len(annotations), "error", err)
+				failed := map[string]bool{}
+				for _, annotation := range annotations {
+					if !failed[annotation.GetInputId()] {
+						failed[annotation.GetInputId()] = true
+						report.Failures = append(report.Failures, InputFailure{InputID: annotation.GetInputId(), Error: fmt.Sprintf("creating annotations failed: %v", err)})
+					}
+				}
+				continue
+			}
+		}
+		for _, annotation := range annotations {
+			annotatedInputs[annotation.GetInputId()] = true
+			report.Annotations++
+			for _, concept := range annotationConcepts(annotation) {
+				report.PerConcept[concept.GetId()]++
+			}
+		}
+	}
+	report.AnnotatedInputs = len(annotatedInputs)
+	h.logger.Info("Auto-annotation finished", "model_id", modelID, "inputs", report.Inputs, "annotations", report.Annotations, "failures", len(report.Failures), "dry_run", dryRun)
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to marshal report: %v", err), Data: errCtx}
+	}
+	return map[string]interface{}{
+		"content": []map[string]any{
+			{"type": "text", "text": autoAnnotateSummary(report)},
+			{"type": "text", "text": string(reportJSON)},
+		},
+	}, nil
+}
+
+// searchUnannotatedInputs pages through the inputs matching query, starting after the first
+// offset matches, until it has limit inputs without an annotation from modelRef. It returns them
+// with the number of annotated inputs passed over and, if more matches follow, the offset of the
+// first match not looked at. The offset counts every match looked at, so a run continued from it
+// moves on even past inputs that got no annotation, such as those without a confident prediction.
+func (h *Handler) searchUnannotatedInputs(userAppIDSet *pb.UserAppIDSet, query *pb.Query, offset, limit int, modelRef string, errCtx map[string]string) ([]*pb.Input, int, int, *mcp.RPCError) {
+	var inputs []*pb.Input
+	skipped := 0
+	position := offset
+	for {
+		ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+		if rpcErr != nil {
+			rpcErr.Data = errCtx
+			return nil, 0, 0, rpcErr
+		}
+		page := uint32(position/searchPageSize) + 1
+		pageInputs, err := h.clarifaiClient.SearchInputs(ctx, userAppIDSet, query, &pb.Pagination{Page: page, PerPage: searchPageSize}, h.logger)
+		cancel()
+		if err != nil {
+			return nil, 0, 0, utils.HandleApiError(err, errCtx, h.logger)
+		}
+		candidates := pageInputs[min(position%searchPageSize, len(pageInputs)):]
+		annotated, rpcErr := h.modelAnnotatedInputs(userAppIDSet, candidates, modelRef, errCtx)
+		if rpcErr != nil {
+			return nil, 0, 0, rpcErr
+		}
+		for _, input := range candidates {
+			if len(inputs) == limit {
+				return inputs, skipped, position, nil
+			}
+			position++
+			if annotated[input.GetId()] {
+				skipped++
+				continue
+			}
+			inputs = append(inputs, input)
+		}
+		if len(pageInputs) < searchPageSize {
+			return inputs, skipped, 0, nil
+		}
+	}
+}
+
+// withoutModelAnnotations drops the inputs that already have an annotation auto_annotate created
+// with modelRef, and returns the rest with the number dropped.
+func (h *Handler) withoutModelAnnotations(userAppIDSet *pb.UserAppIDSet, inputs []*pb.Input, modelRef string, errCtx map[string]string) ([]*pb.Input, int, *mcp.RPCError) {
+	annotated, rpcErr := h.modelAnnotatedInputs(userAppIDSet, inputs, modelRef, errCtx)
+	if rpcErr != nil {
+		return nil, 0, rpcErr
+	}
+	if len(annotated) == 0 {
+		return inputs, 0, nil
+	}
+	var kept []*pb.Input
+	for _, input := range inputs {
+		if !annotated[input.GetId()] {
+			kept = append(kept, input)
+		}
+	}
+	return kept, len(inputs) - len(kept), nil
+}
+
+// modelAnnotatedInputs returns the IDs of the inputs that have an annotation auto_annotate
+// created with modelRef.
+func (h *Handler) modelAnnotatedInputs(userAppIDSet *pb.UserAppIDSet, inputs []*pb.Input, modelRef string, errCtx map[string]string) (map[string]bool, *mcp.RPCError) {
+	annotated := map[string]bool{}
+	for start := 0; start < len(inputs); start += searchPageSize {
+		end := start + searchPageSize
+		if end > len(inputs) {
+			end = len(inputs)
+		}
+		ids := make([]string, 0, end-start)
+		for _, input := range inputs[start:end] {
+			ids = append(ids, input.GetId())
+		}
+		ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+		if rpcErr != nil {
+			rpcErr.Data = errCtx
+			return nil, rpcErr
+		}
+		annotations, err := h.clarifaiClient.ListInputAnnotations(ctx, userAppIDSet, ids, h.logger)
+		cancel()
+		if err != nil {
+			return nil, utils.HandleApiError(err, errCtx, h.logger)
+		}
+		for _, annotation := range annotations {
+			if annotation.GetAnnotationInfo().GetFields()[autoAnnotateInfoKey].GetStringValue() == modelRef {
+				annotated[annotation.GetInputId()] = true
+			}
+		}
+	}
+	return annotated, nil
+}
+
+// parseDatasetQuery combines the optional 'dataset_id' and 'query' arguments into one search
+// query. It returns nil when neither is given.
+func parseDatasetQuery(args map[string]interface{}) (*pb.Query, *mcp.RPCError) {
+	query := &pb.Query{}
+	if raw, present := args["dataset_id"]; present && raw != nil {
+		id, ok := raw.(string)
+		if !ok || !clarifaiIDPattern.MatchString(id) {
+			return nil, invalidParam("dataset_id", "must be 1-255 letters, digits, '-' or '_'")
+		}
+		query.Filters = append(query.Filters, &pb.Filter{Input: &pb.Input{DatasetIds: []string{id}}})
+	}
+	if raw, present := args["query"]; present && raw != nil && raw != "" {
+		queryString, ok := raw.(string)
+		if !ok {
+			return nil, invalidParam("query", "must be a string")
+		}
+		parsed, err := clarifai.ParseQuery(queryString)
+		if err != nil {
+			return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: " + err.Error()}
+		}
+		query.Filters = append(query.Filters, parsed.Filters...)
+		query.Ranks = parsed.Ranks
+	}
+	if len(query.Filters) == 0 && len(query.Ranks) == 0 {
+		return nil, nil
+	}
+	return query, nil
+}
+
+// predictInputs runs the model on existing inputs and returns one output per input, in input
+// order; outputs missing from the response are nil.
+func (h *Handler) predictInputs(modelIDSet *pb.UserAppIDSet, modelID string, inputs []*pb.Input) ([]*pb.Output, error) {
+	request := &pb.PostModelOutputsRequest{UserAppId: modelIDSet, ModelId: modelID}
+	for _, input := range inputs {
+		data := input.GetData()
+		request.Inputs = append(request.Inputs, &pb.Input{Id: input.GetId(), Data: &pb.Data{
+			Image: data.GetImage(),
+			Video: data.GetVideo(),
+			Audio: data.GetAudio(),
+			Text:  data.GetText(),
+		}})
+	}
+
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		return nil, fmt.Errorf("%s", rpcErr.Message)
+	}
+	defer cancel()
+	resp, err := h.clarifaiClient.API.PostModelOutputs(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	// Batches with some failed inputs come back as MIXED_STATUS with per-output statuses.
+	if code := resp.GetStatus().GetCode(); code != statuspb.StatusCode_SUCCESS && code != statuspb.StatusCode_MIXED_STATUS {
+		return nil, clarifai.NewAPIStatusError(resp.GetStatus())
+	}
+
+	byID := make(map[string]*pb.Output, len(resp.Outputs))
+	for _, output := range resp.Outputs {
+		byID[output.GetInput().GetId()] = output
+	}
+	outputs := make([]*pb.Output, len(inputs))
+	for i, input := range inputs {
+		if output, ok := byID[input.GetId()]; ok {
+			outputs[i] = output
+		} else if len(resp.Outputs) == len(inputs) && resp.Outputs[i].GetInput().GetId() == "" {
+			outputs[i] = resp.Outputs[i] // Outputs without input IDs are in request order
+		}
+	}
+	return outputs, nil
+}
+
+// predictionAnnotations converts a prediction into annotations: one for the input-level concepts
+// and one per region, each holding the concepts at or above minConfidence and tagged with info.
+func predictionAnnotations(inputID string, data *pb.Data, filter []string, minConfidence float32, status *statuspb.Status, info *structpb.Struct) []*pb.Annotation {
+	var annotations []*pb.Annotation
+	if concepts := keptConcepts(data.GetConcepts(), filter, minConfidence); len(concepts) > 0 {
+		annotations = append(annotations, &pb.Annotation{InputId: inputID, Status: status, AnnotationInfo: info, Data: &pb.Data{Concepts: concepts}})
+	}
+	for _, region := range data.GetRegions() {
+		concepts := keptConcepts(region.GetData().GetConcepts(), filter, minConfidence)
+		if len(concepts) == 0 || region.GetRegionInfo() == nil {
+			continue
+		}
+		annotations = append(annotations, &pb.Annotation{InputId: inputID, Status: status, AnnotationInfo: info, Data: &pb.Data{
+			Regions: []*pb.Region{{RegionInfo: region.GetRegionInfo(), Data: &pb.Data{Concepts: concepts}}},
+		}})
+	}
+	return annotations
+}
+
+// keptConcepts returns the predicted concepts that pass the filter and threshold, as positive labels.
+func keptConcepts(predicted []*pb.Concept, filter []string, minConfidence float32) []*pb.Concept {
+	var kept []*pb.Concept
+	for _, c := range predicted {
+		if c.GetValue() < minConfidence || !conceptMatches(c, filter) {
+			continue
+		}
+		kept = append(kept, &pb.Concept{Id: c.GetId(), Name: c.GetName(), Value: 1})
+	}
+	return kept
+}
+
+// conceptMatches reports whether c is one of filter, by ID or case-insensitive name. An empty filter matches all.
+func conceptMatches(c *pb.Concept, filter []string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, want := range filter {
+		if c.GetId() == want || strings.EqualFold(c.GetName(), want) {
+			return true
+		}
+	}
+	return false
+}
+
+// annotationConcepts returns the concepts of an annotation, on the input or its region.
+func annotationConcepts(annotation *pb.Annotation) []*pb.Concept {
+	concepts := annotation.GetData().GetConcepts()
+	for _, region := range annotation.GetData().GetRegions() {
+		concepts = append(concepts, region.GetData().GetConcepts()...)
+	}
+	return concepts
+}
+
+// autoAnnotateSummary renders e.g. "Created 12 annotation(s) on 5 of 6 input(s) (awaiting_review): cat 7, dog 5."
+func autoAnnotateSummary(report AutoAnnotateReport) string {
+	verb := "Created"
+	if report.DryRun {
+		verb = "Dry run: would create"
+	}
+	summary := fmt.Sprintf("%s %d annotation(s) on %d of %d input(s) (%s)", verb, report.Annotations, report.AnnotatedInputs, report.Inputs, report.AnnotationStatus)
+	conceptIDs := make([]string, 0, len(report.PerConcept))
+	for id := range report.PerConcept {
+		conceptIDs = append(conceptIDs, id)
+	}
+	sort.Slice(conceptIDs, func(i, j int) bool {
+		if report.PerConcept[conceptIDs[i]] != report.PerConcept[conceptIDs[j]] {
+			return report.PerConcept[conceptIDs[i]] > report.PerConcept[conceptIDs[j]]
+		}
+		return conceptIDs[i] < conceptIDs[j]
+	})
+	parts := make([]string, len(conceptIDs))
+	for i, id := range conceptIDs {
+		parts[i] = fmt.Sprintf("%s %d", id, report.PerConcept[id])
+	}
+	if len(parts) > 0 {
+		summary += ": " + strings.Join(parts, ", ")
+	}
+	summary += "."
+	if len(report.Failures) > 0 {
+		summary += fmt.Sprintf(" %d input(s) failed.", len(report.Failures))
+	}
+	if report.AlreadyAnnotated > 0 {
+		summary += fmt.Sprintf(" Skipped %d input(s) this model already annotated.", report.AlreadyAnnotated)
+	}
+	if report.Truncated {
+		summary += fmt.Sprintf(" More inputs matched than max_inputs; run again with offset %d to continue, or raise max_inputs.", report.NextOffset)
+	}
+	return summary
+}
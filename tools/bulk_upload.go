@@ -0,0 +1,577 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+)
+
+const (
+	// bulkManifestName is the default manifest file written into the uploaded directory.
+	bulkManifestName = ".clarifai_bulk_upload.json"
+
+	defaultBulkBatchSize = 32
+	maxBulkBatchSize     = 128 // Clarifai accepts at most 128 inputs per PostInputs call
+	defaultBulkWorkers   = 4
+	maxBulkWorkers       = 16
+)
+
+// Bulk upload file states recorded in the manifest.
+const (
+	bulkStatusUploaded    = "uploaded"
+	bulkStatusFailed      = "failed"
+	bulkStatusUnsupported = "unsupported"
+)
+
+// BulkUploadManifest records the outcome of every file of a bulk upload so an interrupted or
+// partly failed run can be resumed: files already uploaded are skipped on the next run.
+type BulkUploadManifest struct {
+	Directory string                      `json:"directory"`
+	UserID    string                      `json:"userId"`
+	AppID     string                      `json:"appId"`
+	UpdatedAt string                      `json:"updatedAt"`
+	Files     map[string]*BulkUploadEntry `json:"files"` // Keyed by slash-separated path relative to Directory
+}
+
+// BulkUploadEntry is the state of one file in a bulk upload.
+type BulkUploadEntry struct {
+	Status   string   `json:"status"`
+	Kind     string   `json:"kind,omitempty"`
+	InputIDs []string `json:"inputIds,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	// Processing outcome of the file's inputs, only set when the upload waited for processing
+	Processing       string `json:"processing,omitempty"`
+	ProcessingDetail string `json:"processingDetail,omitempty"`
+}
+
+// BulkUploadResult reports what happened to one file during this run.
+type BulkUploadResult struct {
+	File string `json:"file"`
+	BulkUploadEntry
+}
+
+// bulkUploadOptions are the validated arguments of a bulk upload.
+type bulkUploadOptions struct {
+	Directory    string
+	Recursive    bool
+	Extensions   map[string]bool // Lowercase with leading dot; empty means all files
+	BatchSize    int
+	Workers      int
+	ManifestPath string
+	Restart      bool
+	Input        inputOptions
+	Wait         waitOptions
+}
+
+// bulkBatchResult carries the outcome of one batch from a worker back to the collector.
+type bulkBatchResult struct {
+	Files   []string
+	Entries []*BulkUploadEntry
+}
+
+// bulkUploadArgumentSchema returns the JSON schema properties of the bulk_upload tool.
+// Custom input IDs are not offered: every file gets an ID derived from its path.
+func bulkUploadArgumentSchema() map[string]interface{} {
+	return mergeProperties(map[string]interface{}{
+		"directory": map[string]interface{}{
+			"type":        "string",
+			"description": "Absolute path to the local directory to upload.",
+		},
+		"recursive": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Optional: Include files in subdirectories. Defaults to true.",
+		},
+		"extensions": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": "Optional: Only upload files with these extensions, e.g. ['.jpg', '.png']. Defaults to all supported files.",
+		},
+		"batch_size": map[string]interface{}{
+			"type":        "integer",
+			"description": fmt.Sprintf("Optional: Inputs per PostInputs call (1-%d). Defaults to %d.", maxBulkBatchSize, defaultBulkBatchSize),
+		},
+		"workers": map[string]interface{}{
+			"type":        "integer",
+			"description": fmt.Sprintf("Optional: Number of batches uploaded concurrently (1-%d). Defaults to %d.", maxBulkWorkers, defaultBulkWorkers),
+		},
+		"manifest_path": map[string]interface{}{
+			"type":        "string",
+			"description": fmt.Sprintf("Optional: Where to keep the progress manifest. Defaults to %s inside the directory.", bulkManifestName),
+		},
+		"restart": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Optional: Ignore an existing manifest and upload every file again. Inputs that already exist in the app are kept and count as uploaded. Defaults to false.",
+		},
+		"app_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: App ID context. Defaults to the app associated with the PAT.",
+		},
+		"user_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: User ID context. Defaults to the user associated with the PAT.",
+		},
+	}, sharedInputArgumentSchema(), waitArgumentSchema())
+}
+
+// parseBulkUploadOptions reads and validates the bulk_upload arguments.
+func parseBulkUploadOptions(args map[string]interface{}) (bulkUploadOptions, *mcp.RPCError) {
+	opts := bulkUploadOptions{Recursive: true, BatchSize: defaultBulkBatchSize, Workers: defaultBulkWorkers}
+
+	dir, ok := args["directory"].(string)
+	if !ok || dir == "" {
+		return opts, &mcp.RPCError{Code: -32602, Message: "Invalid params: missing or invalid 'directory'"}
+	}
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return opts, invalidParam("directory", "must be an existing directory")
+	}
+	opts.Directory = filepath.Clean(dir)
+
+	if _, present := args["recursive"]; present {
+		recursive, rpcErr := boolArg(args, "recursive")
+		if rpcErr != nil {
+			return opts, rpcErr
+		}
+		opts.Recursive = recursive
+	}
+	restart, rpcErr := boolArg(args, "restart")
+	if rpcErr != nil {
+		return opts, rpcErr
+	}
+	opts.Restart = restart
+
+	extensions, rpcErr := stringListArg(args, "extensions")
+	if rpcErr != nil {
+		return opts, rpcErr
+	}
+	opts.Extensions = make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		opts.Extensions[ext] = true
+	}
+
+	batchSize, present, rpcErr := intArg(args, "batch_size")
+	if rpcErr != nil {
+		return opts, rpcErr
+	}
+	if present {
+		if batchSize < 1 || batchSize > maxBulkBatchSize {
+			return opts, invalidParam("batch_size", fmt.Sprintf("must be between 1 and %d", maxBulkBatchSize))
+		}
+		opts.BatchSize = batchSize
+	}
+	workers, present, rpcErr := intArg(args, "workers")
+	if rpcErr != nil {
+		return opts, rpcErr
+	}
+	if present {
+		if workers < 1 || workers > maxBulkWorkers {
+			return opts, invalidParam("workers", fmt.Sprintf("must be between 1 and %d", maxBulkWorkers))
+		}
+		opts.Workers = workers
+	}
+
+	opts.ManifestPath = filepath.Join(opts.Directory, bulkManifestName)
+	if raw, present := args["manifest_path"]; present && raw != nil {
+		path, ok := raw.(string)
+		if !ok || path == "" {
+			return opts, invalidParam("manifest_path", "must be a non-empty string")
+		}
+		opts.ManifestPath = filepath.Clean(path)
+	}
+
+	if _, present := args["input_id"]; present {
+		return opts, invalidParam("input_id", "is not supported; bulk_upload derives an input ID from each file's path")
+	}
+	opts.Input, rpcErr = parseInputOptions(args)
+	if rpcErr != nil {
+		return opts, rpcErr
+	}
+	opts.Wait, rpcErr = parseWaitOptions(args)
+	if rpcErr != nil {
+		return opts, rpcErr
+	}
+	return opts, nil
+}
+
+// callBulkUpload uploads every supported file in a directory. Files are grouped into batches that
+// a pool of workers posts concurrently; the manifest is rewritten after each batch so a rerun
+// skips whatever was already uploaded.
+func (h *Handler) callBulkUpload(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callBulkUpload tool")
+
+	opts, rpcErr := parseBulkUploadOptions(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	userID, appID := userAppIDSet.UserId, userAppIDSet.AppId
+
+	errCtx := map[string]string{
+		"tool":      "bulk_upload",
+		"directory": opts.Directory,
+		"manifest":  opts.ManifestPath,
+		"userID":    userID,
+		"appID":     appID,
+	}
+
+	manifest, err := loadBulkManifest(opts.ManifestPath, opts.Restart)
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to read bulk upload manifest: %v", err), Data: errCtx}
+	}
+	if len(manifest.Files) > 0 && (manifest.Directory != opts.Directory || manifest.UserID != userID || manifest.AppID != appID) {
+		return nil, &mcp.RPCError{
+			Code:    -32602,
+			Message: fmt.Sprintf("Invalid params: manifest %s belongs to a bulk upload of %s to %s/%s; pass restart=true or another manifest_path", opts.ManifestPath, manifest.Directory, manifest.UserID, manifest.AppID),
+			Data:    errCtx,
+		}
+	}
+	manifest.Directory, manifest.UserID, manifest.AppID = opts.Directory, userID, appID
+
+	files, err := listBulkFiles(opts)
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to list directory: %v", err), Data: errCtx}
+	}
+	var pending []string
+	alreadyUploaded := 0
+	for _, file := range files {
+		if entry, ok := manifest.Files[file]; ok && entry.Status == bulkStatusUploaded {
+			alreadyUploaded++
+			continue
+		}
+		pending = append(pending, file)
+	}
+	h.logger.Debug("Bulk upload planned", "directory", opts.Directory, "files", len(files), "pending", len(pending), "already_uploaded", alreadyUploaded)
+
+	batches := make(chan []string)
+	results := make(chan bulkBatchResult)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				results <- h.uploadBulkBatch(userAppIDSet, opts, batch)
+			}
+		}()
+	}
+	go func() {
+		for start := 0; start < len(pending); start += opts.BatchSize {
+			end := start + opts.BatchSize
+			if end > len(pending) {
+				end = len(pending)
+			}
+			batches <- pending[start:end]
+		}
+		close(batches)
+		wg.Wait()
+		close(results)
+	}()
+
+	var runResults []BulkUploadResult
+	var manifestErr error
+	for result := range results {
+		for i, file := range result.Files {
+			manifest.Files[file] = result.Entries[i]
+			runResults = append(runResults, BulkUploadResult{File: file, BulkUploadEntry: *result.Entries[i]})
+		}
+		// Keep draining results even if the manifest cannot be written, so workers never block
+		if manifestErr == nil {
+			manifestErr = writeBulkManifest(opts.ManifestPath, manifest)
+		}
+	}
+	var processingSummary string
+	if opts.Wait.Enabled {
+		var ids []string
+		for _, r := range runResults {
+			if r.Status == bulkStatusUploaded {
+				ids = append(ids, r.InputIDs...)
+			}
+		}
+		statuses := h.waitForInputs(userAppIDSet, ids, opts.Wait.Timeout)
+		for i, r := range runResults {
+			if r.Status != bulkStatusUploaded {
+				continue
+			}
+			entry := manifest.Files[r.File]
+			entry.Processing, entry.ProcessingDetail = combinedInputState(statuses, r.InputIDs)
+			runResults[i].BulkUploadEntry = *entry
+		}
+		processingSummary = inputStatusSummary(statuses, opts.Wait.Timeout)
+	}
+	if manifestErr == nil && (len(runResults) == 0 || opts.Wait.Enabled) {
+		manifestErr = writeBulkManifest(opts.ManifestPath, manifest)
+	}
+	if manifestErr != nil {
+		h.logger.Error("Failed to write bulk upload manifest", "manifest", opts.ManifestPath, "error", manifestErr)
+	}
+	sort.Slice(runResults, func(i, j int) bool { return runResults[i].File < runResults[j].File })
+
+	counts := map[string]int{}
+	inputCount := 0
+	for _, r := range runResults {
+		counts[r.Status]++
+		inputCount += len(r.InputIDs)
+	}
+	h.logger.Debug("Bulk upload finished", "uploaded", counts[bulkStatusUploaded], "failed", counts[bulkStatusFailed], "unsupported", counts[bulkStatusUnsupported])
+
+	summary := fmt.Sprintf("Bulk upload of %s: %d file(s) uploaded as %d input(s), %d failed, %d unsupported, %d skipped as already uploaded.",
+		opts.Directory, counts[bulkStatusUploaded], inputCount, counts[bulkStatusFailed], counts[bulkStatusUnsupported], alreadyUploaded)
+	if processingSummary != "" {
+		summary += "\n" + processingSummary
+	}
+	if manifestErr != nil {
+		summary += fmt.Sprintf("\nWarning: failed to write manifest %s: %v", opts.ManifestPath, manifestErr)
+	} else {
+		summary += "\nManifest: " + opts.ManifestPath
+	}
+	if counts[bulkStatusFailed] > 0 {
+		summary += "\nRun bulk_upload again with the same arguments to retry the failed files."
+	}
+
+	if runResults == nil {
+		runResults = []BulkUploadResult{}
+	}
+	resultsJSON, err := json.MarshalIndent(runResults, "", "  ")
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to marshal bulk upload results: %v", err), Data: errCtx}
+	}
+	return map[string]interface{}{
+		"content": []map[string]any{
+			{"type": "text", "text": summary},
+			{"type": "text", "text": string(resultsJSON)},
+		},
+	}, nil
+}
+
+// uploadBulkBatch reads the files of one batch and posts their inputs, splitting the inputs into
+// calls of at most opts.BatchSize (a PDF may produce many). A file only counts as uploaded when
+// every call carrying one of its inputs succeeded.
+func (h *Handler) uploadBulkBatch(userAppIDSet *pb.UserAppIDSet, opts bulkUploadOptions, files []string) bulkBatchResult {
+	result := bulkBatchResult{Files: files, Entries: make([]*BulkUploadEntry, len(files))}
+
+	var inputs []*pb.Input
+	var owners []int // Index into files for each input
+	for i, file := range files {
+		entry := &BulkUploadEntry{}
+		result.Entries[i] = entry
+
+		data, err := os.ReadFile(filepath.Join(opts.Directory, filepath.FromSlash(file)))
+		if err != nil {
+			entry.Status, entry.Error = bulkStatusFailed, fmt.Sprintf("failed to read file: %v", err)
+			continue
+		}
+		fileOpts := opts.Input
+		fileOpts.InputID = bulkInputID(file)
+		built, err := buildFileInputs(file, data, fileOpts)
+		if err != nil {
+			entry.Status, entry.Error = bulkStatusFailed, err.Error()
+			if errors.Is(err, utils.ErrUnsupportedContent) {
+				entry.Status = bulkStatusUnsupported
+			}
+			continue
+		}
+		entry.Status, entry.Kind = bulkStatusUploaded, built.Kind
+		for _, input := range built.Inputs {
+			inputs = append(inputs, input)
+			owners = append(owners, i)
+		}
+	}
+
+	for start := 0; start < len(inputs); start += opts.BatchSize {
+		end := start + opts.BatchSize
+		if end > len(inputs) {
+			end = len(inputs)
+		}
+		err := h.postBulkInputs(userAppIDSet, inputs[start:end])
+		for j := start; j < end; j++ {
+			entry := result.Entries[owners[j]]
+			if err != nil {
+				entry.Status, entry.Error = bulkStatusFailed, err.Error()
+				continue
+			}
+			// IDs of inputs that did get created are kept even if another part of the file failed
+			entry.InputIDs = append(entry.InputIDs, inputs[j].Id)
+		}
+	}
+	return result
+}
+
+// postBulkInputs posts inputs with fixed IDs. Clarifai rejects the whole call when one of the IDs
+// already exists, which happens after a call that timed out but did go through, or with restart.
+// So when the call fails, the inputs that already exist are looked up and count as uploaded, and
+// only the others are posted again. The original error stands if none of them exist.
+func (h *Handler) postBulkInputs(userAppIDSet *pb.UserAppIDSet, inputs []*pb.Input) error {
+	err := h.postInputsOnce(userAppIDSet, inputs)
+	if err == nil {
+		return nil
+	}
+	ids := make([]string, len(inputs))
+	for i, input := range inputs {
+		ids[i] = input.Id
+	}
+	existing, listErr := h.listInputsByID(userAppIDSet, ids)
+	if listErr != nil || len(existing) == 0 {
+		return err
+	}
+	exists := map[string]bool{}
+	for _, input := range existing {
+		exists[input.GetId()] = true
+	}
+	var missing []*pb.Input
+	for _, input := range inputs {
+		if !exists[input.Id] {
+			missing = append(missing, input)
+		}
+	}
+	h.logger.Debug("Inputs of a failed PostInputs call already exist", "existing", len(inputs)-len(missing), "missing", len(missing), "error", err)
+	if len(missing) == 0 {
+		return nil
+	}
+	return h.postInputsOnce(userAppIDSet, missing)
+}
+
+// postInputsOnce sends one PostInputs call with its own timeout.
+func (h *Handler) postInputsOnce(userAppIDSet *pb.UserAppIDSet, inputs []*pb.Input) error {
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		return errors.New(rpcErr.Message)
+	}
+	defer cancel()
+
+	h.logger.Debug("Making gRPC call to PostInputs (bulk)", "inputs", len(inputs))
+	_, err := h.clarifaiClient.PostInputs(ctx, userAppIDSet, inputs, h.logger)
+	return err
+}
+
+// bulkInputID derives a stable input ID from a file's relative path, so retrying a batch can
+// never create the same input twice. A short hash keeps IDs of similarly named files apart.
+func bulkInputID(relPath string) string {
+	return stableInputID(relPath, relPath)
+}
+
+// stableInputID returns the slug of name followed by a short hash of key.
+func stableInputID(name, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	hash := hex.EncodeToString(sum[:6])
+	slug := utils.Slugify(name, 200)
+	if slug == "" {
+		return hash
+	}
+	return slug + "-" + hash
+}
+
+// listBulkFiles returns the files to consider for upload as sorted slash-separated paths relative
+// to the directory. Hidden files and directories are skipped, which also excludes the manifest.
+func listBulkFiles(opts bulkUploadOptions) ([]string, error) {
+	manifestPath, _ := filepath.Abs(opts.ManifestPath)
+	var files []string
+	err := filepath.WalkDir(opts.Directory, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == opts.Directory {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if !opts.Recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if abs, _ := filepath.Abs(path); abs == manifestPath {
+			return nil
+		}
+		if len(opts.Extensions) > 0 && !opts.Extensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		rel, err := filepath.Rel(opts.Directory, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// loadBulkManifest reads the manifest at path. A missing manifest, or restart, gives an empty one.
+func loadBulkManifest(path string, restart bool) (*BulkUploadManifest, error) {
+	manifest := &BulkUploadManifest{Files: map[string]*BulkUploadEntry{}}
+	if restart {
+		return manifest, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("%s is not a bulk upload manifest: %w", path, err)
+	}
+	if manifest.Files == nil {
+		manifest.Files = map[string]*BulkUploadEntry{}
+	}
+	return manifest, nil
+}
+
+// writeBulkManifest replaces the manifest at path through a temporary file, so an interrupted
+// write never leaves a truncated manifest behind.
+func writeBulkManifest(path string, manifest *BulkUploadManifest) error {
+	manifest.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place, so an
+// interrupted write never leaves a truncated file behind.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
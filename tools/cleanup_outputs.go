@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+)
+
+// callCleanupOutputs applies the retention policy to the output path. Arguments override the
+// configured limits for this call only. It is a dry run unless dry_run is explicitly false.
+func (h *Handler) callCleanupOutputs(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callCleanupOutputs tool")
+
+	if h.outputPath == "" {
+		return nil, &mcp.RPCError{Code: -32000, Message: "No output path configured; start the server with -output-path"}
+	}
+
+	dryRun := true
+	if _, present := args["dry_run"]; present {
+		value, rpcErr := boolArg(args, "dry_run")
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		dryRun = value
+	}
+
+	var policy utils.RetentionPolicy
+	if h.config != nil {
+		policy = h.config.RetentionPolicy()
+	}
+	if raw, present := args["max_age"]; present && raw != nil {
+		s, ok := raw.(string)
+		if !ok {
+			return nil, invalidParam("max_age", "must be a duration string such as '72h'")
+		}
+		maxAge, err := time.ParseDuration(s)
+		if err != nil || maxAge <= 0 {
+			return nil, invalidParam("max_age", "must be a positive duration such as '72h'")
+		}
+		policy.MaxAge = maxAge
+	}
+	maxFiles, ok, rpcErr := intArg(args, "max_files")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if ok {
+		if maxFiles <= 0 {
+			return nil, invalidParam("max_files", "must be a positive integer")
+		}
+		policy.MaxFiles = maxFiles
+	}
+	maxMB, ok, rpcErr := floatArg(args, "max_mb")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if ok {
+		if maxMB <= 0 {
+			return nil, invalidParam("max_mb", "must be positive")
+		}
+		policy.MaxTotalBytes = int64(maxMB * (1 << 20))
+	}
+	if !policy.Enabled() {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: no retention limits configured; pass max_age, max_files or max_mb"}
+	}
+
+	errCtx := map[string]string{
+		"tool":       "cleanup_outputs",
+		"outputPath": h.outputPath,
+		"dryRun":     fmt.Sprint(dryRun),
+	}
+	report, err := utils.ApplyRetention(h.outputPath, policy, dryRun, time.Now())
+	if err != nil {
+		h.logger.Error("Output cleanup failed", "output_path", h.outputPath, "error", err)
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to clean up outputs: %v", err), Data: errCtx}
+	}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to marshal cleanup report: %v", err), Data: errCtx}
+	}
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	summary := fmt.Sprintf("%s %d of %d output file(s) in %s, freeing %d bytes.", verb, len(report.Deleted), report.Scanned, h.outputPath, report.FreedBytes)
+	if len(report.Errors) > 0 {
+		summary += fmt.Sprintf(" %d file(s) could not be deleted.", len(report.Errors))
+	}
+	h.logger.Debug("Output cleanup finished", "dry_run", dryRun, "deleted", len(report.Deleted), "freed_bytes", report.FreedBytes)
+
+	toolResult := map[string]interface{}{
+		"content": []map[string]any{
+			{"type": "text", "text": summary},
+			{"type": "text", "text": string(reportJSON)},
+		},
+	}
+	return toolResult, nil
+}
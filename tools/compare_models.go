@@ -0,0 +1,420 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"clarifai-mcp-server-local/clarifai"
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	statuspb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	maxCompareModels          = 10
+	defaultCompareConcurrency = 4
+	defaultCompareTopK        = 3
+	maxCompareCellText        = 60
+)
+
+// ModelComparison is the result of running one model in compare_models.
+type ModelComparison struct {
+	Model     string          `json:"model"`
+	UserID    string          `json:"userId"`
+	AppID     string          `json:"appId"`
+	ModelID   string          `json:"modelId"`
+	VersionID string          `json:"versionId,omitempty"`
+	LatencyMs int64           `json:"latencyMs"`
+	Error     string          `json:"error,omitempty"`
+	Summary   *OutputSummary  `json:"summary,omitempty"`
+	Output    json.RawMessage `json:"output,omitempty"`
+}
+
+// CompareReport is the full compare_models report saved to disk.
+type CompareReport struct {
+	InputType string            `json:"inputType"`
+	Input     string            `json:"input"`
+	CreatedAt string            `json:"createdAt"`
+	Models    []ModelComparison `json:"models"`
+}
+
+// compareModelsArgumentSchema returns the JSON schema properties of compare_models.
+func compareModelsArgumentSchema() map[string]interface{} {
+	return mergeProperties(map[string]interface{}{
+		"model_ids": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": fmt.Sprintf("Models to compare (2-%d): 'model_id' in the app given by user_id/app_id, 'user_id/app_id/model_id' (e.g. 'clarifai/main/general-image-recognition') or a clarifai.com model URL, optionally with '/versions/<version_id>'.", maxCompareModels),
+		},
+		"filepath": map[string]interface{}{
+			"type":        "string",
+			"description": "Local image to run through every model. Give exactly one of filepath, image_url and text.",
+		},
+		"image_url": map[string]interface{}{
+			"type":        "string",
+			"description": "Public image URL to run through every model.",
+		},
+		"text": map[string]interface{}{
+			"type":        "string",
+			"description": "Text to run through every model.",
+		},
+		"min_confidence": map[string]interface{}{
+			"type":        "number",
+			"description": "Optional: Drop concepts and regions below this confidence (0-1) in the table and summaries. Defaults to 0.",
+		},
+		"top_k": map[string]interface{}{
+			"type":        "integer",
+			"description": fmt.Sprintf("Optional: Concepts or regions shown per model in the table. Defaults to %d.", defaultCompareTopK),
+		},
+		"max_concurrency": map[string]interface{}{
+			"type":        "integer",
+			"description": fmt.Sprintf("Optional: Models called at the same time (1-%d). Defaults to %d.", maxCompareModels, defaultCompareConcurrency),
+		},
+		"report_path": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Absolute path of a .json file to save the full report to. Defaults to a new compare_models_<timestamp>_<random>.json under the output path.",
+		},
+		"overwrite_report": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Optional: Replace an existing file at report_path. Defaults to false, which refuses to overwrite it.",
+		},
+	}, appContextArgumentSchema())
+}
+
+// compareReportNameTemplate names reports saved under the output path (see utils.RenderOutputName).
+const compareReportNameTemplate = "compare_models_{timestamp}_{rand}"
+
+// reportPathArg reads the optional report_path argument: an absolute path of a .json file.
+func reportPathArg(args map[string]interface{}) (string, *mcp.RPCError) {
+	raw, present := args["report_path"]
+	if !present || raw == nil || raw == "" {
+		return "", nil
+	}
+	path, ok := raw.(string)
+	if !ok {
+		return "", invalidParam("report_path", "must be a string")
+	}
+	if !filepath.IsAbs(path) {
+		return "", invalidParam("report_path", "must be an absolute path")
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		return "", invalidParam("report_path", "must end in .json")
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return "", invalidParam("report_path", "is a directory")
+	}
+	return filepath.Clean(path), nil
+}
+
+// saveComparisonReport writes the report to reportPath, or to a new file under the output path
+// when reportPath is empty, and returns where it was saved. An existing file at reportPath is only
+// replaced with overwrite.
+func (h *Handler) saveComparisonReport(reportPath string, overwrite bool, data []byte) (string, error) {
+	if reportPath == "" {
+		return utils.SaveNamedFile(h.outputPath, compareReportNameTemplate, utils.OutputNameFields{}, ".json", data)
+	}
+	if err := os.MkdirAll(filepath.Dir(reportPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create report directory: %w", err)
+	}
+	if overwrite {
+		return reportPath, os.WriteFile(reportPath, data, 0644)
+	}
+	if err := utils.WriteNewFile(reportPath, data); err != nil {
+		return "", err
+	}
+	return reportPath, nil
+}
+
+// callCompareModels runs the same input through several models concurrently and returns a
+// side-by-side table of their top predictions and latencies. Models that fail are reported in
+// the table instead of failing the tool.
+func (h *Handler) callCompareModels(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callCompareModels tool")
+
+	refs, rpcErr := stringListArg(args, "model_ids")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if len(refs) < 2 || len(refs) > maxCompareModels {
+		return nil, invalidParam("model_ids", fmt.Sprintf("must list 2-%d models", maxCompareModels))
+	}
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	comparisons := make([]ModelComparison, len(refs))
+	seen := map[string]bool{}
+	for i, ref := range refs {
+		comparison, err := parseModelRef(ref, userAppIDSet)
+		if err != nil {
+			return nil, invalidParam("model_ids", err.Error())
+		}
+		key := comparison.UserID + "/" + comparison.AppID + "/" + comparison.ModelID + "@" + comparison.VersionID
+		if seen[key] {
+			return nil, invalidParam("model_ids", fmt.Sprintf("lists %q more than once", ref))
+		}
+		seen[key] = true
+		comparisons[i] = comparison
+	}
+
+	opts := formatOptions{TopK: defaultCompareTopK, Format: formatSummary}
+	minConfidence, _, rpcErr := floatArg(args, "min_confidence")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if minConfidence < 0 || minConfidence > 1 {
+		return nil, invalidParam("min_confidence", "must be between 0 and 1")
+	}
+	opts.MinConfidence = float32(minConfidence)
+	if topK, ok, rpcErr := intArg(args, "top_k"); rpcErr != nil {
+		return nil, rpcErr
+	} else if ok {
+		if topK < 1 {
+			return nil, invalidParam("top_k", "must be at least 1")
+		}
+		opts.TopK = topK
+	}
+	concurrency := defaultCompareConcurrency
+	if value, ok, rpcErr := intArg(args, "max_concurrency"); rpcErr != nil {
+		return nil, rpcErr
+	} else if ok {
+		if value < 1 || value > maxCompareModels {
+			return nil, invalidParam("max_concurrency", fmt.Sprintf("must be between 1 and %d", maxCompareModels))
+		}
+		concurrency = value
+	}
+
+	report := CompareReport{CreatedAt: time.Now().UTC().Format(time.RFC3339)}
+	errCtx := map[string]string{"tool": "compare_models"}
+	data := &pb.Data{}
+	inputs := 0
+	if path, _ := args["filepath"].(string); path != "" {
+		inputs++
+		imageBytes, err := os.ReadFile(path)
+		if err != nil {
+			h.logger.Error("Failed to read image file", "filepath", path, "error", err)
+			return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to read image file: %v", err), Data: errCtx}
+		}
+		data.Image = &pb.Image{Base64: imageBytes}
+		report.InputType, report.Input = "image", path
+	}
+	if imageURL, _ := args["image_url"].(string); imageURL != "" {
+		inputs++
+		data.Image = &pb.Image{Url: imageURL}
+		report.InputType, report.Input = "image_url", imageURL
+	}
+	if text, _ := args["text"].(string); text != "" {
+		inputs++
+		data.Text = &pb.Text{Raw: text}
+		report.InputType, report.Input = "text", text
+	}
+	if inputs != 1 {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: give exactly one of 'filepath', 'image_url' and 'text'"}
+	}
+
+	reportPath, rpcErr := reportPathArg(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	overwrite, rpcErr := boolArg(args, "overwrite_report")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if reportPath != "" && !overwrite {
+		if _, err := os.Stat(reportPath); err == nil {
+			return nil, invalidParam("report_path", fmt.Sprintf("%s already exists; set 'overwrite_report' to replace it", reportPath))
+		}
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				h.runComparison(&comparisons[index], data, opts)
+			}
+		}()
+	}
+	for i := range comparisons {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	report.Models = comparisons
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to marshal comparison report: %v", err), Data: errCtx}
+	}
+	reportPath, err = h.saveComparisonReport(reportPath, overwrite, reportJSON)
+	errCtx["reportPath"] = reportPath
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to write comparison report: %v", err), Data: errCtx}
+	}
+
+	h.logger.Debug("Compared models", "models", len(comparisons), "report", reportPath)
+	return map[string]interface{}{
+		"content": []map[string]any{
+			{"type": "text", "text": comparisonTable(comparisons)},
+			{"type": "text", "text": "Full report: " + reportPath},
+		},
+	}, nil
+}
+
+// runComparison predicts data with one model and records the outcome and latency in comparison.
+func (h *Handler) runComparison(comparison *ModelComparison, data *pb.Data, opts formatOptions) {
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		comparison.Error = rpcErr.Message
+		return
+	}
+	defer cancel()
+	request := &pb.PostModelOutputsRequest{
+		UserAppId: &pb.UserAppIDSet{UserId: comparison.UserID, AppId: comparison.AppID},
+		ModelId:   comparison.ModelID,
+		VersionId: comparison.VersionID,
+		Inputs:    []*pb.Input{{Data: data}},
+	}
+	started := time.Now()
+	resp, err := h.clarifaiClient.API.PostModelOutputs(ctx, request)
+	comparison.LatencyMs = time.Since(started).Milliseconds()
+	switch {
+	case err != nil:
+		comparison.Error = err.Error()
+	case resp.GetStatus().GetCode() != statuspb.StatusCode_SUCCESS:
+		comparison.Error = clarifai.NewAPIStatusError(resp.GetStatus()).Error()
+	case len(resp.Outputs) == 0:
+		comparison.Error = "API response did not contain output data"
+	}
+	if comparison.Error != "" {
+		h.logger.Warn("Model comparison call failed", "model", comparison.Model, "error", comparison.Error)
+		return
+	}
+
+	output := resp.Outputs[0]
+	summary := summarizeOutput(output, opts)
+	comparison.Summary = &summary
+	if outputJSON, err := protojson.Marshal(output); err == nil {
+		comparison.Output = outputJSON
+	} else {
+		h.logger.Warn("Failed to marshal model output", "model", comparison.Model, "error", err)
+	}
+}
+
+// parseModelRef resolves a model_ids entry to its user, app, model and optional version. Bare
+// model IDs use the given default app.
+func parseModelRef(ref string, defaults *pb.UserAppIDSet) (ModelComparison, error) {
+	comparison := ModelComparison{Model: ref, UserID: defaults.GetUserId(), AppID: defaults.GetAppId()}
+	path := strings.Trim(ref, "/")
+	if strings.Contains(ref, "://") {
+		parsed, err := url.Parse(ref)
+		if err != nil {
+			return comparison, fmt.Errorf("contains invalid model URL %q", ref)
+		}
+		path = strings.Trim(parsed.Path, "/")
+	}
+	parts := strings.Split(path, "/")
+	if len(parts) >= 4 && parts[2] == "models" {
+		parts = append(parts[:2], parts[3:]...) // user/app/models/model[/versions/version]
+	}
+	if len(parts) == 5 && parts[3] == "versions" {
+		comparison.VersionID = parts[4]
+		parts = parts[:3]
+	}
+	switch len(parts) {
+	case 1:
+		comparison.ModelID = parts[0]
+		if comparison.UserID == "" || comparison.AppID == "" {
+			return comparison, fmt.Errorf("contains %q without an app; pass user_id and app_id or use 'user_id/app_id/model_id'", ref)
+		}
+	case 3:
+		comparison.UserID, comparison.AppID, comparison.ModelID = parts[0], parts[1], parts[2]
+	default:
+		return comparison, fmt.Errorf("contains %q, which is not 'model_id', 'user_id/app_id/model_id' or a model URL", ref)
+	}
+	for _, id := range []string{comparison.UserID, comparison.AppID, comparison.ModelID} {
+		if !clarifaiIDPattern.MatchString(id) {
+			return comparison, fmt.Errorf("contains %q, which has an empty or invalid user, app or model ID", ref)
+		}
+	}
+	if comparison.VersionID != "" && !clarifaiIDPattern.MatchString(comparison.VersionID) {
+		return comparison, fmt.Errorf("contains %q, which has an invalid version ID", ref)
+	}
+	return comparison, nil
+}
+
+// comparisonTable renders the comparisons as a Markdown table in model order, followed by the
+// fastest successful model.
+func comparisonTable(comparisons []ModelComparison) string {
+	var b strings.Builder
+	b.WriteString("| Model | Latency | Top predictions |\n|---|---|---|\n")
+	fastest := -1
+	for i, comparison := range comparisons {
+		cell := ""
+		if comparison.Error != "" {
+			cell = "Error: " + truncateCell(comparison.Error)
+		} else {
+			cell = predictionsCell(comparison.Summary)
+			if fastest < 0 || comparison.LatencyMs < comparisons[fastest].LatencyMs {
+				fastest = i
+			}
+		}
+		fmt.Fprintf(&b, "| %s | %d ms | %s |\n", escapeCell(comparison.Model), comparison.LatencyMs, escapeCell(cell))
+	}
+	if fastest >= 0 {
+		fmt.Fprintf(&b, "\nFastest: %s (%d ms).", comparisons[fastest].Model, comparisons[fastest].LatencyMs)
+	} else {
+		b.WriteString("\nEvery model failed.")
+	}
+	return b.String()
+}
+
+// predictionsCell summarizes an output in one table cell: its concepts, else its regions, else
+// its text, else its embedding size.
+func predictionsCell(summary *OutputSummary) string {
+	var parts []string
+	switch {
+	case len(summary.Concepts) > 0:
+		for _, concept := range summary.Concepts {
+			parts = append(parts, fmt.Sprintf("%s %.2f", concept.Name, concept.Confidence))
+		}
+	case len(summary.Regions) > 0:
+		for _, region := range summary.Regions {
+			label := region.Label
+			if label == "" {
+				label = truncateCell(region.Text)
+			}
+			parts = append(parts, fmt.Sprintf("%s %.2f", label, region.Confidence))
+		}
+	case summary.Text != "":
+		return fmt.Sprintf("%q", truncateCell(summary.Text))
+	case len(summary.Embeddings) > 0:
+		return fmt.Sprintf("embedding (%d dims)", summary.Embeddings[0].Dimensions)
+	default:
+		return "no predictions"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// truncateCell shortens text to maxCompareCellText runes on a single line.
+func truncateCell(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > maxCompareCellText {
+		return string(runes[:maxCompareCellText]) + "…"
+	}
+	return text
+}
+
+// escapeCell keeps pipes in cell text from breaking the Markdown table.
+func escapeCell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
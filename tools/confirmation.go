@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"clarifai-mcp-server-local/mcp"
+)
+
+// mutatingTools create, change or delete Clarifai data and are refused in -read-only mode.
+var mutatingTools = map[string]bool{
+	"upload_file":                true,
+	"upload_urls":                true,
+	"upload_manifest":            true,
+	"bulk_upload":                true,
+	"delete_inputs":              true,
+	"patch_input":                true,
+	"create_annotation":          true,
+	"update_annotation":          true,
+	"delete_annotation":          true,
+	"auto_annotate":              true,
+	"import_dataset":             true,
+	"create_dataset":             true,
+	"add_inputs_to_dataset":      true,
+	"remove_inputs_from_dataset": true,
+	"create_dataset_version":     true,
+	"create_model":               true,
+	"train_model_version":        true,
+	"evaluate_model_version":     true,
+}
+
+// newConfirmSecret returns the per-process key for confirmation tokens. Tokens therefore stop
+// working when the server restarts, which forces a fresh preview.
+func newConfirmSecret() []byte {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Sprintf("failed to create confirmation secret: %v", err))
+	}
+	return secret
+}
+
+// confirmationArgumentSchema returns the confirm_token property of previewed mutations.
+func confirmationArgumentSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"confirm_token": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Token from a preview of the same call. Without it the call only previews the change; pass it to apply exactly the previewed change.",
+		},
+	}
+}
+
+// confirmationToken returns the token that authorises operation, a JSON-serialisable
+// description of the exact change a tool is about to make.
+func (h *Handler) confirmationToken(tool string, operation interface{}) (string, error) {
+	data, err := json.Marshal(operation)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode operation: %w", err)
+	}
+	mac := hmac.New(sha256.New, h.confirmSecret)
+	mac.Write([]byte(tool))
+	mac.Write([]byte{0})
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// checkConfirmation decides between preview and execution. Without a confirm_token argument it
+// returns confirmed=false and the token to hand out with the preview. With one, the token must
+// match operation: any difference from the previewed change is rejected.
+func (h *Handler) checkConfirmation(tool string, operation interface{}, args map[string]interface{}) (bool, string, *mcp.RPCError) {
+	token, err := h.confirmationToken(tool, operation)
+	if err != nil {
+		return false, "", &mcp.RPCError{Code: -32000, Message: err.Error()}
+	}
+	raw, present := args["confirm_token"]
+	if !present || raw == nil || raw == "" {
+		return false, token, nil
+	}
+	given, ok := raw.(string)
+	if !ok {
+		return false, "", invalidParam("confirm_token", "must be a string")
+	}
+	if !hmac.Equal([]byte(given), []byte(token)) {
+		return false, "", invalidParam("confirm_token", "does not match this change; it may have changed since the preview (or the server restarted). Call again without confirm_token to preview it")
+	}
+	return true, token, nil
+}
@@ -0,0 +1,245 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"clarifai-mcp-server-local/clarifai"
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	statuspb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api/status"
+)
+
+// cropManifestName is the file written next to the crops describing where each came from.
+const cropManifestName = "manifest.json"
+
+// CropManifest links every crop file back to its source image and region.
+type CropManifest struct {
+	Source      string      `json:"source"`
+	ModelID     string      `json:"modelId"`
+	ImageWidth  int         `json:"imageWidth"`
+	ImageHeight int         `json:"imageHeight"`
+	CreatedAt   string      `json:"createdAt"`
+	Crops       []CropEntry `json:"crops"`
+}
+
+// CropEntry describes a single cropped region.
+type CropEntry struct {
+	File        string             `json:"file"`
+	RegionID    string             `json:"regionId,omitempty"`
+	Label       string             `json:"label,omitempty"`
+	Confidence  float32            `json:"confidence"`
+	BoundingBox BoundingBoxSummary `json:"boundingBox"`
+	PixelBox    PixelBox           `json:"pixelBox"`
+}
+
+// PixelBox is a bounding box in source image pixel coordinates.
+type PixelBox struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// callCropRegions runs a detection model on a local image and writes each detected region as its own file.
+func (h *Handler) callCropRegions(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callCropRegions tool")
+
+	sourcePath, pathOk := args["filepath"].(string)
+	if !pathOk || sourcePath == "" {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: missing or invalid 'filepath'"}
+	}
+
+	modelID, _ := args["model_id"].(string)
+	userID, _ := args["user_id"].(string)
+	appID, _ := args["app_id"].(string)
+	outputDir, _ := args["output_dir"].(string)
+
+	conceptFilter, rpcErr := stringListArg(args, "concepts")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	minConfidence, _, rpcErr := floatArg(args, "min_confidence")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if minConfidence < 0 || minConfidence > 1 {
+		return nil, invalidParam("min_confidence", "must be between 0 and 1")
+	}
+
+	effectiveUserID, effectiveAppID, effectiveModelID := h.resolveDetectionModelIDs(userID, appID, modelID)
+
+	if outputDir == "" {
+		stem := strings.TrimSuffix(filepath.Base(sourcePath), filepath.Ext(sourcePath))
+		outputDir = filepath.Join(h.outputPath, fmt.Sprintf("crops_%s_%d", utils.Slugify(stem, 40), time.Now().UnixNano()))
+	}
+
+	errCtx := map[string]string{
+		"tool":      "crop_regions",
+		"filepath":  sourcePath,
+		"outputDir": outputDir,
+		"userID":    effectiveUserID,
+		"appID":     effectiveAppID,
+		"modelID":   effectiveModelID,
+	}
+
+	imageBytes, err := os.ReadFile(sourcePath)
+	if err != nil {
+		h.logger.Error("Failed to read image file", "filepath", sourcePath, "error", err)
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to read image file: %v", err), Data: errCtx}
+	}
+	img, format, err := utils.DecodeImage(imageBytes)
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to decode image file: %v", err), Data: errCtx}
+	}
+
+	grpcRequest := &pb.PostModelOutputsRequest{
+		UserAppId: &pb.UserAppIDSet{UserId: effectiveUserID, AppId: effectiveAppID},
+		ModelId:   effectiveModelID,
+		Inputs:    []*pb.Input{{Data: &pb.Data{Image: &pb.Image{Base64: imageBytes}}}},
+	}
+
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, rpcErr
+	}
+	defer cancel()
+
+	h.logger.Debug("Making gRPC call to PostModelOutputs (crop)", "timeout", h.timeoutSec, "user_id", effectiveUserID, "app_id", effectiveAppID, "model_id", effectiveModelID)
+	resp, err := h.clarifaiClient.API.PostModelOutputs(ctx, grpcRequest)
+	h.logger.Debug("gRPC call to PostModelOutputs (crop) finished.")
+
+	if err != nil {
+		return nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+	if resp.GetStatus().GetCode() != statuspb.StatusCode_SUCCESS {
+		apiErr := clarifai.NewAPIStatusError(resp.GetStatus())
+		return nil, utils.HandleApiError(apiErr, errCtx, h.logger)
+	}
+	if len(resp.Outputs) == 0 || resp.Outputs[0].Data == nil {
+		apiErr := fmt.Errorf("API response did not contain output data")
+		return nil, utils.HandleApiError(apiErr, errCtx, h.logger)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to create output directory: %v", err), Data: errCtx}
+	}
+
+	// Keep JPEG sources as JPEG to avoid bloating photo crops; everything else is written as lossless PNG.
+	ext := ".png"
+	if format == "jpeg" {
+		ext = ".jpg"
+	}
+
+	bounds := img.Bounds()
+	manifest := CropManifest{
+		Source:      sourcePath,
+		ModelID:     effectiveModelID,
+		ImageWidth:  bounds.Dx(),
+		ImageHeight: bounds.Dy(),
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+		Crops:       []CropEntry{},
+	}
+	for _, region := range resp.Outputs[0].Data.Regions {
+		box := region.GetRegionInfo().GetBoundingBox()
+		if box == nil {
+			continue
+		}
+		concept := matchingRegionConcept(region, conceptFilter)
+		if concept == nil || concept.GetValue() < float32(minConfidence) {
+			continue
+		}
+		rect := utils.RegionBounds(bounds, box)
+		if rect.Empty() {
+			continue
+		}
+
+		label := conceptName(concept)
+		name := fmt.Sprintf("crop_%03d", len(manifest.Crops)+1)
+		if slug := utils.Slugify(label, 40); slug != "" {
+			name += "_" + slug
+		}
+		cropPath := filepath.Join(outputDir, name+ext)
+		if err := writeCrop(cropPath, utils.CropImage(img, rect), ext); err != nil {
+			h.logger.Error("Failed to write crop", "path", cropPath, "error", err)
+			return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to write crop: %v", err), Data: errCtx}
+		}
+
+		manifest.Crops = append(manifest.Crops, CropEntry{
+			File:       cropPath,
+			RegionID:   region.GetId(),
+			Label:      label,
+			Confidence: concept.GetValue(),
+			BoundingBox: BoundingBoxSummary{
+				Top:    box.GetTopRow(),
+				Left:   box.GetLeftCol(),
+				Bottom: box.GetBottomRow(),
+				Right:  box.GetRightCol(),
+			},
+			PixelBox: PixelBox{X: rect.Min.X - bounds.Min.X, Y: rect.Min.Y - bounds.Min.Y, Width: rect.Dx(), Height: rect.Dy()},
+		})
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to marshal crop manifest: %v", err), Data: errCtx}
+	}
+	manifestPath := filepath.Join(outputDir, cropManifestName)
+	if err := os.WriteFile(manifestPath, manifestJSON, 0644); err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to write crop manifest: %v", err), Data: errCtx}
+	}
+
+	h.logger.Debug("Cropped regions", "count", len(manifest.Crops), "output_dir", outputDir)
+	summary := fmt.Sprintf("Wrote %d crop(s) to %s\nManifest: %s", len(manifest.Crops), outputDir, manifestPath)
+	toolResult := map[string]interface{}{
+		"content": []map[string]any{
+			{"type": "text", "text": summary},
+			{"type": "text", "text": string(manifestJSON)},
+		},
+	}
+	return toolResult, nil
+}
+
+// matchingRegionConcept returns the highest-confidence concept of the region whose name or ID
+// is in filter. With an empty filter the region's top concept is returned.
+func matchingRegionConcept(region *pb.Region, filter []string) *pb.Concept {
+	if len(filter) == 0 {
+		return topRegionConcept(region)
+	}
+	var best *pb.Concept
+	for _, c := range region.GetData().GetConcepts() {
+		for _, want := range filter {
+			if strings.EqualFold(c.GetName(), want) || c.GetId() == want {
+				if best == nil || c.GetValue() > best.GetValue() {
+					best = c
+				}
+				break
+			}
+		}
+	}
+	return best
+}
+
+// writeCrop encodes a crop according to ext and writes it to path.
+func writeCrop(path string, crop image.Image, ext string) error {
+	var data []byte
+	var err error
+	if ext == ".jpg" {
+		data, err = utils.EncodeJPEG(crop, 95)
+	} else {
+		data, err = utils.EncodePNG(crop)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
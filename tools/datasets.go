@@ -0,0 +1,282 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"clarifai-mcp-server-local/clarifai"
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+)
+
+// datasetArgumentSchema returns the properties shared by the dataset tools.
+func datasetArgumentSchema() map[string]interface{} {
+	return mergeProperties(map[string]interface{}{
+		"dataset_id": map[string]interface{}{
+			"type":        "string",
+			"description": "ID of the dataset.",
+		},
+	}, appContextArgumentSchema())
+}
+
+// describedResourceArgumentSchema returns the optional description and metadata properties of
+// created datasets and dataset versions.
+func describedResourceArgumentSchema(noun string) map[string]interface{} {
+	return map[string]interface{}{
+		"description": map[string]interface{}{
+			"type":        "string",
+			"description": fmt.Sprintf("Optional: Description of the %s.", noun),
+		},
+		"metadata": map[string]interface{}{
+			"type":        "object",
+			"description": fmt.Sprintf("Optional: Arbitrary JSON metadata stored with the %s.", noun),
+		},
+	}
+}
+
+// callCreateDataset creates an empty dataset in the app.
+func (h *Handler) callCreateDataset(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callCreateDataset tool")
+
+	datasetID, rpcErr := idArg(args, "dataset_id")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	metadata, rpcErr := structArg(args, "metadata")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	description, _ := args["description"].(string)
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":      "create_dataset",
+		"datasetID": datasetID,
+		"userID":    userAppIDSet.UserId,
+		"appID":     userAppIDSet.AppId,
+	}
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, rpcErr
+	}
+	defer cancel()
+	dataset, err := h.clarifaiClient.CreateDataset(ctx, userAppIDSet, &pb.Dataset{Id: datasetID, Description: description, Metadata: metadata}, h.logger)
+	if err != nil {
+		return nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+	h.logger.Info("Created dataset", "dataset_id", dataset.GetId())
+	return protoResult(fmt.Sprintf("Created dataset %s.", dataset.GetId()), dataset, errCtx)
+}
+
+// callAddInputsToDataset adds inputs to a dataset, listed by ID or selected by a search query.
+func (h *Handler) callAddInputsToDataset(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callAddInputsToDataset tool")
+
+	datasetID, rpcErr := idArg(args, "dataset_id")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	ids, rpcErr := datasetInputIDsArg(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	queryString, _ := args["query"].(string)
+	if (len(ids) == 0) == (queryString == "") {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: give either 'input_ids' or 'query'"}
+	}
+	var search *pb.Search
+	if queryString != "" {
+		query, err := clarifai.ParseQuery(queryString)
+		if err != nil {
+			return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: " + err.Error()}
+		}
+		if len(query.Ranks) > 0 {
+			return nil, invalidParam("query", "may only contain filter terms (concept:, metadata., dataset:, status:, geo:); free text and ~ terms rank inputs instead of selecting them")
+		}
+		search = &pb.Search{Query: query}
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":      "add_inputs_to_dataset",
+		"datasetID": datasetID,
+		"userID":    userAppIDSet.UserId,
+		"appID":     userAppIDSet.AppId,
+	}
+
+	if search != nil {
+		ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+		if rpcErr != nil {
+			rpcErr.Data = errCtx
+			return nil, rpcErr
+		}
+		defer cancel()
+		resp, err := h.clarifaiClient.AddDatasetInputs(ctx, userAppIDSet, datasetID, nil, search, h.logger)
+		if err != nil {
+			return nil, utils.HandleApiError(err, errCtx, h.logger)
+		}
+		h.logger.Info("Added inputs to dataset by search", "dataset_id", datasetID, "query", queryString)
+		if operation := resp.GetBulkOperation(); operation != nil {
+			return protoResult(fmt.Sprintf("Adding the inputs matching %q to dataset %s as bulk operation %s (%s).", queryString, datasetID, operation.GetId(), operation.GetStatus().GetCode()), operation, errCtx)
+		}
+		return datasetInputsResult(fmt.Sprintf("Added %d input(s) matching %q to dataset %s.", len(resp.GetDatasetInputs()), queryString, datasetID), resp.GetDatasetInputs())
+	}
+
+	added := 0
+	for start := 0; start < len(ids); start += maxBulkBatchSize {
+		end := start + maxBulkBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+		if rpcErr != nil {
+			rpcErr.Data = errCtx
+			return nil, rpcErr
+		}
+		_, err := h.clarifaiClient.AddDatasetInputs(ctx, userAppIDSet, datasetID, ids[start:end], nil, h.logger)
+		cancel()
+		if err != nil {
+			rpcErr := utils.HandleApiError(err, errCtx, h.logger)
+			if start > 0 {
+				rpcErr.Message += fmt.Sprintf(" (%d of %d inputs were added before the failure)", start, len(ids))
+			}
+			return nil, rpcErr
+		}
+		added = end
+	}
+	h.logger.Info("Added inputs to dataset", "dataset_id", datasetID, "inputs", added)
+	return map[string]interface{}{
+		"content": []map[string]any{{"type": "text", "text": fmt.Sprintf("Added %d input(s) to dataset %s.", added, datasetID)}},
+	}, nil
+}
+
+// callRemoveInputsFromDataset removes inputs from a dataset without deleting them from the app.
+func (h *Handler) callRemoveInputsFromDataset(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callRemoveInputsFromDataset tool")
+
+	datasetID, rpcErr := idArg(args, "dataset_id")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	ids, rpcErr := datasetInputIDsArg(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if len(ids) == 0 {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: missing or invalid 'input_ids'"}
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":      "remove_inputs_from_dataset",
+		"datasetID": datasetID,
+		"userID":    userAppIDSet.UserId,
+		"appID":     userAppIDSet.AppId,
+	}
+	for start := 0; start < len(ids); start += maxBulkBatchSize {
+		end := start + maxBulkBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+		if rpcErr != nil {
+			rpcErr.Data = errCtx
+			return nil, rpcErr
+		}
+		err := h.clarifaiClient.RemoveDatasetInputs(ctx, userAppIDSet, datasetID, ids[start:end], h.logger)
+		cancel()
+		if err != nil {
+			rpcErr := utils.HandleApiError(err, errCtx, h.logger)
+			if start > 0 {
+				rpcErr.Message += fmt.Sprintf(" (%d of %d inputs were removed before the failure)", start, len(ids))
+			}
+			return nil, rpcErr
+		}
+	}
+	h.logger.Info("Removed inputs from dataset", "dataset_id", datasetID, "inputs", len(ids))
+	return map[string]interface{}{
+		"content": []map[string]any{{"type": "text", "text": fmt.Sprintf("Removed %d input(s) from dataset %s. The inputs themselves were kept.", len(ids), datasetID)}},
+	}, nil
+}
+
+// callCreateDatasetVersion freezes the dataset's current inputs and annotations as a version,
+// which training and evaluation can then refer to.
+func (h *Handler) callCreateDatasetVersion(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callCreateDatasetVersion tool")
+
+	datasetID, rpcErr := idArg(args, "dataset_id")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	versionID := ""
+	if _, present := args["version_id"]; present {
+		if versionID, rpcErr = idArg(args, "version_id"); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	metadata, rpcErr := structArg(args, "metadata")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	description, _ := args["description"].(string)
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":      "create_dataset_version",
+		"datasetID": datasetID,
+		"userID":    userAppIDSet.UserId,
+		"appID":     userAppIDSet.AppId,
+	}
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, rpcErr
+	}
+	defer cancel()
+	version, err := h.clarifaiClient.CreateDatasetVersion(ctx, userAppIDSet, datasetID, &pb.DatasetVersion{Id: versionID, Description: description, Metadata: metadata}, h.logger)
+	if err != nil {
+		return nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+	h.logger.Info("Created dataset version", "dataset_id", datasetID, "version_id", version.GetId(), "status", version.GetStatus().GetCode())
+	text := fmt.Sprintf("Created version %s of dataset %s (status %s).", version.GetId(), datasetID, version.GetStatus().GetCode())
+	return protoResult(text, version, errCtx)
+}
+
+// datasetInputIDsArg reads and validates the optional input_ids argument of the dataset tools.
+func datasetInputIDsArg(args map[string]interface{}) ([]string, *mcp.RPCError) {
+	ids, rpcErr := stringListArg(args, "input_ids")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	seen := map[string]bool{}
+	unique := ids[:0]
+	for _, id := range ids {
+		if !clarifaiIDPattern.MatchString(id) {
+			return nil, invalidParam("input_ids", fmt.Sprintf("contains invalid input ID %q", id))
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique, nil
+}
+
+// datasetInputsResult returns a summary line plus the IDs of the inputs added to a dataset.
+func datasetInputsResult(text string, datasetInputs []*pb.DatasetInput) (interface{}, *mcp.RPCError) {
+	content := []map[string]any{{"type": "text", "text": text}}
+	if len(datasetInputs) > 0 {
+		ids := ""
+		for i, datasetInput := range datasetInputs {
+			if i > 0 {
+				ids += ", "
+			}
+			ids += datasetInput.GetInput().GetId()
+		}
+		content = append(content, map[string]any{"type": "text", "text": "Input IDs: " + ids})
+	}
+	return map[string]interface{}{"content": content}, nil
+}
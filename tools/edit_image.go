@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"clarifai-mcp-server-local/mcp"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// callEditImage runs an image-to-image or inpainting model on a local source image.
+// The prompt and source image travel in the same input; an optional mask is attached
+// as a region mask, which is how Clarifai inpainting models receive it.
+func (h *Handler) callEditImage(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callEditImage tool")
+
+	sourcePath, pathOk := args["filepath"].(string)
+	if !pathOk || sourcePath == "" {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: missing or invalid 'filepath'"}
+	}
+	textPrompt, promptOk := args["text_prompt"].(string)
+	if !promptOk || textPrompt == "" {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: missing or invalid 'text_prompt'"}
+	}
+	modelID, modelOk := args["model_id"].(string)
+	if !modelOk || modelID == "" {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: missing or invalid 'model_id'"}
+	}
+	maskPath, _ := args["mask_filepath"].(string)
+	userID, _ := args["user_id"].(string)
+	appID, _ := args["app_id"].(string)
+
+	genParams, rpcErr := parseGenerationParams(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	outputOpts, rpcErr := h.imageOutputOptions(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	// Use configured defaults if args are empty
+	effectiveUserID := userID
+	effectiveAppID := appID
+	if effectiveUserID == "" {
+		effectiveUserID = h.config.DefaultUserID
+		h.logger.Debug("Using default user ID from config", "user_id", effectiveUserID)
+	}
+	if effectiveAppID == "" {
+		effectiveAppID = h.config.DefaultAppID
+		h.logger.Debug("Using default app ID from config", "app_id", effectiveAppID)
+	}
+
+	errCtx := map[string]string{
+		"tool":         "edit_image",
+		"filepath":     sourcePath,
+		"maskFilepath": maskPath,
+		"textPrompt":   textPrompt,
+		"userID":       effectiveUserID,
+		"appID":        effectiveAppID,
+		"modelID":      modelID,
+	}
+	if genParams.SendSeed {
+		errCtx["seed"] = strconv.FormatInt(genParams.Seed, 10)
+	}
+
+	sourceBytes, err := os.ReadFile(sourcePath)
+	if err != nil {
+		h.logger.Error("Failed to read source image", "filepath", sourcePath, "error", err)
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to read image file: %v", err), Data: errCtx}
+	}
+	inputData := &pb.Data{
+		Image: &pb.Image{Base64: sourceBytes},
+		Text:  &pb.Text{Raw: textPrompt},
+	}
+	if maskPath != "" {
+		maskBytes, err := os.ReadFile(maskPath)
+		if err != nil {
+			h.logger.Error("Failed to read mask image", "filepath", maskPath, "error", err)
+			return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to read mask file: %v", err), Data: errCtx}
+		}
+		inputData.Regions = []*pb.Region{{
+			RegionInfo: &pb.RegionInfo{Mask: &pb.Mask{Image: &pb.Image{Base64: maskBytes}}},
+		}}
+	}
+	h.logger.Debug("Prepared edit input", "source_bytes", len(sourceBytes), "has_mask", maskPath != "")
+
+	images, rpcErr := h.generateImages(genParams, errCtx, func(params *structpb.Struct) *pb.PostModelOutputsRequest {
+		return &pb.PostModelOutputsRequest{
+			UserAppId: &pb.UserAppIDSet{UserId: effectiveUserID, AppId: effectiveAppID},
+			ModelId:   modelID,
+			Inputs:    []*pb.Input{{Data: inputData}},
+			Model:     modelWithParams(params),
+		}
+	})
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	meta := GenerationMetadata{
+		Tool:        "edit_image",
+		Prompt:      textPrompt,
+		ModelID:     modelID,
+		UserID:      effectiveUserID,
+		AppID:       effectiveAppID,
+		SourceImage: sourcePath,
+		MaskImage:   maskPath,
+	}
+	return h.generationResult(images, meta, genParams, outputOpts, errCtx)
+}
@@ -0,0 +1,402 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+)
+
+// exportStateName is the file in the export directory that lets an interrupted export resume.
+const exportStateName = ".export_state.json"
+
+// exportState is the progress of an export, saved after every page.
+type exportState struct {
+	Format    string               `json:"format"`
+	UserID    string               `json:"userId"`
+	AppID     string               `json:"appId"`
+	DatasetID string               `json:"datasetId,omitempty"`
+	Query     string               `json:"query,omitempty"`
+	PerPage   uint32               `json:"perPage"`
+	NextPage  uint32               `json:"nextPage"`
+	Complete  bool                 `json:"complete"`
+	Images    []utils.DatasetImage `json:"images"`
+	Skipped   []string             `json:"skipped,omitempty"` // Inputs without an image
+	Failures  []InputFailure       `json:"failures,omitempty"`
+}
+
+// sameExport reports whether other exports the same data in the same format.
+func (s *exportState) sameExport(other *exportState) bool {
+	return s.Format == other.Format && s.UserID == other.UserID && s.AppID == other.AppID &&
+		s.DatasetID == other.DatasetID && s.Query == other.Query
+}
+
+// ExportReport summarises an export_dataset call.
+type ExportReport struct {
+	OutputDir string         `json:"outputDir"`
+	Format    string         `json:"format"`
+	Complete  bool           `json:"complete"`
+	NextPage  uint32         `json:"nextPage,omitempty"` // First page of the next call while incomplete
+	Images    int            `json:"images"`
+	Boxes     int            `json:"boxes"`
+	Concepts  []string       `json:"concepts"`
+	Files     []string       `json:"files,omitempty"` // Annotation files, once complete
+	Skipped   []string       `json:"skipped,omitempty"`
+	Failures  []InputFailure `json:"failures,omitempty"`
+	PagesRead int            `json:"pagesRead"`
+	ResumedAt uint32         `json:"resumedAt,omitempty"`
+	StateFile string         `json:"stateFile,omitempty"`
+}
+
+// exportDatasetArgumentSchema returns the JSON schema properties of export_dataset.
+func exportDatasetArgumentSchema() map[string]interface{} {
+	return mergeProperties(map[string]interface{}{
+		"format": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{utils.DatasetFormatCOCO, utils.DatasetFormatYOLO, utils.DatasetFormatVOC},
+			"description": "Annotation format: 'coco' (annotations.json), 'yolo' (labels/*.txt and classes.txt) or 'voc' (Annotations/*.xml).",
+		},
+		"dataset_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Only export the inputs of this dataset. Defaults to the whole app.",
+		},
+		"output_dir": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Directory to export to. Defaults to a directory named after the app, dataset and format under the server's output path.",
+		},
+		"page": map[string]interface{}{
+			"type":        "integer",
+			"description": "Optional: Page of inputs to start from (1-based). Ignored when resuming.",
+		},
+		"per_page": map[string]interface{}{
+			"type":        "integer",
+			"description": "Optional: Inputs per page (max 1000). Defaults to 20.",
+		},
+		"max_pages": map[string]interface{}{
+			"type":        "integer",
+			"description": "Optional: Stop after this many pages; call again with the same arguments to resume. Defaults to all pages.",
+		},
+		"restart": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Optional: Discard the progress of an unfinished export in output_dir and start over. Defaults to false.",
+		},
+	}, inputQueryArgumentSchema("Optional: Only export the inputs matching this search query."), appContextArgumentSchema())
+}
+
+// callExportDataset pages through the inputs of an app or dataset, downloads their images and
+// writes their bounding box annotations as a local COCO, YOLO or VOC dataset. Progress is saved
+// after every page, so an interrupted or max_pages-limited export resumes where it stopped.
+func (h *Handler) callExportDataset(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callExportDataset tool")
+
+	format, _ := args["format"].(string)
+	if format != utils.DatasetFormatCOCO && format != utils.DatasetFormatYOLO && format != utils.DatasetFormatVOC {
+		return nil, invalidParam("format", "must be 'coco', 'yolo' or 'voc'")
+	}
+	query, rpcErr := parseDatasetQuery(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	paginationParams := url.Values{}
+	for _, name := range []string{"page", "per_page"} {
+		if value, ok, rpcErr := intArg(args, name); rpcErr != nil {
+			return nil, rpcErr
+		} else if ok {
+			paginationParams.Set(name, strconv.Itoa(value))
+		}
+	}
+	maxPages := 0
+	if value, ok, rpcErr := intArg(args, "max_pages"); rpcErr != nil {
+		return nil, rpcErr
+	} else if ok {
+		if value < 1 {
+			return nil, invalidParam("max_pages", "must be at least 1")
+		}
+		maxPages = value
+	}
+	restart, rpcErr := boolArg(args, "restart")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	datasetID, _ := args["dataset_id"].(string)
+	queryString, _ := args["query"].(string)
+	outputDir, _ := args["output_dir"].(string)
+	if outputDir == "" {
+		scope := "all"
+		if datasetID != "" {
+			scope = datasetID
+		}
+		outputDir = filepath.Join(h.outputPath, fmt.Sprintf("export_%s_%s_%s", utils.Slugify(userAppIDSet.AppId, 40), utils.Slugify(scope, 40), format))
+	}
+	errCtx := map[string]string{
+		"tool":      "export_dataset",
+		"userID":    userAppIDSet.UserId,
+		"appID":     userAppIDSet.AppId,
+		"outputDir": outputDir,
+	}
+
+	state := &exportState{Format: format, UserID: userAppIDSet.UserId, AppID: userAppIDSet.AppId, DatasetID: datasetID, Query: queryString}
+	cursor := ""
+	var resumedAt uint32
+	if !restart {
+		previous, err := loadExportState(outputDir)
+		if err != nil {
+			return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to read export progress: %v", err), Data: errCtx}
+		}
+		if previous != nil && !previous.Complete {
+			if !previous.sameExport(state) {
+				return nil, invalidParam("output_dir", "holds an unfinished export of other data or another format; set 'restart' or choose another directory")
+			}
+			state = previous
+			resumedAt = state.NextPage
+			cursor = strconv.Itoa(int(state.NextPage))
+			paginationParams.Set("per_page", strconv.Itoa(int(state.PerPage)))
+		}
+	}
+	page, perPage := utils.ParsePagination(paginationParams, cursor, h.logger)
+	state.PerPage = perPage
+
+	if err := os.MkdirAll(filepath.Join(outputDir, utils.DatasetImagesDir), 0755); err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to create export directory: %v", err), Data: errCtx}
+	}
+
+	pagesRead := 0
+	for !state.Complete && (maxPages == 0 || pagesRead < maxPages) {
+		inputs, more, rpcErr := h.exportPage(userAppIDSet, query, page, perPage, errCtx)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		for _, input := range inputs.list {
+			img, skipped, err := h.exportInput(input, inputs.annotations[input.GetId()], outputDir, format)
+			switch {
+			case err != nil:
+				h.logger.Warn("Failed to export input", "input_id", input.GetId(), "error", err)
+				state.Failures = append(state.Failures, InputFailure{InputID: input.GetId(), Error: err.Error()})
+			case skipped:
+				state.Skipped = append(state.Skipped, input.GetId())
+			default:
+				state.Images = append(state.Images, img)
+			}
+		}
+		pagesRead++
+		page++
+		state.NextPage = page
+		state.Complete = !more
+		if err := saveExportState(outputDir, state); err != nil {
+			return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to save export progress: %v", err), Data: errCtx}
+		}
+	}
+
+	report := ExportReport{
+		OutputDir: outputDir,
+		Format:    format,
+		Complete:  state.Complete,
+		Images:    len(state.Images),
+		Concepts:  utils.DatasetConcepts(state.Images),
+		Skipped:   state.Skipped,
+		Failures:  state.Failures,
+		PagesRead: pagesRead,
+		ResumedAt: resumedAt,
+	}
+	for _, img := range state.Images {
+		report.Boxes += len(img.Boxes)
+	}
+	if state.Complete {
+		files, err := utils.WriteDataset(outputDir, format, state.Images)
+		if err != nil {
+			return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to write %s annotations: %v", strings.ToUpper(format), err), Data: errCtx}
+		}
+		report.Files = files
+	} else {
+		report.NextPage = state.NextPage
+		report.StateFile = filepath.Join(outputDir, exportStateName)
+	}
+	h.logger.Info("Dataset export finished", "output_dir", outputDir, "format", format, "complete", state.Complete, "images", report.Images, "pages", pagesRead)
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to marshal report: %v", err), Data: errCtx}
+	}
+	return map[string]interface{}{
+		"content": []map[string]any{
+			{"type": "text", "text": exportSummary(report)},
+			{"type": "text", "text": string(reportJSON)},
+		},
+	}, nil
+}
+
+// exportPageInputs are the inputs of one page with their annotations by input ID.
+type exportPageInputs struct {
+	list        []*pb.Input
+	annotations map[string][]*pb.Annotation
+}
+
+// exportPage fetches one page of inputs and their annotations. more is false on the last page.
+func (h *Handler) exportPage(userAppIDSet *pb.UserAppIDSet, query *pb.Query, page, perPage uint32, errCtx map[string]string) (exportPageInputs, bool, *mcp.RPCError) {
+	result := exportPageInputs{annotations: map[string][]*pb.Annotation{}}
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return result, false, rpcErr
+	}
+	defer cancel()
+
+	messages, nextCursor, err := h.clarifaiClient.ListInputs(ctx, userAppIDSet, &pb.Pagination{Page: page, PerPage: perPage}, query, h.logger)
+	if err != nil {
+		return result, false, utils.HandleApiError(err, errCtx, h.logger)
+	}
+	ids := make([]string, 0, len(messages))
+	for _, message := range messages {
+		if input, ok := message.(*pb.Input); ok {
+			result.list = append(result.list, input)
+			ids = append(ids, input.GetId())
+		}
+	}
+	if len(ids) > 0 {
+		annotations, err := h.clarifaiClient.ListInputAnnotations(ctx, userAppIDSet, ids, h.logger)
+		if err != nil {
+			return result, false, utils.HandleApiError(err, errCtx, h.logger)
+		}
+		for _, annotation := range annotations {
+			result.annotations[annotation.GetInputId()] = append(result.annotations[annotation.GetInputId()], annotation)
+		}
+	}
+	return result, nextCursor != "", nil
+}
+
+// exportInput saves the image of an input under outputDir/images and collects its labelled
+// bounding boxes. Inputs without an image are skipped. Images saved by an earlier, interrupted
+// export are reused instead of downloaded again.
+func (h *Handler) exportInput(input *pb.Input, annotations []*pb.Annotation, outputDir, format string) (utils.DatasetImage, bool, error) {
+	source := input.GetData().GetImage()
+	if source == nil {
+		return utils.DatasetImage{}, true, nil
+	}
+
+	var imageBytes []byte
+	existing, _ := filepath.Glob(filepath.Join(outputDir, utils.DatasetImagesDir, input.GetId()+".*"))
+	if len(existing) > 0 {
+		data, err := os.ReadFile(existing[0])
+		if err != nil {
+			return utils.DatasetImage{}, false, fmt.Errorf("failed to read saved image: %w", err)
+		}
+		imageBytes = data
+	} else {
+		switch {
+		case len(source.GetBase64()) > 0:
+			imageBytes = source.GetBase64()
+		case source.GetUrl() != "":
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(h.timeoutSec)*time.Second)
+			data, err := utils.DownloadURL(ctx, source.GetUrl())
+			cancel()
+			if err != nil {
+				return utils.DatasetImage{}, false, err
+			}
+			imageBytes = data
+		default:
+			return utils.DatasetImage{}, false, errors.New("input has neither an image URL nor image bytes")
+		}
+	}
+	ext := utils.ImageFileExtension(utils.DetectImageMIMEType(imageBytes))
+	file := utils.DatasetImagesDir + "/" + input.GetId() + ext
+	if len(existing) == 0 {
+		if err := os.WriteFile(filepath.Join(outputDir, filepath.FromSlash(file)), imageBytes, 0644); err != nil {
+			return utils.DatasetImage{}, false, fmt.Errorf("failed to save image: %w", err)
+		}
+	}
+
+	img := utils.DatasetImage{ID: input.GetId(), File: file}
+	if config, _, err := image.DecodeConfig(bytes.NewReader(imageBytes)); err == nil {
+		img.Width, img.Height = config.Width, config.Height
+	} else if info := source.GetImageInfo(); info != nil {
+		img.Width, img.Height = int(info.GetWidth()), int(info.GetHeight())
+	}
+	if (img.Width == 0 || img.Height == 0) && format != utils.DatasetFormatYOLO {
+		return utils.DatasetImage{}, false, fmt.Errorf("unknown image size; %s needs pixel coordinates", strings.ToUpper(format))
+	}
+
+	for _, annotation := range annotations {
+		for _, region := range annotation.GetData().GetRegions() {
+			box := region.GetRegionInfo().GetBoundingBox()
+			if box == nil {
+				continue
+			}
+			for _, concept := range region.GetData().GetConcepts() {
+				if concept.GetValue() <= 0 { // Negative labels mark absent objects
+					continue
+				}
+				name := concept.GetId()
+				if name == "" {
+					name = concept.GetName()
+				}
+				img.Boxes = append(img.Boxes, utils.DatasetBox{
+					Concept:   name,
+					TopRow:    float64(box.GetTopRow()),
+					LeftCol:   float64(box.GetLeftCol()),
+					BottomRow: float64(box.GetBottomRow()),
+					RightCol:  float64(box.GetRightCol()),
+				})
+			}
+		}
+	}
+	return img, false, nil
+}
+
+// loadExportState reads the progress file of outputDir. It returns nil when there is none.
+func loadExportState(outputDir string) (*exportState, error) {
+	data, err := os.ReadFile(filepath.Join(outputDir, exportStateName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state exportState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", exportStateName, err)
+	}
+	return &state, nil
+}
+
+// saveExportState replaces the export state in outputDir atomically.
+func saveExportState(outputDir string, state *exportState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(outputDir, exportStateName), data)
+}
+
+// exportSummary renders the one-line outcome of an export.
+func exportSummary(report ExportReport) string {
+	var summary string
+	if report.Complete {
+		summary = fmt.Sprintf("Exported %d image(s) with %d bounding box(es) of %d concept(s) as %s to %s.",
+			report.Images, report.Boxes, len(report.Concepts), strings.ToUpper(report.Format), report.OutputDir)
+	} else {
+		summary = fmt.Sprintf("Export in progress: %d image(s) saved to %s so far. Call export_dataset again with the same arguments to resume from page %d.",
+			report.Images, report.OutputDir, report.NextPage)
+	}
+	if len(report.Skipped) > 0 {
+		summary += fmt.Sprintf(" Skipped %d input(s) without an image.", len(report.Skipped))
+	}
+	if len(report.Failures) > 0 {
+		summary += fmt.Sprintf(" %d input(s) failed.", len(report.Failures))
+	}
+	return summary
+}
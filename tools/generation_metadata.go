@@ -0,0 +1,28 @@
+package tools
+
+// GenerationMetadata records everything needed to reproduce a saved generated image.
+// It is written as a JSON sidecar next to the image file.
+type GenerationMetadata struct {
+	Tool         string                 `json:"tool"`
+	Prompt       string                 `json:"prompt"`
+	ModelID      string                 `json:"modelId"`
+	UserID       string                 `json:"userId,omitempty"`
+	AppID        string                 `json:"appId,omitempty"`
+	SourceImage  string                 `json:"sourceImage,omitempty"`
+	MaskImage    string                 `json:"maskImage,omitempty"`
+	Seed         *int64                 `json:"seed,omitempty"` // Absent when the model seeded the image itself
+	Index        int                    `json:"index"`
+	Params       map[string]interface{} `json:"params"` // Inference parameters exactly as sent
+	RequestID    string                 `json:"requestId,omitempty"`
+	OutputFormat string                 `json:"outputFormat,omitempty"`
+	ImageFile    string                 `json:"imageFile"`
+	CreatedAt    string                 `json:"createdAt"`
+}
+
+// generatedImage is one image returned by a generation model, with the request it came from.
+type generatedImage struct {
+	Data      []byte
+	Index     int                    // Position in the batch; the image was generated with seed+Index when a seed was sent
+	Params    map[string]interface{} // Inference parameters sent for this image
+	RequestID string                 // Clarifai request ID of the PostModelOutputs call
+}
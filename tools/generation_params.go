@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"fmt"
+	"math/rand"
+
+	"clarifai-mcp-server-local/mcp"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// maxNumImages caps how many images a single generation tool call may request.
+const maxNumImages = 8
+
+// generationParams holds the tunable parameters of image generation models.
+// Zero values mean "use the model default" and are not sent.
+type generationParams struct {
+	NegativePrompt string
+	Width          int
+	Height         int
+	Seed           int64 // Base seed; image N of a batch uses Seed+N
+	SendSeed       bool  // Whether Seed is sent; false leaves seeding to the model
+	Steps          int
+	GuidanceScale  float64
+	NumImages      int
+	Extra          map[string]interface{} // Arbitrary inference_params, applied last
+}
+
+// generationArgumentSchema returns the JSON schema properties for image generation parameters.
+func generationArgumentSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"negative_prompt": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Things the image should not contain.",
+		},
+		"width": map[string]interface{}{
+			"type":        "integer",
+			"description": "Optional: Output width in pixels.",
+		},
+		"height": map[string]interface{}{
+			"type":        "integer",
+			"description": "Optional: Output height in pixels.",
+		},
+		"seed": map[string]interface{}{
+			"type":        "integer",
+			"description": "Optional: Random seed for reproducible results. Image N of a batch uses seed+N. When omitted, a single image uses the model's own seeding and a batch gets a random base seed, which is reported.",
+		},
+		"steps": map[string]interface{}{
+			"type":        "integer",
+			"description": "Optional: Number of diffusion steps.",
+		},
+		"guidance_scale": map[string]interface{}{
+			"type":        "number",
+			"description": "Optional: How strongly the image should follow the prompt.",
+		},
+		"num_images": map[string]interface{}{
+			"type":        "integer",
+			"description": fmt.Sprintf("Optional: Number of images to generate (1-%d). Defaults to 1.", maxNumImages),
+		},
+		"inference_params": map[string]interface{}{
+			"type":        "object",
+			"description": "Optional: Additional model-specific inference parameters, passed through as-is. Overrides the named parameters above, except 'seed', which is read as the base seed like the seed argument.",
+		},
+	}
+}
+
+// parseGenerationParams reads and validates image generation parameters from tool arguments.
+func parseGenerationParams(args map[string]interface{}) (generationParams, *mcp.RPCError) {
+	params := generationParams{NumImages: 1}
+	params.NegativePrompt, _ = args["negative_prompt"].(string)
+
+	positiveInts := []struct {
+		name   string
+		target *int
+	}{
+		{"width", &params.Width},
+		{"height", &params.Height},
+		{"steps", &params.Steps},
+		{"num_images", &params.NumImages},
+	}
+	for _, p := range positiveInts {
+		value, ok, rpcErr := intArg(args, p.name)
+		if rpcErr != nil {
+			return params, rpcErr
+		}
+		if !ok {
+			continue
+		}
+		if value <= 0 {
+			return params, invalidParam(p.name, "must be a positive integer")
+		}
+		*p.target = value
+	}
+	if params.NumImages > maxNumImages {
+		return params, invalidParam("num_images", fmt.Sprintf("must not exceed %d", maxNumImages))
+	}
+
+	seed, ok, rpcErr := intArg(args, "seed")
+	if rpcErr != nil {
+		return params, rpcErr
+	}
+	if ok {
+		if seed < 0 {
+			return params, invalidParam("seed", "must not be negative")
+		}
+		params.Seed, params.SendSeed = int64(seed), true
+	}
+
+	guidance, ok, rpcErr := floatArg(args, "guidance_scale")
+	if rpcErr != nil {
+		return params, rpcErr
+	}
+	if ok {
+		if guidance <= 0 {
+			return params, invalidParam("guidance_scale", "must be positive")
+		}
+		params.GuidanceScale = guidance
+	}
+
+	if raw, present := args["inference_params"]; present && raw != nil {
+		extra, ok := raw.(map[string]interface{})
+		if !ok {
+			return params, invalidParam("inference_params", "must be an object")
+		}
+		params.Extra = make(map[string]interface{}, len(extra))
+		for k, v := range extra {
+			params.Extra[k] = v
+		}
+		// A seed in inference_params would give every image of a batch the same seed, so it is
+		// taken as the base seed instead of being passed through.
+		if rawSeed, present := params.Extra["seed"]; present {
+			if params.SendSeed {
+				return params, invalidParam("inference_params", "must not set 'seed' when the seed argument is given")
+			}
+			seed, ok := rawSeed.(float64)
+			if !ok || seed < 0 || seed != float64(int64(seed)) {
+				return params, invalidParam("inference_params", "'seed' must be a non-negative integer")
+			}
+			params.Seed, params.SendSeed = int64(seed), true
+			delete(params.Extra, "seed")
+		}
+	}
+
+	// Images of a batch need distinct seeds to differ, so a batch without one gets a random base seed.
+	if !params.SendSeed && params.NumImages > 1 {
+		params.Seed, params.SendSeed = rand.Int63n(1<<32), true
+	}
+	return params, nil
+}
+
+// modelParams builds the inference parameter struct for the image at the given batch index.
+func (p generationParams) modelParams(index int) (*structpb.Struct, error) {
+	values := map[string]interface{}{}
+	if seed, ok := p.seedFor(index); ok {
+		values["seed"] = float64(seed)
+	}
+	if p.NegativePrompt != "" {
+		values["negative_prompt"] = p.NegativePrompt
+	}
+	if p.Width > 0 {
+		values["width"] = float64(p.Width)
+	}
+	if p.Height > 0 {
+		values["height"] = float64(p.Height)
+	}
+	if p.Steps > 0 {
+		values["steps"] = float64(p.Steps)
+	}
+	if p.GuidanceScale > 0 {
+		values["guidance_scale"] = p.GuidanceScale
+	}
+	for k, v := range p.Extra {
+		values[k] = v
+	}
+	params, err := structpb.NewStruct(values)
+	if err != nil {
+		return nil, fmt.Errorf("invalid inference parameters: %w", err)
+	}
+	return params, nil
+}
+
+// seedFor returns the seed sent for the image at the given batch index. ok is false when no
+// seed is sent and the model seeds the image itself.
+func (p generationParams) seedFor(index int) (seed int64, ok bool) {
+	if !p.SendSeed {
+		return 0, false
+	}
+	return p.Seed + int64(index), true
+}
+
+// modelWithParams wraps inference parameters in the Model message expected by PostModelOutputs.
+func modelWithParams(params *structpb.Struct) *pb.Model {
+	return &pb.Model{
+		ModelVersion: &pb.ModelVersion{
+			OutputInfo: &pb.OutputInfo{Params: params},
+		},
+	}
+}
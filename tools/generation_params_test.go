@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGenerationParams(t *testing.T) {
+	t.Run("All parameters", func(t *testing.T) {
+		params, rpcErr := parseGenerationParams(map[string]interface{}{
+			"negative_prompt":  "blurry",
+			"width":            float64(512),
+			"height":           float64(768),
+			"seed":             float64(42),
+			"steps":            float64(30),
+			"guidance_scale":   7.5,
+			"num_images":       float64(2),
+			"inference_params": map[string]interface{}{"sampler": "ddim", "steps": float64(25)},
+		})
+		require.Nil(t, rpcErr)
+		assert.Equal(t, 2, params.NumImages)
+		assert.Equal(t, int64(42), params.Seed)
+
+		modelParams, err := params.modelParams(1)
+		require.NoError(t, err)
+		values := modelParams.AsMap()
+		assert.Equal(t, "blurry", values["negative_prompt"])
+		assert.Equal(t, float64(512), values["width"])
+		assert.Equal(t, float64(768), values["height"])
+		assert.Equal(t, float64(43), values["seed"], "batch index is added to the seed")
+		assert.Equal(t, 7.5, values["guidance_scale"])
+		assert.Equal(t, "ddim", values["sampler"])
+		assert.Equal(t, float64(25), values["steps"], "inference_params override named parameters")
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		params, rpcErr := parseGenerationParams(map[string]interface{}{})
+		require.Nil(t, rpcErr)
+		assert.Equal(t, 1, params.NumImages)
+		modelParams, err := params.modelParams(0)
+		require.NoError(t, err)
+		values := modelParams.AsMap()
+		assert.NotContains(t, values, "seed", "a single image without a seed is seeded by the model")
+		assert.NotContains(t, values, "width")
+		_, ok := params.seedFor(0)
+		assert.False(t, ok)
+	})
+
+	t.Run("Batches without a seed get distinct seeds", func(t *testing.T) {
+		params, rpcErr := parseGenerationParams(map[string]interface{}{"num_images": float64(3)})
+		require.Nil(t, rpcErr)
+		first, err := params.modelParams(0)
+		require.NoError(t, err)
+		third, err := params.modelParams(2)
+		require.NoError(t, err)
+		assert.Equal(t, first.AsMap()["seed"].(float64)+2, third.AsMap()["seed"])
+	})
+
+	t.Run("Seed in inference_params is the base seed", func(t *testing.T) {
+		params, rpcErr := parseGenerationParams(map[string]interface{}{
+			"num_images":       float64(2),
+			"inference_params": map[string]interface{}{"seed": float64(9), "sampler": "ddim"},
+		})
+		require.Nil(t, rpcErr)
+		assert.Equal(t, int64(9), params.Seed)
+		modelParams, err := params.modelParams(1)
+		require.NoError(t, err)
+		assert.Equal(t, float64(10), modelParams.AsMap()["seed"], "inference_params.seed does not override the per-image seed")
+		assert.Equal(t, "ddim", modelParams.AsMap()["sampler"])
+	})
+
+	invalid := []struct {
+		name string
+		args map[string]interface{}
+		msg  string
+	}{
+		{"Zero width", map[string]interface{}{"width": float64(0)}, "'width' must be a positive integer"},
+		{"Too many images", map[string]interface{}{"num_images": float64(maxNumImages + 1)}, "'num_images' must not exceed"},
+		{"Negative seed", map[string]interface{}{"seed": float64(-1)}, "'seed' must not be negative"},
+		{"Non-numeric guidance", map[string]interface{}{"guidance_scale": "high"}, "'guidance_scale' must be a number"},
+		{"Inference params not an object", map[string]interface{}{"inference_params": "x=1"}, "'inference_params' must be an object"},
+		{"Seed given twice", map[string]interface{}{"seed": float64(1), "inference_params": map[string]interface{}{"seed": float64(2)}}, "'inference_params' must not set 'seed'"},
+		{"Fractional inference_params seed", map[string]interface{}{"inference_params": map[string]interface{}{"seed": 1.5}}, "'seed' must be a non-negative integer"},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			_, rpcErr := parseGenerationParams(tc.args)
+			require.NotNil(t, rpcErr)
+			assert.Equal(t, -32602, rpcErr.Code)
+			assert.Contains(t, rpcErr.Message, tc.msg)
+		})
+	}
+}
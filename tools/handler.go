@@ -19,6 +19,7 @@ type Handler struct {
 	timeoutSec     int
 	logger         *slog.Logger
 	config         *config.Config
+	confirmSecret  []byte // Signs confirmation tokens of previewed mutations, see confirmationToken
 }
 
 // NewHandler remains
@@ -30,6 +31,7 @@ func NewHandler(client *clarifai.Client, cfg *config.Config) *Handler {
 		timeoutSec:     cfg.TimeoutSec,
 		logger:         slog.Default(),
 		config:         cfg,
+		confirmSecret:  newConfirmSecret(),
 	}
 }
 
@@ -2,11 +2,16 @@ package tools
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"image"
 	"log/slog"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,6 +24,7 @@ import (
 	statuspb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api/status" // Import status proto
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc" // Import grpc package
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -98,6 +104,134 @@ func (m *MockClarifaiAPIClient) GetAnnotation(ctx context.Context, req *pb.GetAn
 	return args.Get(0).(*pb.SingleAnnotationResponse), args.Error(1)
 }
 
+func (m *MockClarifaiAPIClient) PostInputs(ctx context.Context, req *pb.PostInputsRequest, opts ...grpc.CallOption) (*pb.MultiInputResponse, error) {
+	args := m.Called(ctx, req) // Do not pass mockOpts explicitly
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.MultiInputResponse), args.Error(1)
+}
+
+func (m *MockClarifaiAPIClient) PostAnnotationsSearches(ctx context.Context, req *pb.PostAnnotationsSearchesRequest, opts ...grpc.CallOption) (*pb.MultiSearchResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.MultiSearchResponse), args.Error(1)
+}
+
+func (m *MockClarifaiAPIClient) PostDatasets(ctx context.Context, req *pb.PostDatasetsRequest, opts ...grpc.CallOption) (*pb.MultiDatasetResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.MultiDatasetResponse), args.Error(1)
+}
+
+func (m *MockClarifaiAPIClient) PostDatasetInputs(ctx context.Context, req *pb.PostDatasetInputsRequest, opts ...grpc.CallOption) (*pb.MultiDatasetInputResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.MultiDatasetInputResponse), args.Error(1)
+}
+
+func (m *MockClarifaiAPIClient) DeleteDatasetInputs(ctx context.Context, req *pb.DeleteDatasetInputsRequest, opts ...grpc.CallOption) (*statuspb.BaseResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*statuspb.BaseResponse), args.Error(1)
+}
+
+func (m *MockClarifaiAPIClient) PostDatasetVersions(ctx context.Context, req *pb.PostDatasetVersionsRequest, opts ...grpc.CallOption) (*pb.MultiDatasetVersionResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.MultiDatasetVersionResponse), args.Error(1)
+}
+
+func (m *MockClarifaiAPIClient) PostModels(ctx context.Context, req *pb.PostModelsRequest, opts ...grpc.CallOption) (*pb.SingleModelResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.SingleModelResponse), args.Error(1)
+}
+
+func (m *MockClarifaiAPIClient) PostModelVersions(ctx context.Context, req *pb.PostModelVersionsRequest, opts ...grpc.CallOption) (*pb.SingleModelResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.SingleModelResponse), args.Error(1)
+}
+
+func (m *MockClarifaiAPIClient) GetModelVersion(ctx context.Context, req *pb.GetModelVersionRequest, opts ...grpc.CallOption) (*pb.SingleModelVersionResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.SingleModelVersionResponse), args.Error(1)
+}
+
+func (m *MockClarifaiAPIClient) PostModelVersionEvaluations(ctx context.Context, req *pb.PostModelVersionEvaluationsRequest, opts ...grpc.CallOption) (*pb.MultiEvalMetricsResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.MultiEvalMetricsResponse), args.Error(1)
+}
+
+func (m *MockClarifaiAPIClient) GetModelVersionEvaluation(ctx context.Context, req *pb.GetModelVersionEvaluationRequest, opts ...grpc.CallOption) (*pb.SingleEvalMetricsResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.SingleEvalMetricsResponse), args.Error(1)
+}
+
+func (m *MockClarifaiAPIClient) PostAnnotations(ctx context.Context, req *pb.PostAnnotationsRequest, opts ...grpc.CallOption) (*pb.MultiAnnotationResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.MultiAnnotationResponse), args.Error(1)
+}
+
+func (m *MockClarifaiAPIClient) PatchAnnotations(ctx context.Context, req *pb.PatchAnnotationsRequest, opts ...grpc.CallOption) (*pb.MultiAnnotationResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.MultiAnnotationResponse), args.Error(1)
+}
+
+func (m *MockClarifaiAPIClient) DeleteAnnotation(ctx context.Context, req *pb.DeleteAnnotationRequest, opts ...grpc.CallOption) (*statuspb.BaseResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*statuspb.BaseResponse), args.Error(1)
+}
+
+func (m *MockClarifaiAPIClient) PatchInputs(ctx context.Context, req *pb.PatchInputsRequest, opts ...grpc.CallOption) (*pb.MultiInputResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pb.MultiInputResponse), args.Error(1)
+}
+
+func (m *MockClarifaiAPIClient) DeleteInputs(ctx context.Context, req *pb.DeleteInputsRequest, opts ...grpc.CallOption) (*statuspb.BaseResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*statuspb.BaseResponse), args.Error(1)
+}
+
 // --- Test Setup ---
 
 func setupTestHandler(mockAPI *MockClarifaiAPIClient) *Handler {
@@ -114,6 +248,16 @@ func setupTestHandler(mockAPI *MockClarifaiAPIClient) *Handler {
 	return handler
 }
 
+// callTool sends a tools/call request for name with args to handler.
+func callTool(handler *Handler, name string, args map[string]interface{}) mcp.JSONRPCResponse {
+	return *handler.HandleRequest(mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "req-" + name,
+		Method:  "tools/call",
+		Params:  mcp.RequestParams{Name: name, Arguments: args},
+	})
+}
+
 // Helper to create a success status proto
 func successStatus() *statuspb.Status {
 	return &statuspb.Status{Code: statuspb.StatusCode_SUCCESS}
@@ -379,6 +523,75 @@ func TestHandleReadResource_ListInputs_Success(t *testing.T) {
 	mockAPI.AssertExpectations(t)
 }
 
+func TestHandleReadResource_SearchQuery(t *testing.T) {
+	readResource := func(handler *Handler, uri string) *mcp.JSONRPCResponse {
+		return handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-search-query",
+			Method:  "resources/read",
+			Params:  mcp.RequestParams{URI: uri},
+		})
+	}
+
+	t.Run("Inputs", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputsSearches", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsSearchesRequest) bool {
+			query := r.Searches[0].Query
+			return len(query.Filters) == 2 &&
+				query.Filters[0].Annotation.Data.Concepts[0].Id == "cat" &&
+				query.Filters[1].Negate && query.Filters[1].Annotation.Data.Concepts[0].Id == "dog" &&
+				len(query.Ranks) == 0
+		})).Return(&pb.MultiSearchResponse{
+			Status: successStatus(),
+			Hits:   []*pb.Hit{{Input: &pb.Input{Id: "input-1"}}},
+		}, nil)
+
+		resp := readResource(setupTestHandler(mockAPI), "clarifai://u/a/inputs?query="+url.QueryEscape("concept:cat NOT concept:dog"))
+		require.Nil(t, resp.Error)
+		contents := resp.Result.(map[string]interface{})["contents"].([]map[string]interface{})
+		require.Len(t, contents, 1)
+		assert.Equal(t, "clarifai://u/a/inputs/input-1", contents[0]["uri"])
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Plain text keeps the text search", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputsSearches", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsSearchesRequest) bool {
+			return r.Searches[0].Query.Ranks[0].Annotation.Data.Text.Raw == "red car"
+		})).Return(&pb.MultiSearchResponse{Status: successStatus()}, nil)
+
+		resp := readResource(setupTestHandler(mockAPI), "clarifai://u/a/inputs?query=red+car")
+		require.Nil(t, resp.Error)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Annotations", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostAnnotationsSearches", mock.Anything, mock.MatchedBy(func(r *pb.PostAnnotationsSearchesRequest) bool {
+			return r.Searches[0].Query.Filters[0].Annotation.Data.Metadata.Fields["source"].GetStringValue() == "web"
+		})).Return(&pb.MultiSearchResponse{
+			Status: successStatus(),
+			Hits:   []*pb.Hit{{Annotation: &pb.Annotation{Id: "ann-1", InputId: "input-1"}}},
+		}, nil)
+
+		resp := readResource(setupTestHandler(mockAPI), "clarifai://u/a/annotations?query=metadata.source%3Dweb")
+		require.Nil(t, resp.Error)
+		contents := resp.Result.(map[string]interface{})["contents"].([]map[string]interface{})
+		require.Len(t, contents, 1)
+		assert.Equal(t, "clarifai://u/a/annotations/ann-1", contents[0]["uri"])
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		resp := readResource(setupTestHandler(mockAPI), "clarifai://u/a/inputs?query="+url.QueryEscape("concept:cat OR concept:dog"))
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Contains(t, resp.Error.Message, "OR is not supported")
+		mockAPI.AssertNotCalled(t, "PostInputsSearches", mock.Anything, mock.Anything)
+	})
+}
+
 // --- Tool Call Tests ---
 
 // TestCallInferImage_Success_Bytes is renamed and modified to test the file read error path,
@@ -417,9 +630,2472 @@ func TestCallClarifaiImageByPath_FileReadError(t *testing.T) {
 	mockAPI.AssertNotCalled(t, "PostModelOutputs", mock.Anything, mock.Anything)
 }
 
-// TODO: Add a separate test for the success path of clarifai_image_by_path
-// This would require mocking os.ReadFile or using a real temp file.
+func TestCallClarifaiImageByPath_Annotate(t *testing.T) {
+	mockAPI := new(MockClarifaiAPIClient)
+	handler := setupTestHandler(mockAPI)
+	handler.outputPath = t.TempDir()
+
+	// Write a real PNG so the annotation step can decode it.
+	imagePath := handler.outputPath + "/input.png"
+	pngBytes, err := utils.EncodePNG(image.NewRGBA(image.Rect(0, 0, 64, 64)))
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(imagePath, pngBytes, 0644))
+
+	mockResp := &pb.MultiOutputResponse{
+		Status: successStatus(),
+		Outputs: []*pb.Output{{
+			Data: &pb.Data{Regions: []*pb.Region{
+				{
+					RegionInfo: &pb.RegionInfo{BoundingBox: &pb.BoundingBox{TopRow: 0.1, LeftCol: 0.1, BottomRow: 0.6, RightCol: 0.6}},
+					Data:       &pb.Data{Concepts: []*pb.Concept{{Name: "cat", Value: 0.9}}},
+				},
+				{
+					RegionInfo: &pb.RegionInfo{BoundingBox: &pb.BoundingBox{TopRow: 0.5, LeftCol: 0.5, BottomRow: 0.9, RightCol: 0.9}},
+					Data:       &pb.Data{Concepts: []*pb.Concept{{Name: "dog", Value: 0.2}}},
+				},
+			}},
+		}},
+	}
+	mockAPI.On("PostModelOutputs", mock.Anything, mock.MatchedBy(func(r *pb.PostModelOutputsRequest) bool {
+		return r.ModelId == "general-image-detection" && len(r.Inputs) == 1
+	})).Return(mockResp, nil)
+
+	req := mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "req-annotate-1",
+		Method:  "tools/call",
+		Params: mcp.RequestParams{
+			Name: "clarifai_image_by_path",
+			Arguments: map[string]interface{}{
+				"filepath":       imagePath,
+				"annotate":       true,
+				"min_confidence": 0.5,
+			},
+		},
+	}
+
+	resp := handler.HandleRequest(req)
+
+	assert.NotNil(t, resp)
+	assert.Nil(t, resp.Error)
+	content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+	assert.Len(t, content, 3)
+
+	var summary InferenceSummary
+	assert.NoError(t, json.Unmarshal([]byte(content[0]["text"].(string)), &summary))
+	assert.Len(t, summary.Outputs[0].Regions, 1)
+	assert.Equal(t, "cat", summary.Outputs[0].Regions[0].Label)
+
+	assert.Equal(t, "image", content[1]["type"])
+	assert.Equal(t, "image/png", content[1]["mimeType"])
+	assert.NotEmpty(t, content[1]["data"])
+	assert.Contains(t, content[2]["text"], "Annotated image saved to: "+handler.outputPath)
+
+	mockAPI.AssertExpectations(t)
+}
+
+func TestCallCropRegions(t *testing.T) {
+	mockAPI := new(MockClarifaiAPIClient)
+	handler := setupTestHandler(mockAPI)
+	tempDir := t.TempDir()
+
+	imagePath := tempDir + "/source.png"
+	pngBytes, err := utils.EncodePNG(image.NewRGBA(image.Rect(0, 0, 100, 50)))
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(imagePath, pngBytes, 0644))
+
+	mockResp := &pb.MultiOutputResponse{
+		Status: successStatus(),
+		Outputs: []*pb.Output{{
+			Data: &pb.Data{Regions: []*pb.Region{
+				{
+					Id:         "region-cat",
+					RegionInfo: &pb.RegionInfo{BoundingBox: &pb.BoundingBox{TopRow: 0, LeftCol: 0, BottomRow: 0.5, RightCol: 0.5}},
+					Data:       &pb.Data{Concepts: []*pb.Concept{{Id: "cat", Name: "Cat", Value: 0.9}}},
+				},
+				{
+					Id:         "region-dog",
+					RegionInfo: &pb.RegionInfo{BoundingBox: &pb.BoundingBox{TopRow: 0.5, LeftCol: 0.5, BottomRow: 1, RightCol: 1}},
+					Data:       &pb.Data{Concepts: []*pb.Concept{{Id: "dog", Name: "Dog", Value: 0.95}}},
+				},
+				{
+					Id:         "region-weak-cat",
+					RegionInfo: &pb.RegionInfo{BoundingBox: &pb.BoundingBox{TopRow: 0.2, LeftCol: 0.2, BottomRow: 0.4, RightCol: 0.4}},
+					Data:       &pb.Data{Concepts: []*pb.Concept{{Id: "cat", Name: "Cat", Value: 0.1}}},
+				},
+			}},
+		}},
+	}
+	mockAPI.On("PostModelOutputs", mock.Anything, mock.Anything).Return(mockResp, nil)
+
+	outputDir := tempDir + "/crops"
+	req := mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "req-crop-1",
+		Method:  "tools/call",
+		Params: mcp.RequestParams{
+			Name: "crop_regions",
+			Arguments: map[string]interface{}{
+				"filepath":       imagePath,
+				"concepts":       []interface{}{"cat"},
+				"min_confidence": 0.5,
+				"output_dir":     outputDir,
+			},
+		},
+	}
+
+	resp := handler.HandleRequest(req)
+
+	assert.NotNil(t, resp)
+	assert.Nil(t, resp.Error)
+
+	manifestBytes, err := os.ReadFile(outputDir + "/manifest.json")
+	assert.NoError(t, err)
+	var manifest CropManifest
+	assert.NoError(t, json.Unmarshal(manifestBytes, &manifest))
+	assert.Equal(t, imagePath, manifest.Source)
+	assert.Equal(t, 100, manifest.ImageWidth)
+	if assert.Len(t, manifest.Crops, 1) {
+		crop := manifest.Crops[0]
+		assert.Equal(t, "region-cat", crop.RegionID)
+		assert.Equal(t, "Cat", crop.Label)
+		assert.Equal(t, PixelBox{X: 0, Y: 0, Width: 50, Height: 25}, crop.PixelBox)
+		assert.Equal(t, outputDir+"/crop_001_cat.png", crop.File)
+
+		cropBytes, err := os.ReadFile(crop.File)
+		assert.NoError(t, err)
+		cropImg, format, err := utils.DecodeImage(cropBytes)
+		assert.NoError(t, err)
+		assert.Equal(t, "png", format)
+		assert.Equal(t, image.Rect(0, 0, 50, 25), cropImg.Bounds())
+	}
+	mockAPI.AssertExpectations(t)
+}
+
+func TestCallGenerateImage_MultipleImages(t *testing.T) {
+	mockAPI := new(MockClarifaiAPIClient)
+	handler := setupTestHandler(mockAPI)
+	handler.outputPath = t.TempDir()
+	pngBytes, err := utils.EncodePNG(image.NewRGBA(image.Rect(0, 0, 8, 8)))
+	require.NoError(t, err)
+
+	var seeds []float64
+	mockAPI.On("PostModelOutputs", mock.Anything, mock.MatchedBy(func(r *pb.PostModelOutputsRequest) bool {
+		params := r.GetModel().GetModelVersion().GetOutputInfo().GetParams().AsMap()
+		seeds = append(seeds, params["seed"].(float64))
+		return r.ModelId == "stable-diffusion-xl" && params["negative_prompt"] == "text" && params["width"] == float64(256)
+	})).Return(&pb.MultiOutputResponse{
+		Status:  successStatus(),
+		Outputs: []*pb.Output{{Data: &pb.Data{Image: &pb.Image{Base64: pngBytes}}}},
+	}, nil)
+
+	req := mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "req-generate-1",
+		Method:  "tools/call",
+		Params: mcp.RequestParams{
+			Name: "generate_image",
+			Arguments: map[string]interface{}{
+				"text_prompt":     "a cat",
+				"negative_prompt": "text",
+				"width":           float64(256),
+				"seed":            float64(7),
+				"num_images":      float64(2),
+			},
+		},
+	}
+
+	resp := handler.HandleRequest(req)
+
+	assert.NotNil(t, resp)
+	assert.Nil(t, resp.Error)
+	content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+	assert.Len(t, content, 3) // Summary text plus one entry per image
+	assert.Contains(t, content[0]["text"], "seed 7")
+	assert.Equal(t, []float64{7, 8}, seeds)
+	mockAPI.AssertNumberOfCalls(t, "PostModelOutputs", 2)
+}
+
+func TestCallGenerateImage_ReturnModes(t *testing.T) {
+	pngBytes, err := utils.EncodePNG(image.NewRGBA(image.Rect(0, 0, 8, 8)))
+	require.NoError(t, err)
+	encoded := base64.StdEncoding.EncodeToString(pngBytes)
+
+	testCases := []struct {
+		name      string
+		mode      string
+		imageData []byte
+		types     []string
+	}{
+		{"Auto inlines small images", "", pngBytes, []string{"text", "image"}},
+		{"Inline decodes base64 data URIs", "inline", []byte("data:image/png;base64," + encoded), []string{"text", "image"}},
+		{"Path", "path", pngBytes, []string{"text", "text"}},
+		{"Both", "both", pngBytes, []string{"text", "text", "image"}},
+		{"Resource", "resource", pngBytes, []string{"text", "resource"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := new(MockClarifaiAPIClient)
+			handler := setupTestHandler(mockAPI)
+			handler.outputPath = t.TempDir()
+			mockAPI.On("PostModelOutputs", mock.Anything, mock.Anything).Return(&pb.MultiOutputResponse{
+				Status:  successStatus(),
+				Outputs: []*pb.Output{{Data: &pb.Data{Image: &pb.Image{Base64: tc.imageData}}}},
+			}, nil)
+
+			args := map[string]interface{}{"text_prompt": "a cat"}
+			if tc.mode != "" {
+				args["return_mode"] = tc.mode
+			}
+			resp := handler.HandleRequest(mcp.JSONRPCRequest{
+				JSONRPC: "2.0",
+				ID:      "req-generate-mode",
+				Method:  "tools/call",
+				Params:  mcp.RequestParams{Name: "generate_image", Arguments: args},
+			})
+
+			require.Nil(t, resp.Error)
+			content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+			var types []string
+			for _, item := range content {
+				types = append(types, item["type"].(string))
+				switch item["type"] {
+				case "image":
+					assert.Equal(t, "image/png", item["mimeType"])
+					assert.Equal(t, encoded, item["data"])
+					assert.NotContains(t, item, "bytes")
+				case "resource":
+					resource := item["resource"].(map[string]interface{})
+					assert.True(t, strings.HasPrefix(resource["uri"].(string), "file://"+handler.outputPath))
+					assert.Equal(t, "image/png", resource["mimeType"])
+					assert.Equal(t, encoded, resource["blob"])
+				}
+			}
+			assert.Equal(t, tc.types, types)
+		})
+	}
+
+	t.Run("Auto saves images over the inline limit", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		handler := setupTestHandler(mockAPI)
+		handler.outputPath = t.TempDir()
+		handler.config.InlineImageMaxBytes = 10
+		mockAPI.On("PostModelOutputs", mock.Anything, mock.Anything).Return(&pb.MultiOutputResponse{
+			Status:  successStatus(),
+			Outputs: []*pb.Output{{Data: &pb.Data{Image: &pb.Image{Base64: pngBytes}}}},
+		}, nil)
+
+		resp := handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-generate-auto",
+			Method:  "tools/call",
+			Params:  mcp.RequestParams{Name: "generate_image", Arguments: map[string]interface{}{"text_prompt": "a cat"}},
+		})
+
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+		require.Len(t, content, 2)
+		assert.Contains(t, content[1]["text"], "Image saved to: "+handler.outputPath)
+	})
+
+	t.Run("Re-encode to JPEG", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		handler := setupTestHandler(mockAPI)
+		handler.outputPath = t.TempDir()
+		mockAPI.On("PostModelOutputs", mock.Anything, mock.Anything).Return(&pb.MultiOutputResponse{
+			Status:  successStatus(),
+			Outputs: []*pb.Output{{Data: &pb.Data{Image: &pb.Image{Base64: pngBytes}}}},
+		}, nil)
+
+		resp := handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-generate-jpeg",
+			Method:  "tools/call",
+			Params: mcp.RequestParams{Name: "generate_image", Arguments: map[string]interface{}{
+				"text_prompt":   "a cat",
+				"return_mode":   "both",
+				"output_format": "jpg",
+				"quality":       float64(80),
+			}},
+		})
+
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+		require.Len(t, content, 3)
+		assert.Contains(t, content[1]["text"], ".jpg\n")
+		assert.Equal(t, "image/jpeg", content[2]["mimeType"])
+	})
+
+	for _, bad := range []struct {
+		name string
+		args map[string]interface{}
+		msg  string
+	}{
+		{"Invalid format", map[string]interface{}{"output_format": "tiff"}, "'output_format'"},
+		{"Quality out of range", map[string]interface{}{"quality": float64(101)}, "'quality'"},
+	} {
+		t.Run(bad.name, func(t *testing.T) {
+			handler := setupTestHandler(new(MockClarifaiAPIClient))
+			bad.args["text_prompt"] = "a cat"
+			resp := handler.HandleRequest(mcp.JSONRPCRequest{
+				JSONRPC: "2.0",
+				ID:      "req-generate-bad-output",
+				Method:  "tools/call",
+				Params:  mcp.RequestParams{Name: "generate_image", Arguments: bad.args},
+			})
+			require.NotNil(t, resp.Error)
+			assert.Equal(t, -32602, resp.Error.Code)
+			assert.Contains(t, resp.Error.Message, bad.msg)
+		})
+	}
+
+	t.Run("Invalid mode", func(t *testing.T) {
+		handler := setupTestHandler(new(MockClarifaiAPIClient))
+		resp := handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-generate-bad-mode",
+			Method:  "tools/call",
+			Params:  mcp.RequestParams{Name: "generate_image", Arguments: map[string]interface{}{"text_prompt": "a cat", "return_mode": "email"}},
+		})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Contains(t, resp.Error.Message, "'return_mode'")
+	})
+}
+
+func TestCallGenerateImage_WithoutSeed(t *testing.T) {
+	mockAPI := new(MockClarifaiAPIClient)
+	handler := setupTestHandler(mockAPI)
+	handler.outputPath = t.TempDir()
+	handler.config.OutputNameTemplate = "{prompt_slug}_{seed}"
+	pngBytes, err := utils.EncodePNG(image.NewRGBA(image.Rect(0, 0, 8, 8)))
+	require.NoError(t, err)
+
+	mockAPI.On("PostModelOutputs", mock.Anything, mock.MatchedBy(func(r *pb.PostModelOutputsRequest) bool {
+		_, hasSeed := r.GetModel().GetModelVersion().GetOutputInfo().GetParams().AsMap()["seed"]
+		return !hasSeed
+	})).Return(&pb.MultiOutputResponse{
+		Status:  successStatus(),
+		Outputs: []*pb.Output{{Data: &pb.Data{Image: &pb.Image{Base64: pngBytes}}}},
+	}, nil).Once()
+
+	resp := handler.HandleRequest(mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "req-generate-no-seed",
+		Method:  "tools/call",
+		Params: mcp.RequestParams{Name: "generate_image", Arguments: map[string]interface{}{
+			"text_prompt": "a lighthouse",
+			"return_mode": "path",
+		}},
+	})
+
+	require.Nil(t, resp.Error)
+	content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+	require.Len(t, content, 2)
+	assert.Contains(t, content[0]["text"], "seeded by the model")
+	text := content[1]["text"].(string)
+	sidecarPath := text[strings.Index(text, "Metadata: ")+len("Metadata: "):]
+	sidecarBytes, err := os.ReadFile(sidecarPath)
+	require.NoError(t, err)
+	var meta GenerationMetadata
+	require.NoError(t, json.Unmarshal(sidecarBytes, &meta))
+	assert.Nil(t, meta.Seed, "no seed is recorded when none was sent")
+	assert.NotContains(t, string(sidecarBytes), `"seed"`)
+	assert.Equal(t, "a-lighthouse", strings.TrimSuffix(filepath.Base(meta.ImageFile), filepath.Ext(meta.ImageFile)))
+	mockAPI.AssertExpectations(t)
+}
+
+func TestCallGenerateImage_SidecarMetadata(t *testing.T) {
+	mockAPI := new(MockClarifaiAPIClient)
+	handler := setupTestHandler(mockAPI)
+	handler.outputPath = t.TempDir()
+	handler.config.OutputNameTemplate = "{model}/{prompt_slug}_{seed}_{index}"
+	pngBytes, err := utils.EncodePNG(image.NewRGBA(image.Rect(0, 0, 8, 8)))
+	require.NoError(t, err)
+
+	mockAPI.On("PostModelOutputs", mock.Anything, mock.Anything).Return(&pb.MultiOutputResponse{
+		Status:  &statuspb.Status{Code: statuspb.StatusCode_SUCCESS, ReqId: "req-abc"},
+		Outputs: []*pb.Output{{Data: &pb.Data{Image: &pb.Image{Base64: pngBytes}}}},
+	}, nil)
+
+	resp := handler.HandleRequest(mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "req-generate-sidecar",
+		Method:  "tools/call",
+		Params: mcp.RequestParams{Name: "generate_image", Arguments: map[string]interface{}{
+			"text_prompt":     "a lighthouse",
+			"negative_prompt": "fog",
+			"seed":            float64(100),
+			"num_images":      float64(2),
+			"return_mode":     "path",
+		}},
+	})
+
+	require.Nil(t, resp.Error)
+	content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+	require.Len(t, content, 3)
+
+	text := content[2]["text"].(string)
+	require.Contains(t, text, "Metadata: ")
+	sidecarPath := text[strings.Index(text, "Metadata: ")+len("Metadata: "):]
+	sidecarBytes, err := os.ReadFile(sidecarPath)
+	require.NoError(t, err)
+
+	var meta GenerationMetadata
+	require.NoError(t, json.Unmarshal(sidecarBytes, &meta))
+	assert.Equal(t, "generate_image", meta.Tool)
+	assert.Equal(t, "a lighthouse", meta.Prompt)
+	assert.Equal(t, "stable-diffusion-xl", meta.ModelID)
+	assert.Equal(t, "stability-ai", meta.UserID)
+	require.NotNil(t, meta.Seed)
+	assert.Equal(t, int64(101), *meta.Seed)
+	assert.Equal(t, 1, meta.Index)
+	assert.Equal(t, "fog", meta.Params["negative_prompt"])
+	assert.Equal(t, float64(101), meta.Params["seed"])
+	assert.Equal(t, "req-abc", meta.RequestID)
+	assert.Equal(t, utils.SidecarPath(meta.ImageFile), sidecarPath)
+	assert.True(t, strings.HasPrefix(meta.ImageFile, filepath.Join(handler.outputPath, "stable-diffusion-xl", "a-lighthouse_101_1")), meta.ImageFile)
+	assert.FileExists(t, meta.ImageFile)
+	assert.NotEmpty(t, meta.CreatedAt)
+}
+
+func TestCallEditImage_WithMask(t *testing.T) {
+	mockAPI := new(MockClarifaiAPIClient)
+	handler := setupTestHandler(mockAPI)
+	tempDir := t.TempDir()
+	handler.outputPath = tempDir
+
+	sourcePath := tempDir + "/source.png"
+	maskPath := tempDir + "/mask.png"
+	assert.NoError(t, os.WriteFile(sourcePath, []byte("source-bytes"), 0644))
+	assert.NoError(t, os.WriteFile(maskPath, []byte("mask-bytes"), 0644))
+	editedBytes, err := utils.EncodePNG(image.NewRGBA(image.Rect(0, 0, 8, 8)))
+	require.NoError(t, err)
+
+	mockAPI.On("PostModelOutputs", mock.Anything, mock.MatchedBy(func(r *pb.PostModelOutputsRequest) bool {
+		data := r.Inputs[0].Data
+		return r.ModelId == "inpaint-model" &&
+			string(data.Image.Base64) == "source-bytes" &&
+			data.Text.Raw == "add a hat" &&
+			string(data.Regions[0].RegionInfo.Mask.Image.Base64) == "mask-bytes"
+	})).Return(&pb.MultiOutputResponse{
+		Status:  successStatus(),
+		Outputs: []*pb.Output{{Data: &pb.Data{Image: &pb.Image{Base64: editedBytes}}}},
+	}, nil)
+
+	req := mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "req-edit-1",
+		Method:  "tools/call",
+		Params: mcp.RequestParams{
+			Name: "edit_image",
+			Arguments: map[string]interface{}{
+				"filepath":      sourcePath,
+				"mask_filepath": maskPath,
+				"text_prompt":   "add a hat",
+				"model_id":      "inpaint-model",
+			},
+		},
+	}
+
+	resp := handler.HandleRequest(req)
+
+	assert.NotNil(t, resp)
+	assert.Nil(t, resp.Error)
+	content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+	assert.Len(t, content, 2)
+	mockAPI.AssertExpectations(t)
+
+	t.Run("Missing model", func(t *testing.T) {
+		req.Params.Arguments = map[string]interface{}{"filepath": sourcePath, "text_prompt": "add a hat"}
+		resp := handler.HandleRequest(req)
+		assert.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Contains(t, resp.Error.Message, "'model_id'")
+	})
+}
+
+func TestCallUploadFile_WithOptions(t *testing.T) {
+	mockAPI := new(MockClarifaiAPIClient)
+	handler := setupTestHandler(mockAPI)
+	filePath := filepath.Join(t.TempDir(), "cat.jpg")
+	require.NoError(t, os.WriteFile(filePath, []byte("jpeg-bytes"), 0644))
+
+	mockAPI.On("PostInputs", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsRequest) bool {
+		if len(r.Inputs) != 1 {
+			return false
+		}
+		input := r.Inputs[0]
+		return input.Id == "cat-001" &&
+			string(input.Data.Image.Base64) == "jpeg-bytes" &&
+			len(input.Data.Concepts) == 2 &&
+			input.Data.Concepts[0].Id == "cat" && input.Data.Concepts[0].Value == 1 &&
+			input.Data.Concepts[1].Id == "dog" && input.Data.Concepts[1].Value == 0 &&
+			input.Data.Metadata.AsMap()["batch"] == "2025-04" &&
+			input.Data.Geo.GetGeoPoint().GetLatitude() == float32(40.5) &&
+			len(input.DatasetIds) == 1 && input.DatasetIds[0] == "pets"
+	})).Return(&pb.MultiInputResponse{Status: successStatus(), Inputs: []*pb.Input{{Id: "cat-001"}}}, nil)
+
+	resp := handler.HandleRequest(mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      "req-upload-options",
+		Method:  "tools/call",
+		Params: mcp.RequestParams{Name: "upload_file", Arguments: map[string]interface{}{
+			"filepath":          filePath,
+			"input_id":          "cat-001",
+			"concepts":          []interface{}{"cat"},
+			"negative_concepts": []interface{}{"dog"},
+			"metadata":          map[string]interface{}{"batch": "2025-04"},
+			"latitude":          40.5,
+			"longitude":         -3.7,
+			"dataset_id":        "pets",
+		}},
+	})
 
+	require.Nil(t, resp.Error)
+	content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+	assert.Contains(t, content[0]["text"], "File uploaded successfully.")
+	mockAPI.AssertExpectations(t)
+
+	t.Run("Invalid options are rejected before uploading", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		handler := setupTestHandler(mockAPI)
+		resp := handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-upload-invalid",
+			Method:  "tools/call",
+			Params: mcp.RequestParams{Name: "upload_file", Arguments: map[string]interface{}{
+				"filepath": filePath,
+				"latitude": 40.5,
+			}},
+		})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		mockAPI.AssertNotCalled(t, "PostInputs", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCallUploadFile_ContentTypes(t *testing.T) {
+	tempDir := t.TempDir()
+	textPath := filepath.Join(tempDir, "notes.txt")
+	require.NoError(t, os.WriteFile(textPath, []byte("a note about cats"), 0644))
+	pdfBytes, err := os.ReadFile(filepath.Join("..", "utils", "testdata", "sample.pdf"))
+	require.NoError(t, err)
+	pdfPath := filepath.Join(tempDir, "report.pdf")
+	require.NoError(t, os.WriteFile(pdfPath, pdfBytes, 0644))
+	zipPath := filepath.Join(tempDir, "archive.zip")
+	require.NoError(t, os.WriteFile(zipPath, []byte("PK\x03\x04\x14\x00\x00\x00"), 0644))
+
+	upload := func(handler *Handler, args map[string]interface{}) mcp.JSONRPCResponse {
+		return *handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-upload-content",
+			Method:  "tools/call",
+			Params:  mcp.RequestParams{Name: "upload_file", Arguments: args},
+		})
+	}
+
+	t.Run("Text file", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputs", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsRequest) bool {
+			data := r.Inputs[0].Data
+			return len(r.Inputs) == 1 && data.Image == nil && data.Text.GetRaw() == "a note about cats"
+		})).Return(&pb.MultiInputResponse{Status: successStatus()}, nil)
+
+		resp := upload(setupTestHandler(mockAPI), map[string]interface{}{"filepath": textPath})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+		assert.Contains(t, content[0]["text"], "Uploaded as text input")
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("PDF pages become text inputs", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputs", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsRequest) bool {
+			if len(r.Inputs) != 1 {
+				return false
+			}
+			input := r.Inputs[0]
+			metadata := input.Data.Metadata.AsMap()
+			return input.Id == "report-page-1" &&
+				input.Data.Text.GetRaw() == "Hello page one" &&
+				metadata["page"] == float64(1) &&
+				metadata["source_file"] == "report.pdf" &&
+				metadata["owner"] == "qa"
+		})).Return(&pb.MultiInputResponse{Status: successStatus()}, nil)
+
+		resp := upload(setupTestHandler(mockAPI), map[string]interface{}{
+			"filepath": pdfPath,
+			"input_id": "report",
+			"metadata": map[string]interface{}{"owner": "qa"},
+		})
+		require.Nil(t, resp.Error)
+		text := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})[0]["text"]
+		assert.Contains(t, text, "PDF uploaded as 1 text input(s)")
+		assert.Contains(t, text, "Skipped page 2 has no text layer")
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Unsupported file", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		resp := upload(setupTestHandler(mockAPI), map[string]interface{}{"filepath": zipPath})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Contains(t, resp.Error.Message, "unsupported file type")
+		mockAPI.AssertNotCalled(t, "PostInputs", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCallCleanupOutputs(t *testing.T) {
+	handler := setupTestHandler(new(MockClarifaiAPIClient))
+	handler.outputPath = t.TempDir()
+	old := filepath.Join(handler.outputPath, "generated_image_old.png")
+	recent := filepath.Join(handler.outputPath, "generated_image_recent.png")
+	for _, path := range []string{old, recent} {
+		require.NoError(t, os.WriteFile(path, []byte("png"), 0644))
+	}
+	oldTime := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(old, oldTime, oldTime))
+
+	call := func(args map[string]interface{}) mcp.JSONRPCResponse {
+		return *handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-cleanup",
+			Method:  "tools/call",
+			Params:  mcp.RequestParams{Name: "cleanup_outputs", Arguments: args},
+		})
+	}
+
+	t.Run("Dry run by default", func(t *testing.T) {
+		resp := call(map[string]interface{}{"max_age": "24h"})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+		assert.Contains(t, content[0]["text"], "Would delete 1 of 2")
+		assert.Contains(t, content[1]["text"], "generated_image_old.png")
+		assert.FileExists(t, old)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		resp := call(map[string]interface{}{"max_age": "24h", "dry_run": false})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+		assert.Contains(t, content[0]["text"], "Deleted 1 of 2")
+		assert.NoFileExists(t, old)
+		assert.FileExists(t, recent)
+	})
+
+	t.Run("No limits", func(t *testing.T) {
+		resp := call(map[string]interface{}{})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Contains(t, resp.Error.Message, "no retention limits")
+	})
+
+	t.Run("Invalid max_age", func(t *testing.T) {
+		resp := call(map[string]interface{}{"max_age": "three days"})
+		require.NotNil(t, resp.Error)
+		assert.Contains(t, resp.Error.Message, "'max_age'")
+	})
+}
+
+func TestCallBulkUpload(t *testing.T) {
+	dir := t.TempDir()
+	pngBytes, err := os.ReadFile(filepath.Join("..", "utils", "testdata", "sample.png"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cat.png"), pngBytes, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("a note"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "archive.zip"), []byte("PK\x03\x04\x14\x00\x00\x00"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden.txt"), []byte("secret"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "dog.txt"), []byte("a dog"), 0644))
+
+	bulkUpload := func(handler *Handler, args map[string]interface{}) mcp.JSONRPCResponse {
+		return *handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-bulk-upload",
+			Method:  "tools/call",
+			Params:  mcp.RequestParams{Name: "bulk_upload", Arguments: args},
+		})
+	}
+	results := func(t *testing.T, resp mcp.JSONRPCResponse) (string, map[string]BulkUploadResult) {
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+		var list []BulkUploadResult
+		require.NoError(t, json.Unmarshal([]byte(content[1]["text"].(string)), &list))
+		byFile := map[string]BulkUploadResult{}
+		for _, r := range list {
+			byFile[r.File] = r
+		}
+		return content[0]["text"].(string), byFile
+	}
+	hasInput := func(prefix string) interface{} {
+		return mock.MatchedBy(func(r *pb.PostInputsRequest) bool {
+			return len(r.Inputs) == 1 && strings.HasPrefix(r.Inputs[0].Id, prefix)
+		})
+	}
+
+	t.Run("First run records failures in the manifest", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputs", mock.Anything, hasInput("cat-png-")).Return(&pb.MultiInputResponse{Status: successStatus()}, nil)
+		mockAPI.On("PostInputs", mock.Anything, hasInput("sub-dog-txt-")).Return(&pb.MultiInputResponse{Status: successStatus()}, nil)
+		mockAPI.On("PostInputs", mock.Anything, hasInput("notes-txt-")).Return(nil, errors.New("connection reset"))
+		mockAPI.On("ListInputs", mock.Anything, mock.Anything).Return(&pb.MultiInputResponse{Status: successStatus()}, nil)
+
+		summary, byFile := results(t, bulkUpload(setupTestHandler(mockAPI), map[string]interface{}{
+			"directory":  dir,
+			"batch_size": float64(1),
+			"workers":    float64(3),
+			"concepts":   []interface{}{"pets"},
+		}))
+		assert.Contains(t, summary, "2 file(s) uploaded as 2 input(s), 1 failed, 1 unsupported, 0 skipped")
+		require.Len(t, byFile, 4, "hidden files and the manifest must not be uploaded")
+		assert.Equal(t, bulkStatusUploaded, byFile["cat.png"].Status)
+		assert.Equal(t, utils.ContentImage, byFile["cat.png"].Kind)
+		assert.Equal(t, []string{bulkInputID("cat.png")}, byFile["cat.png"].InputIDs)
+		assert.Equal(t, bulkStatusUploaded, byFile["sub/dog.txt"].Status)
+		assert.Equal(t, bulkStatusFailed, byFile["notes.txt"].Status)
+		assert.Contains(t, byFile["notes.txt"].Error, "connection reset")
+		assert.Equal(t, bulkStatusUnsupported, byFile["archive.zip"].Status)
+		mockAPI.AssertNumberOfCalls(t, "PostInputs", 3)
+
+		manifestData, err := os.ReadFile(filepath.Join(dir, bulkManifestName))
+		require.NoError(t, err)
+		var manifest BulkUploadManifest
+		require.NoError(t, json.Unmarshal(manifestData, &manifest))
+		assert.Equal(t, bulkStatusUploaded, manifest.Files["cat.png"].Status)
+		assert.Equal(t, bulkStatusFailed, manifest.Files["notes.txt"].Status)
+	})
+
+	t.Run("Second run resumes with the remaining files", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputs", mock.Anything, hasInput("notes-txt-")).Return(&pb.MultiInputResponse{Status: successStatus()}, nil)
+
+		summary, byFile := results(t, bulkUpload(setupTestHandler(mockAPI), map[string]interface{}{"directory": dir}))
+		assert.Contains(t, summary, "1 file(s) uploaded as 1 input(s), 0 failed, 1 unsupported, 2 skipped as already uploaded")
+		assert.Equal(t, bulkStatusUploaded, byFile["notes.txt"].Status)
+		assert.NotContains(t, byFile, "cat.png")
+		mockAPI.AssertNumberOfCalls(t, "PostInputs", 1)
+	})
+
+	t.Run("Restart treats existing inputs as uploaded", func(t *testing.T) {
+		duplicate := &pb.MultiInputResponse{Status: &statuspb.Status{Code: statuspb.StatusCode_INPUT_INVALID_ARGUMENT, Description: "Input ID already exists"}}
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputs", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsRequest) bool { return len(r.Inputs) == 3 })).Return(duplicate, nil).Once()
+		mockAPI.On("ListInputs", mock.Anything, mock.MatchedBy(func(r *pb.ListInputsRequest) bool { return len(r.Ids) == 3 })).Return(&pb.MultiInputResponse{
+			Status: successStatus(),
+			Inputs: []*pb.Input{{Id: bulkInputID("cat.png")}, {Id: bulkInputID("notes.txt")}},
+		}, nil)
+		mockAPI.On("PostInputs", mock.Anything, hasInput("sub-dog-txt-")).Return(&pb.MultiInputResponse{Status: successStatus()}, nil).Once()
+
+		summary, byFile := results(t, bulkUpload(setupTestHandler(mockAPI), map[string]interface{}{"directory": dir, "restart": true}))
+		assert.Contains(t, summary, "3 file(s) uploaded as 3 input(s), 0 failed, 1 unsupported, 0 skipped")
+		for _, file := range []string{"cat.png", "notes.txt", "sub/dog.txt"} {
+			assert.Equal(t, bulkStatusUploaded, byFile[file].Status, file)
+			assert.Equal(t, []string{bulkInputID(file)}, byFile[file].InputIDs, file)
+		}
+		mockAPI.AssertNumberOfCalls(t, "PostInputs", 2)
+	})
+
+	t.Run("Manifest of another app", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		resp := bulkUpload(setupTestHandler(mockAPI), map[string]interface{}{"directory": dir, "app_id": "other-app"})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Contains(t, resp.Error.Message, "restart=true")
+		mockAPI.AssertNotCalled(t, "PostInputs", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		for _, args := range []map[string]interface{}{
+			{},
+			{"directory": filepath.Join(dir, "missing")},
+			{"directory": dir, "batch_size": float64(500)},
+			{"directory": dir, "workers": float64(0)},
+			{"directory": dir, "input_id": "fixed"},
+		} {
+			resp := bulkUpload(setupTestHandler(mockAPI), args)
+			require.NotNil(t, resp.Error, "args: %v", args)
+			assert.Equal(t, -32602, resp.Error.Code)
+		}
+	})
+}
+
+func TestCallUploadURLs(t *testing.T) {
+	uploadURLs := func(handler *Handler, args map[string]interface{}) mcp.JSONRPCResponse {
+		return *handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-upload-urls",
+			Method:  "tools/call",
+			Params:  mcp.RequestParams{Name: "upload_urls", Arguments: args},
+		})
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputs", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsRequest) bool {
+			return len(r.Inputs) == 2 &&
+				r.Inputs[0].Data.Image.GetUrl() == "https://example.com/cat.jpg" &&
+				r.Inputs[0].Data.Image.GetAllowDuplicateUrl() &&
+				r.Inputs[0].Data.Concepts[0].Id == "pet" &&
+				r.Inputs[1].Data.Video.GetUrl() == "https://example.com/clip.mp4" &&
+				r.Inputs[1].DatasetIds[0] == "train"
+		})).Return(&pb.MultiInputResponse{
+			Status: successStatus(),
+			Inputs: []*pb.Input{{Id: "generated-1"}, {Id: "generated-2"}},
+		}, nil)
+
+		resp := uploadURLs(setupTestHandler(mockAPI), map[string]interface{}{
+			"urls":                []interface{}{"https://example.com/cat.jpg", "https://example.com/clip.mp4"},
+			"allow_duplicate_url": true,
+			"concepts":            []interface{}{"pet"},
+			"dataset_id":          "train",
+		})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+		assert.Equal(t, "Uploaded 2 input(s) from URLs.", content[0]["text"])
+		var uploaded []uploadedInput
+		require.NoError(t, json.Unmarshal([]byte(content[1]["text"].(string)), &uploaded))
+		require.Len(t, uploaded, 2)
+		assert.Equal(t, "generated-1", uploaded[0].InputID)
+		assert.Equal(t, utils.ContentVideo, uploaded[1].Kind)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		for _, args := range []map[string]interface{}{
+			{},
+			{"urls": []interface{}{"ftp://example.com/a.jpg"}},
+			{"urls": []interface{}{"https://example.com/doc.pdf"}},
+			{"urls": []interface{}{"https://example.com/a.jpg", "https://example.com/b.jpg"}, "input_id": "one"},
+		} {
+			resp := uploadURLs(setupTestHandler(mockAPI), args)
+			require.NotNil(t, resp.Error, "args: %v", args)
+			assert.Equal(t, -32602, resp.Error.Code)
+		}
+		mockAPI.AssertNotCalled(t, "PostInputs", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCallUploadManifest(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "note.txt"), []byte("a note"), 0644))
+	uploadManifest := func(handler *Handler, args map[string]interface{}) mcp.JSONRPCResponse {
+		return *handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-upload-manifest",
+			Method:  "tools/call",
+			Params:  mcp.RequestParams{Name: "upload_manifest", Arguments: args},
+		})
+	}
+
+	t.Run("Rows override defaults", func(t *testing.T) {
+		manifestPath := filepath.Join(dir, "inputs.csv")
+		require.NoError(t, os.WriteFile(manifestPath, []byte("url,path,input_id,concepts\n"+
+			"https://example.com/cat.jpg,,cat-1,cat\n"+
+			",note.txt,note-1,\n"), 0644))
+
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputs", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsRequest) bool {
+			return len(r.Inputs) == 2 &&
+				r.Inputs[0].Id == "cat-1" && r.Inputs[0].Data.Concepts[0].Id == "cat" &&
+				r.Inputs[1].Id == "note-1" && r.Inputs[1].Data.Text.GetRaw() == "a note" &&
+				r.Inputs[1].Data.Concepts[0].Id == "default-label"
+		})).Return(&pb.MultiInputResponse{Status: successStatus()}, nil)
+
+		resp := uploadManifest(setupTestHandler(mockAPI), map[string]interface{}{
+			"manifest_path": manifestPath,
+			"concepts":      []interface{}{"default-label"},
+		})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+		assert.Equal(t, "Uploaded 2 input(s) from 2 manifest row(s).", content[0]["text"])
+		assert.Contains(t, content[1]["text"], `"inputId": "note-1"`)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Invalid rows upload nothing", func(t *testing.T) {
+		manifestPath := filepath.Join(dir, "bad.jsonl")
+		require.NoError(t, os.WriteFile(manifestPath, []byte(
+			`{"url": "https://example.com/ok.jpg"}`+"\n"+
+				`{"url": "https://example.com/bad.jpg", "input_id": "has space"}`+"\n"+
+				`{"path": "missing.png"}`+"\n"), 0644))
+
+		mockAPI := new(MockClarifaiAPIClient)
+		resp := uploadManifest(setupTestHandler(mockAPI), map[string]interface{}{"manifest_path": manifestPath})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Contains(t, resp.Error.Message, "2 manifest row(s) are invalid")
+		assert.Contains(t, resp.Error.Message, "line 2: 'input_id'")
+		assert.Contains(t, resp.Error.Message, "line 3: failed to read file")
+		mockAPI.AssertNotCalled(t, "PostInputs", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		resp := uploadManifest(setupTestHandler(mockAPI), map[string]interface{}{"manifest_path": filepath.Join(dir, "inputs.xlsx")})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Contains(t, resp.Error.Message, "'format'")
+	})
+}
+
+func TestUploadWait(t *testing.T) {
+	previousInterval := inputPollInterval
+	inputPollInterval = time.Millisecond
+	t.Cleanup(func() { inputPollInterval = previousInterval })
+
+	filePath := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(filePath, []byte("a note"), 0644))
+	inputWithStatus := func(id string, code statuspb.StatusCode, description string) *pb.Input {
+		return &pb.Input{Id: id, Status: &statuspb.Status{Code: code, Description: description}}
+	}
+	listByIDs := func(ids ...string) interface{} {
+		return mock.MatchedBy(func(r *pb.ListInputsRequest) bool {
+			return assert.ObjectsAreEqual(ids, r.Ids)
+		})
+	}
+
+	t.Run("upload_file polls until processed", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputs", mock.Anything, mock.Anything).Return(&pb.MultiInputResponse{
+			Status: successStatus(),
+			Inputs: []*pb.Input{{Id: "in-1"}},
+		}, nil)
+		mockAPI.On("ListInputs", mock.Anything, listByIDs("in-1")).Return(&pb.MultiInputResponse{
+			Status: successStatus(),
+			Inputs: []*pb.Input{inputWithStatus("in-1", statuspb.StatusCode_INPUT_DOWNLOAD_IN_PROGRESS, "In progress")},
+		}, nil).Once()
+		mockAPI.On("ListInputs", mock.Anything, listByIDs("in-1")).Return(&pb.MultiInputResponse{
+			Status: successStatus(),
+			Inputs: []*pb.Input{inputWithStatus("in-1", statuspb.StatusCode_INPUT_DOWNLOAD_SUCCESS, "Download complete")},
+		}, nil).Once()
+
+		resp := callTool(setupTestHandler(mockAPI), "upload_file", map[string]interface{}{"filepath": filePath, "wait": true})
+		require.Nil(t, resp.Error)
+		text := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})[0]["text"].(string)
+		assert.True(t, strings.HasPrefix(text, "File uploaded; input processing processed."), text)
+		assert.Contains(t, text, "Processing: 1 processed, 0 failed, 0 pending.")
+		assert.Contains(t, text, "Input in-1: processed")
+		assert.NotContains(t, text, "File uploaded successfully")
+		mockAPI.AssertNumberOfCalls(t, "ListInputs", 2)
+	})
+
+	t.Run("upload_file reports failed processing", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputs", mock.Anything, mock.Anything).Return(&pb.MultiInputResponse{
+			Status: successStatus(),
+			Inputs: []*pb.Input{{Id: "in-2"}},
+		}, nil)
+		mockAPI.On("ListInputs", mock.Anything, listByIDs("in-2")).Return(&pb.MultiInputResponse{
+			Status: successStatus(),
+			Inputs: []*pb.Input{inputWithStatus("in-2", statuspb.StatusCode_INPUT_DOWNLOAD_FAILED, "Download failed")},
+		}, nil)
+
+		resp := callTool(setupTestHandler(mockAPI), "upload_file", map[string]interface{}{"filepath": filePath, "wait": true})
+		require.Nil(t, resp.Error)
+		text := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})[0]["text"].(string)
+		assert.Contains(t, text, "input processing failed")
+		assert.Contains(t, text, "Input in-2: failed (Download failed)")
+	})
+
+	t.Run("upload_file times out as pending", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputs", mock.Anything, mock.Anything).Return(&pb.MultiInputResponse{
+			Status: successStatus(),
+			Inputs: []*pb.Input{{Id: "in-3"}},
+		}, nil)
+		mockAPI.On("ListInputs", mock.Anything, listByIDs("in-3")).Return(nil, errors.New("unavailable"))
+
+		resp := callTool(setupTestHandler(mockAPI), "upload_file", map[string]interface{}{"filepath": filePath, "wait": true, "wait_timeout": 0.02})
+		require.Nil(t, resp.Error)
+		text := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})[0]["text"].(string)
+		assert.Contains(t, text, "Processing: 0 processed, 0 failed, 1 pending.")
+		assert.Contains(t, text, "Input in-3: pending (status unknown: unavailable)")
+	})
+
+	t.Run("upload_urls reports the state of each input", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputs", mock.Anything, mock.Anything).Return(&pb.MultiInputResponse{
+			Status: successStatus(),
+			Inputs: []*pb.Input{{Id: "ok"}, {Id: "broken"}},
+		}, nil)
+		mockAPI.On("ListInputs", mock.Anything, listByIDs("ok", "broken")).Return(&pb.MultiInputResponse{
+			Status: successStatus(),
+			Inputs: []*pb.Input{
+				inputWithStatus("ok", statuspb.StatusCode_INPUT_DOWNLOAD_SUCCESS, "Download complete"),
+				inputWithStatus("broken", statuspb.StatusCode_INPUT_INVALID_URL, "Invalid URL"),
+			},
+		}, nil)
+
+		resp := callTool(setupTestHandler(mockAPI), "upload_urls", map[string]interface{}{
+			"urls": []interface{}{"https://example.com/ok.jpg", "https://example.com/broken.jpg"},
+			"wait": true,
+		})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+		assert.Contains(t, content[0]["text"], "Processing: 1 processed, 1 failed, 0 pending.")
+		var uploaded []uploadedInput
+		require.NoError(t, json.Unmarshal([]byte(content[1]["text"].(string)), &uploaded))
+		assert.Equal(t, inputStateProcessed, uploaded[0].State)
+		assert.Equal(t, inputStateFailed, uploaded[1].State)
+		assert.Equal(t, "Invalid URL", uploaded[1].Description)
+	})
+
+	t.Run("Invalid wait_timeout", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		resp := callTool(setupTestHandler(mockAPI), "upload_file", map[string]interface{}{"filepath": filePath, "wait": true, "wait_timeout": float64(-1)})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		mockAPI.AssertNotCalled(t, "PostInputs", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCallSearchInputs(t *testing.T) {
+	searchInputs := func(handler *Handler, args map[string]interface{}) mcp.JSONRPCResponse {
+		return *handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-search-inputs",
+			Method:  "tools/call",
+			Params:  mcp.RequestParams{Name: "search_inputs", Arguments: args},
+		})
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputsSearches", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsSearchesRequest) bool {
+			query := r.Searches[0].Query
+			return len(query.Filters) == 2 &&
+				query.Filters[1].Input.DatasetIds[0] == "train" &&
+				query.Ranks[0].Annotation.Data.Text.Raw == "sunset" &&
+				r.Pagination.Page == 3 && r.Pagination.PerPage == 2
+		})).Return(&pb.MultiSearchResponse{
+			Status: successStatus(),
+			Hits:   []*pb.Hit{{Score: 0.9, Input: &pb.Input{Id: "first"}}, {Score: 0.8, Input: &pb.Input{Id: "second"}}},
+		}, nil)
+
+		resp := searchInputs(setupTestHandler(mockAPI), map[string]interface{}{
+			"query":    "concept:beach dataset:train sunset",
+			"page":     3,
+			"per_page": 2,
+			"user_id":  "u",
+			"app_id":   "a",
+		})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+		assert.Equal(t, "Found 2 matching input(s) on page 3; more may follow on page 4.", content[0]["text"])
+		var matches []InputMatch
+		require.NoError(t, json.Unmarshal([]byte(content[1]["text"].(string)), &matches))
+		require.Len(t, matches, 2)
+		assert.Equal(t, 5, matches[0].Rank)
+		assert.Equal(t, "clarifai://u/a/inputs/second", matches[1].URI)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		for _, args := range []map[string]interface{}{
+			{},
+			{"query": "status:lost"},
+			{"query": "cat", "per_page": 500},
+			{"query": "cat", "page": 0},
+		} {
+			resp := searchInputs(setupTestHandler(mockAPI), args)
+			require.NotNil(t, resp.Error, "args: %v", args)
+			assert.Equal(t, -32602, resp.Error.Code)
+		}
+		mockAPI.AssertNotCalled(t, "PostInputsSearches", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCallSearchSimilarImages(t *testing.T) {
+	searchSimilar := func(handler *Handler, args map[string]interface{}) mcp.JSONRPCResponse {
+		return *handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-search-similar",
+			Method:  "tools/call",
+			Params:  mcp.RequestParams{Name: "search_similar_images", Arguments: args},
+		})
+	}
+	hits := []*pb.Hit{
+		{Score: 0.95, Input: &pb.Input{Id: "close", Data: &pb.Data{Image: &pb.Image{Url: "https://example.com/close.jpg"}}}},
+		{Score: 0.4, Input: &pb.Input{Id: "far"}},
+	}
+
+	t.Run("Local file with filters", func(t *testing.T) {
+		queryPath := filepath.Join(t.TempDir(), "query.jpg")
+		require.NoError(t, os.WriteFile(queryPath, []byte("query-bytes"), 0644))
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputsSearches", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsSearchesRequest) bool {
+			query := r.Searches[0].Query
+			return string(query.Ranks[0].Annotation.Data.Image.Base64) == "query-bytes" &&
+				len(query.Filters) == 2 &&
+				query.Filters[0].Annotation.Data.Concepts[0].Id == "cat" &&
+				query.Filters[1].Annotation.Data.Metadata.Fields["source"].GetStringValue() == "web" &&
+				r.Pagination.PerPage == 5 &&
+				r.UserAppId.UserId == "u1" && r.UserAppId.AppId == "a1"
+		})).Return(&pb.MultiSearchResponse{Status: successStatus(), Hits: hits}, nil)
+
+		resp := searchSimilar(setupTestHandler(mockAPI), map[string]interface{}{
+			"filepath":  queryPath,
+			"concepts":  "cat",
+			"metadata":  map[string]interface{}{"source": "web"},
+			"top_k":     5,
+			"min_score": 0.5,
+			"user_id":   "u1",
+			"app_id":    "a1",
+		})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+		assert.Equal(t, "Found 1 similar input(s).", content[0]["text"])
+		var matches []InputMatch
+		require.NoError(t, json.Unmarshal([]byte(content[1]["text"].(string)), &matches))
+		require.Len(t, matches, 1)
+		assert.Equal(t, 1, matches[0].Rank)
+		assert.InDelta(t, 0.95, matches[0].Score, 1e-6)
+		assert.Equal(t, "clarifai://u1/a1/inputs/close", matches[0].URI)
+		assert.Equal(t, "https://example.com/close.jpg", matches[0].URL)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Image URL", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputsSearches", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsSearchesRequest) bool {
+			query := r.Searches[0].Query
+			return query.Ranks[0].Annotation.Data.Image.Url == "https://example.com/q.jpg" &&
+				len(query.Filters) == 0 && r.Pagination.PerPage == defaultSimilarTopK
+		})).Return(&pb.MultiSearchResponse{Status: successStatus(), Hits: hits}, nil)
+
+		resp := searchSimilar(setupTestHandler(mockAPI), map[string]interface{}{"image_url": "https://example.com/q.jpg"})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+		assert.Equal(t, "Found 2 similar input(s).", content[0]["text"])
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		for _, args := range []map[string]interface{}{
+			{},
+			{"filepath": "/tmp/a.jpg", "image_url": "https://example.com/a.jpg"},
+			{"image_url": "file:///etc/passwd"},
+			{"image_url": "https://example.com/a.jpg", "top_k": 0},
+			{"image_url": "https://example.com/a.jpg", "min_score": 2},
+			{"image_url": "https://example.com/a.jpg", "dataset_id": "bad id"},
+		} {
+			resp := searchSimilar(setupTestHandler(mockAPI), args)
+			require.NotNil(t, resp.Error, "args: %v", args)
+			assert.Equal(t, -32602, resp.Error.Code)
+		}
+		mockAPI.AssertNotCalled(t, "PostInputsSearches", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCallDeleteInputs(t *testing.T) {
+	deleteInputs := func(handler *Handler, args map[string]interface{}) mcp.JSONRPCResponse {
+		return *handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-delete-inputs",
+			Method:  "tools/call",
+			Params:  mcp.RequestParams{Name: "delete_inputs", Arguments: args},
+		})
+	}
+	resultTexts := func(resp mcp.JSONRPCResponse) []map[string]interface{} {
+		return resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+	}
+
+	t.Run("Preview then delete by ID", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("ListInputs", mock.Anything, mock.MatchedBy(func(r *pb.ListInputsRequest) bool {
+			return len(r.Ids) == 3
+		})).Return(&pb.MultiInputResponse{
+			Status: successStatus(),
+			Inputs: []*pb.Input{{Id: "b"}, {Id: "a", Data: &pb.Data{Image: &pb.Image{Url: "https://example.com/a.jpg"}}}},
+		}, nil)
+		handler := setupTestHandler(mockAPI)
+		args := map[string]interface{}{"input_ids": []interface{}{"a", "b", "missing"}}
+
+		resp := deleteInputs(handler, args)
+		require.Nil(t, resp.Error)
+		content := resultTexts(resp)
+		assert.Contains(t, content[0]["text"], "Preview: 2 input(s) would be deleted")
+		assert.Contains(t, content[0]["text"], "Not found: [missing]")
+		mockAPI.AssertNotCalled(t, "DeleteInputs", mock.Anything, mock.Anything)
+
+		token, err := handler.confirmationToken("delete_inputs", deleteOperation{InputIDs: []string{"a", "b"}})
+		require.NoError(t, err)
+		assert.Contains(t, content[0]["text"], token)
+
+		mockAPI.On("DeleteInputs", mock.Anything, mock.MatchedBy(func(r *pb.DeleteInputsRequest) bool {
+			return assert.ObjectsAreEqual([]string{"a", "b"}, r.Ids)
+		})).Return(&statuspb.BaseResponse{Status: successStatus()}, nil).Once()
+		args["confirm_token"] = token
+		resp = deleteInputs(handler, args)
+		require.Nil(t, resp.Error)
+		assert.Equal(t, "Deleted 2 input(s).", resultTexts(resp)[0]["text"])
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Filter search", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputsSearches", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsSearchesRequest) bool {
+			filters := r.Searches[0].Query.Filters
+			return len(filters) == 4 &&
+				filters[0].Annotation.Data.Concepts[0].Id == "cat" &&
+				filters[1].Annotation.Data.Metadata.Fields["a"].GetStringValue() == "1" &&
+				filters[2].Annotation.Data.Metadata.Fields["b"].GetBoolValue() &&
+				filters[3].Input.DatasetIds[0] == "train"
+		})).Return(&pb.MultiSearchResponse{
+			Status: successStatus(),
+			Hits:   []*pb.Hit{{Input: &pb.Input{Id: "x"}}, {Input: &pb.Input{Id: "y"}}},
+		}, nil)
+		resp := deleteInputs(setupTestHandler(mockAPI), map[string]interface{}{
+			"concepts":   []interface{}{"cat"},
+			"metadata":   map[string]interface{}{"a": "1", "b": true},
+			"dataset_id": "train",
+		})
+		require.Nil(t, resp.Error)
+		assert.Contains(t, resultTexts(resp)[0]["text"], "Preview: 2 input(s) would be deleted")
+
+		mockAPI.On("PostInputsSearches", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsSearchesRequest) bool {
+			return len(r.Searches[0].Query.Filters) == 1
+		})).Return(&pb.MultiSearchResponse{
+			Status: successStatus(),
+			Hits:   []*pb.Hit{{Input: &pb.Input{Id: "x"}}, {Input: &pb.Input{Id: "y"}}},
+		}, nil)
+		resp = deleteInputs(setupTestHandler(mockAPI), map[string]interface{}{"concepts": "cat", "max_inputs": 1})
+		require.NotNil(t, resp.Error)
+		assert.Contains(t, resp.Error.Message, "more than max_inputs (1)")
+		mockAPI.AssertNotCalled(t, "DeleteInputs", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Query filter", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputsSearches", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsSearchesRequest) bool {
+			filters := r.Searches[0].Query.Filters
+			return len(filters) == 2 &&
+				filters[0].Input.DatasetIds[0] == "train" &&
+				filters[1].Input.Status.Code == statuspb.StatusCode_INPUT_DOWNLOAD_FAILED
+		})).Return(&pb.MultiSearchResponse{
+			Status: successStatus(),
+			Hits:   []*pb.Hit{{Input: &pb.Input{Id: "broken"}}},
+		}, nil)
+		resp := deleteInputs(setupTestHandler(mockAPI), map[string]interface{}{"dataset_id": "train", "query": "status:failed"})
+		require.Nil(t, resp.Error)
+		assert.Contains(t, resultTexts(resp)[0]["text"], "Preview: 1 input(s) would be deleted")
+
+		resp = deleteInputs(setupTestHandler(mockAPI), map[string]interface{}{"query": "concept:cat sunset"})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Contains(t, resp.Error.Message, "only contain filter terms")
+	})
+
+	t.Run("Wrong token", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("ListInputs", mock.Anything, mock.Anything).Return(&pb.MultiInputResponse{
+			Status: successStatus(),
+			Inputs: []*pb.Input{{Id: "a"}},
+		}, nil)
+		resp := deleteInputs(setupTestHandler(mockAPI), map[string]interface{}{"input_ids": "a", "confirm_token": "0123"})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		mockAPI.AssertNotCalled(t, "DeleteInputs", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		for _, args := range []map[string]interface{}{
+			{},
+			{"input_ids": "a", "concepts": "cat"},
+			{"input_ids": "bad id"},
+			{"concepts": "cat", "max_inputs": 0},
+			{"input_ids": "a,b", "max_inputs": 1},
+		} {
+			resp := deleteInputs(setupTestHandler(mockAPI), args)
+			require.NotNil(t, resp.Error, "args: %v", args)
+			assert.Equal(t, -32602, resp.Error.Code)
+		}
+		mockAPI.AssertNotCalled(t, "ListInputs", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Read-only mode", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		handler := setupTestHandler(mockAPI)
+		handler.config.ReadOnly = true
+		resp := deleteInputs(handler, map[string]interface{}{"input_ids": "a"})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32000, resp.Error.Code)
+		assert.Contains(t, resp.Error.Message, "-read-only")
+		mockAPI.AssertNotCalled(t, "ListInputs", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCallPatchInput(t *testing.T) {
+	patchInput := func(handler *Handler, args map[string]interface{}) mcp.JSONRPCResponse {
+		return *handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-patch-input",
+			Method:  "tools/call",
+			Params:  mcp.RequestParams{Name: "patch_input", Arguments: args},
+		})
+	}
+	current := func() *pb.Input {
+		metadata, _ := structpb.NewStruct(map[string]interface{}{"source": "camera", "info": map[string]interface{}{"a": 1.0, "b": 2.0}})
+		return &pb.Input{Id: "in1", Data: &pb.Data{
+			Concepts: []*pb.Concept{{Id: "cat", Value: 1}, {Id: "dog", Value: 0}},
+			Metadata: metadata,
+		}}
+	}
+
+	t.Run("Preview then merge", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("GetInput", mock.Anything, mock.Anything).Return(&pb.SingleInputResponse{Status: successStatus(), Input: current()}, nil)
+		handler := setupTestHandler(mockAPI)
+		args := map[string]interface{}{
+			"input_id": "in1",
+			"concepts": []interface{}{"pet"},
+			"metadata": map[string]interface{}{"info": map[string]interface{}{"b": 3}},
+		}
+
+		resp := patchInput(handler, args)
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+		var preview map[string]InputSummary
+		require.NoError(t, json.Unmarshal([]byte(content[1]["text"].(string)), &preview))
+		assert.Equal(t, []string{"cat", "pet"}, preview["after"].Concepts)
+		assert.Equal(t, []string{"dog"}, preview["after"].NegativeConcepts)
+		assert.Equal(t, map[string]interface{}{"a": 1.0, "b": 3.0}, preview["after"].Metadata["info"])
+		assert.Equal(t, "camera", preview["after"].Metadata["source"])
+		mockAPI.AssertNotCalled(t, "PatchInputs", mock.Anything, mock.Anything)
+
+		text := content[0]["text"].(string)
+		token := text[strings.LastIndex(text, "confirm_token ")+len("confirm_token \"") : len(text)-2]
+		mockAPI.On("PatchInputs", mock.Anything, mock.MatchedBy(func(r *pb.PatchInputsRequest) bool {
+			return r.Action == "merge" && r.Inputs[0].Id == "in1" && r.Inputs[0].Data.Concepts[0].Id == "pet"
+		})).Return(&pb.MultiInputResponse{Status: successStatus()}, nil).Once()
+		args["confirm_token"] = token
+		resp = patchInput(handler, args)
+		require.Nil(t, resp.Error)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Token expires when the input changes", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("GetInput", mock.Anything, mock.Anything).Return(&pb.SingleInputResponse{Status: successStatus(), Input: current()}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+		args := map[string]interface{}{"input_id": "in1", "action": "remove", "concepts": "cat"}
+		resp := patchInput(handler, args)
+		require.Nil(t, resp.Error)
+		text := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})[0]["text"].(string)
+		token := text[strings.LastIndex(text, "confirm_token ")+len("confirm_token \"") : len(text)-2]
+
+		changed := current()
+		changed.Data.Concepts = append(changed.Data.Concepts, &pb.Concept{Id: "bird", Value: 1})
+		mockAPI.On("GetInput", mock.Anything, mock.Anything).Return(&pb.SingleInputResponse{Status: successStatus(), Input: changed}, nil).Once()
+		args["confirm_token"] = token
+		resp = patchInput(handler, args)
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		mockAPI.AssertNotCalled(t, "PatchInputs", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		for _, args := range []map[string]interface{}{
+			{"concepts": "cat"},
+			{"input_id": "in1"},
+			{"input_id": "in1", "concepts": "cat", "action": "replace"},
+		} {
+			resp := patchInput(setupTestHandler(mockAPI), args)
+			require.NotNil(t, resp.Error, "args: %v", args)
+			assert.Equal(t, -32602, resp.Error.Code)
+		}
+		mockAPI.AssertNotCalled(t, "GetInput", mock.Anything, mock.Anything)
+	})
+}
+
+func TestApplyPatch(t *testing.T) {
+	metadata, _ := structpb.NewStruct(map[string]interface{}{"keep": "x", "info": map[string]interface{}{"a": 1.0, "b": 2.0}})
+	current := &pb.Input{Id: "in1", Data: &pb.Data{Concepts: []*pb.Concept{{Id: "cat", Value: 1}, {Id: "dog", Value: 1}}, Metadata: metadata}}
+	patchMetadata, _ := structpb.NewStruct(map[string]interface{}{"info": map[string]interface{}{"a": nil}})
+	opts := inputOptions{Concepts: []*pb.Concept{{Id: "dog", Value: 0}}, Metadata: patchMetadata}
+
+	overwritten := summarizeInput(applyPatch(current, opts, patchActionOverwrite))
+	assert.Empty(t, overwritten.Concepts)
+	assert.Equal(t, []string{"dog"}, overwritten.NegativeConcepts)
+	assert.Equal(t, map[string]interface{}{"info": map[string]interface{}{"a": nil}}, overwritten.Metadata)
+
+	removed := summarizeInput(applyPatch(current, opts, patchActionRemove))
+	assert.Equal(t, []string{"cat"}, removed.Concepts)
+	assert.Equal(t, map[string]interface{}{"keep": "x", "info": map[string]interface{}{"b": 2.0}}, removed.Metadata)
+
+	merged := summarizeInput(applyPatch(current, opts, patchActionMerge))
+	assert.Equal(t, []string{"cat"}, merged.Concepts)
+	assert.Equal(t, []string{"dog"}, merged.NegativeConcepts)
+}
+
+func TestAnnotationTools(t *testing.T) {
+	t.Run("Create input-level and region annotations", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostAnnotations", mock.Anything, mock.MatchedBy(func(r *pb.PostAnnotationsRequest) bool {
+			data := r.Annotations[0].Data
+			return r.Annotations[0].InputId == "in1" && len(data.Regions) == 0 &&
+				data.Concepts[0].Id == "cat" && data.Concepts[0].Value == 1 &&
+				data.Concepts[1].Id == "dog" && data.Concepts[1].Value == 0
+		})).Return(&pb.MultiAnnotationResponse{Status: successStatus(), Annotations: []*pb.Annotation{{Id: "ann-1", InputId: "in1"}}}, nil).Once()
+		mockAPI.On("PostAnnotations", mock.Anything, mock.MatchedBy(func(r *pb.PostAnnotationsRequest) bool {
+			region := r.Annotations[0].Data.GetRegions()
+			return len(region) == 1 && region[0].RegionInfo.BoundingBox.GetBottomRow() == 0.5 &&
+				region[0].Data.Concepts[0].Id == "cat" && r.Annotations[0].Id == "box-1"
+		})).Return(&pb.MultiAnnotationResponse{Status: successStatus(), Annotations: []*pb.Annotation{{Id: "box-1", InputId: "in1"}}}, nil).Once()
+		mockAPI.On("PostAnnotations", mock.Anything, mock.MatchedBy(func(r *pb.PostAnnotationsRequest) bool {
+			region := r.Annotations[0].Data.GetRegions()
+			return len(region) == 1 && len(region[0].RegionInfo.GetPolygon().GetPoints()) == 3
+		})).Return(&pb.MultiAnnotationResponse{Status: successStatus(), Annotations: []*pb.Annotation{{Id: "poly-1"}}}, nil).Once()
+		mockAPI.On("PostAnnotations", mock.Anything, mock.MatchedBy(func(r *pb.PostAnnotationsRequest) bool {
+			span := r.Annotations[0].Data.GetRegions()[0].RegionInfo.GetSpan()
+			return span.GetCharStart() == 4 && span.GetCharEnd() == 9 && span.GetRawText() == "quick"
+		})).Return(&pb.MultiAnnotationResponse{Status: successStatus(), Annotations: []*pb.Annotation{{Id: "span-1"}}}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "create_annotation", map[string]interface{}{"input_id": "in1", "concepts": "cat", "negative_concepts": "dog"})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+		assert.Equal(t, "Created annotation ann-1 on input in1.", content[0]["text"])
+		var created map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(content[1]["text"].(string)), &created))
+		assert.Equal(t, "ann-1", created["id"])
+
+		resp = callTool(handler, "create_annotation", map[string]interface{}{
+			"input_id":      "in1",
+			"annotation_id": "box-1",
+			"concepts":      []interface{}{"cat"},
+			"bounding_box":  map[string]interface{}{"top_row": 0.1, "left_col": 0.2, "bottom_row": 0.5, "right_col": 0.6},
+		})
+		require.Nil(t, resp.Error)
+
+		resp = callTool(handler, "create_annotation", map[string]interface{}{
+			"input_id": "in1",
+			"concepts": "cat",
+			"polygon": []interface{}{
+				map[string]interface{}{"row": 0.1, "col": 0.1},
+				map[string]interface{}{"row": 0.1, "col": 0.9},
+				map[string]interface{}{"row": 0.9, "col": 0.5},
+			},
+		})
+		require.Nil(t, resp.Error)
+
+		resp = callTool(handler, "create_annotation", map[string]interface{}{
+			"input_id": "in1",
+			"concepts": "adjective",
+			"span":     map[string]interface{}{"char_start": 4, "char_end": 9, "raw_text": "quick"},
+		})
+		require.Nil(t, resp.Error)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Update and delete", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PatchAnnotations", mock.Anything, mock.MatchedBy(func(r *pb.PatchAnnotationsRequest) bool {
+			return r.Action == "merge" && r.Annotations[0].Id == "ann-1" && r.Annotations[0].InputId == "in1"
+		})).Return(&pb.MultiAnnotationResponse{Status: successStatus(), Annotations: []*pb.Annotation{{Id: "ann-1"}}}, nil)
+		mockAPI.On("DeleteAnnotation", mock.Anything, mock.MatchedBy(func(r *pb.DeleteAnnotationRequest) bool {
+			return r.InputId == "in1" && r.AnnotationId == "ann-1"
+		})).Return(&statuspb.BaseResponse{Status: successStatus()}, nil)
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "update_annotation", map[string]interface{}{"input_id": "in1", "annotation_id": "ann-1", "action": "merge", "concepts": "bird"})
+		require.Nil(t, resp.Error)
+		assert.Equal(t, "Updated annotation ann-1 on input in1 (merge).", resp.Result.(map[string]interface{})["content"].([]map[string]interface{})[0]["text"])
+
+		resp = callTool(handler, "delete_annotation", map[string]interface{}{"input_id": "in1", "annotation_id": "ann-1"})
+		require.Nil(t, resp.Error)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		box := func(top, left, bottom, right interface{}) map[string]interface{} {
+			return map[string]interface{}{"top_row": top, "left_col": left, "bottom_row": bottom, "right_col": right}
+		}
+		for _, tc := range []struct {
+			tool    string
+			args    map[string]interface{}
+			message string
+		}{
+			{"create_annotation", map[string]interface{}{"concepts": "cat"}, "'input_id'"},
+			{"create_annotation", map[string]interface{}{"input_id": "in1"}, "'concepts' or 'negative_concepts'"},
+			{"create_annotation", map[string]interface{}{"input_id": "in1", "concepts": "cat", "bounding_box": box(10, 20, 200, 300)}, "'bounding_box.top_row' must be normalized to 0-1"},
+			{"create_annotation", map[string]interface{}{"input_id": "in1", "concepts": "cat", "bounding_box": box(0.5, 0.1, 0.2, 0.6)}, "top_row < bottom_row"},
+			{"create_annotation", map[string]interface{}{"input_id": "in1", "concepts": "cat", "bounding_box": map[string]interface{}{"top_row": 0.1}}, "'bounding_box.left_col' is required"},
+			{"create_annotation", map[string]interface{}{"input_id": "in1", "concepts": "cat", "polygon": []interface{}{map[string]interface{}{"row": 0.1, "col": 0.1}}}, "at least 3 points"},
+			{"create_annotation", map[string]interface{}{"input_id": "in1", "concepts": "cat", "polygon": []interface{}{
+				map[string]interface{}{"row": 0.1, "col": 0.1}, map[string]interface{}{"row": 0.1, "col": 1.5}, map[string]interface{}{"row": 0.9, "col": 0.5},
+			}}, "'polygon[1].col' must be normalized"},
+			{"create_annotation", map[string]interface{}{"input_id": "in1", "concepts": "cat", "span": map[string]interface{}{"char_start": 5, "char_end": 5}}, "greater than char_start"},
+			{"create_annotation", map[string]interface{}{"input_id": "in1", "concepts": "cat", "span": map[string]interface{}{"char_start": 0, "char_end": 3, "raw_text": "ab"}}, "span covers 3"},
+			{"create_annotation", map[string]interface{}{"input_id": "in1", "concepts": "cat", "bounding_box": box(0.1, 0.1, 0.2, 0.2), "span": map[string]interface{}{"char_start": 0, "char_end": 1}}, "cannot be combined"},
+			{"update_annotation", map[string]interface{}{"input_id": "in1", "concepts": "cat"}, "'annotation_id'"},
+			{"update_annotation", map[string]interface{}{"input_id": "in1", "annotation_id": "a", "concepts": "cat", "action": "replace"}, "'action'"},
+			{"delete_annotation", map[string]interface{}{"input_id": "in1"}, "'annotation_id'"},
+		} {
+			resp := callTool(setupTestHandler(mockAPI), tc.tool, tc.args)
+			require.NotNil(t, resp.Error, "%s %v", tc.tool, tc.args)
+			assert.Equal(t, -32602, resp.Error.Code)
+			assert.Contains(t, resp.Error.Message, tc.message)
+		}
+		mockAPI.AssertNotCalled(t, "PostAnnotations", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Read-only mode", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		handler := setupTestHandler(mockAPI)
+		handler.config.ReadOnly = true
+		for _, tool := range []string{"create_annotation", "update_annotation", "delete_annotation"} {
+			resp := callTool(handler, tool, map[string]interface{}{"input_id": "in1", "annotation_id": "a", "concepts": "cat"})
+			require.NotNil(t, resp.Error, tool)
+			assert.Equal(t, -32000, resp.Error.Code)
+		}
+	})
+}
+
+func TestCallAutoAnnotate(t *testing.T) {
+	box := &pb.RegionInfo{BoundingBox: &pb.BoundingBox{TopRow: 0.1, LeftCol: 0.1, BottomRow: 0.5, RightCol: 0.5}}
+	prediction := func(inputID string, concepts ...*pb.Concept) *pb.Output {
+		regions := make([]*pb.Region, len(concepts))
+		for i, c := range concepts {
+			regions[i] = &pb.Region{RegionInfo: box, Data: &pb.Data{Concepts: []*pb.Concept{c}}}
+		}
+		return &pb.Output{Status: successStatus(), Input: &pb.Input{Id: inputID}, Data: &pb.Data{Regions: regions}}
+	}
+	inputs := []*pb.Input{
+		{Id: "a", Data: &pb.Data{Image: &pb.Image{Url: "https://example.com/a.jpg"}}},
+		{Id: "b", Data: &pb.Data{Image: &pb.Image{Url: "https://example.com/b.jpg"}}},
+	}
+
+	t.Run("Annotates inputs by ID", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("ListInputs", mock.Anything, mock.Anything).Return(&pb.MultiInputResponse{Status: successStatus(), Inputs: inputs}, nil)
+		mockAPI.On("ListAnnotations", mock.Anything, mock.Anything).Return(&pb.MultiAnnotationResponse{Status: successStatus()}, nil)
+		mockAPI.On("PostModelOutputs", mock.Anything, mock.MatchedBy(func(r *pb.PostModelOutputsRequest) bool {
+			return r.ModelId == "my-detector" && len(r.Inputs) == 2 && r.Inputs[0].Id == "a" && r.Inputs[0].Data.Image.Url != ""
+		})).Return(&pb.MultiOutputResponse{Status: successStatus(), Outputs: []*pb.Output{
+			prediction("b", &pb.Concept{Id: "dog", Name: "Dog", Value: 0.9}),
+			prediction("a", &pb.Concept{Id: "cat", Name: "Cat", Value: 0.95}, &pb.Concept{Id: "dog", Name: "Dog", Value: 0.8}, &pb.Concept{Id: "bird", Name: "Bird", Value: 0.3}),
+		}}, nil)
+		mockAPI.On("PostAnnotations", mock.Anything, mock.MatchedBy(func(r *pb.PostAnnotationsRequest) bool {
+			if len(r.Annotations) != 3 || r.Annotations[0].InputId != "a" || r.Annotations[2].InputId != "b" {
+				return false
+			}
+			for _, annotation := range r.Annotations {
+				region := annotation.Data.Regions[0]
+				if annotation.Status.Code != statuspb.StatusCode_ANNOTATION_AWAITING_REVIEW || region.Data.Concepts[0].Value != 1 || region.RegionInfo.BoundingBox == nil ||
+					annotation.AnnotationInfo.Fields[autoAnnotateInfoKey].GetStringValue() != "me/models/my-detector" {
+					return false
+				}
+			}
+			return true
+		})).Return(&pb.MultiAnnotationResponse{Status: successStatus()}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "auto_annotate", map[string]interface{}{"input_ids": "a,b,missing", "model_id": "my-detector", "model_user_id": "me", "model_app_id": "models"})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Created 3 annotation(s) on 2 of 2 input(s) (awaiting_review): dog 2, cat 1.", content[0]["text"])
+		var report AutoAnnotateReport
+		require.NoError(t, json.Unmarshal([]byte(content[1]["text"].(string)), &report))
+		assert.Equal(t, map[string]int{"cat": 1, "dog": 2}, report.PerConcept)
+		assert.Equal(t, []string{"missing"}, report.NotFound)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Dry run of a dataset with a concept filter", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputsSearches", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsSearchesRequest) bool {
+			filters := r.Searches[0].Query.Filters
+			return len(filters) == 2 && filters[0].Input.DatasetIds[0] == "train" && filters[1].Input.Status != nil
+		})).Return(&pb.MultiSearchResponse{Status: successStatus(), Hits: []*pb.Hit{{Input: inputs[0]}, {Input: inputs[1]}}}, nil)
+		mockAPI.On("ListAnnotations", mock.Anything, mock.Anything).Return(&pb.MultiAnnotationResponse{Status: successStatus()}, nil)
+		mockAPI.On("PostModelOutputs", mock.Anything, mock.Anything).Return(&pb.MultiOutputResponse{Status: &statuspb.Status{Code: statuspb.StatusCode_MIXED_STATUS}, Outputs: []*pb.Output{
+			prediction("a", &pb.Concept{Id: "cat", Name: "Cat", Value: 0.7}, &pb.Concept{Id: "dog", Name: "Dog", Value: 0.9}),
+			{Status: &statuspb.Status{Code: statuspb.StatusCode_INPUT_DOWNLOAD_FAILED, Description: "download failed"}, Input: &pb.Input{Id: "b"}},
+		}}, nil)
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "auto_annotate", map[string]interface{}{
+			"dataset_id": "train", "query": "status:processed", "concepts": []interface{}{"CAT"}, "min_confidence": 0.6, "annotation_status": "pending", "dry_run": true,
+		})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Dry run: would create 1 annotation(s) on 1 of 2 input(s) (pending): cat 1. 1 input(s) failed.", content[0]["text"])
+		assert.Contains(t, content[1]["text"], "download failed")
+		mockAPI.AssertNotCalled(t, "PostAnnotations", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Skips inputs the model already annotated", func(t *testing.T) {
+		tagged := func(inputID, modelRef string) *pb.Annotation {
+			info, err := structpb.NewStruct(map[string]interface{}{autoAnnotateInfoKey: modelRef})
+			require.NoError(t, err)
+			return &pb.Annotation{InputId: inputID, AnnotationInfo: info}
+		}
+		more := []*pb.Input{
+			{Id: "c", Data: &pb.Data{Image: &pb.Image{Url: "https://example.com/c.jpg"}}},
+			{Id: "d", Data: &pb.Data{Image: &pb.Image{Url: "https://example.com/d.jpg"}}},
+		}
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostInputsSearches", mock.Anything, mock.Anything).Return(&pb.MultiSearchResponse{Status: successStatus(), Hits: []*pb.Hit{
+			{Input: inputs[0]}, {Input: inputs[1]}, {Input: more[0]}, {Input: more[1]},
+		}}, nil)
+		mockAPI.On("ListAnnotations", mock.Anything, mock.Anything).Return(&pb.MultiAnnotationResponse{Status: successStatus(), Annotations: []*pb.Annotation{
+			tagged("a", "me/models/my-detector"),
+			tagged("b", "me/models/other-detector"),
+			{InputId: "c"},
+		}}, nil)
+		mockAPI.On("PostModelOutputs", mock.Anything, mock.MatchedBy(func(r *pb.PostModelOutputsRequest) bool {
+			return len(r.Inputs) == 2 && r.Inputs[0].Id == "b" && r.Inputs[1].Id == "c"
+		})).Return(&pb.MultiOutputResponse{Status: successStatus(), Outputs: []*pb.Output{
+			prediction("b", &pb.Concept{Id: "dog", Name: "Dog", Value: 0.9}),
+			prediction("c", &pb.Concept{Id: "cat", Name: "Cat", Value: 0.9}),
+		}}, nil)
+		mockAPI.On("PostAnnotations", mock.Anything, mock.Anything).Return(&pb.MultiAnnotationResponse{Status: successStatus()}, nil)
+
+		resp := callTool(setupTestHandler(mockAPI), "auto_annotate", map[string]interface{}{
+			"dataset_id": "train", "max_inputs": 2, "model_id": "my-detector", "model_user_id": "me", "model_app_id": "models",
+		})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Created 2 annotation(s) on 2 of 2 input(s) (awaiting_review): cat 1, dog 1. Skipped 1 input(s) this model already annotated. "+
+			"More inputs matched than max_inputs; run again with offset 3 to continue, or raise max_inputs.", content[0]["text"])
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Offset continues past inputs without confident predictions", func(t *testing.T) {
+		hits := []*pb.Hit{{Input: inputs[0]}, {Input: inputs[1]},
+			{Input: &pb.Input{Id: "c", Data: &pb.Data{Image: &pb.Image{Url: "https://example.com/c.jpg"}}}},
+			{Input: &pb.Input{Id: "d", Data: &pb.Data{Image: &pb.Image{Url: "https://example.com/d.jpg"}}}},
+		}
+		run := func(t *testing.T, args map[string]interface{}, predicted []string, outputs []*pb.Output) AutoAnnotateReport {
+			mockAPI := new(MockClarifaiAPIClient)
+			mockAPI.On("PostInputsSearches", mock.Anything, mock.Anything).Return(&pb.MultiSearchResponse{Status: successStatus(), Hits: hits}, nil)
+			mockAPI.On("ListAnnotations", mock.Anything, mock.Anything).Return(&pb.MultiAnnotationResponse{Status: successStatus()}, nil)
+			mockAPI.On("PostModelOutputs", mock.Anything, mock.MatchedBy(func(r *pb.PostModelOutputsRequest) bool {
+				return len(r.Inputs) == 2 && r.Inputs[0].Id == predicted[0] && r.Inputs[1].Id == predicted[1]
+			})).Return(&pb.MultiOutputResponse{Status: successStatus(), Outputs: outputs}, nil).Once()
+			mockAPI.On("PostAnnotations", mock.Anything, mock.Anything).Return(&pb.MultiAnnotationResponse{Status: successStatus()}, nil)
+
+			resp := callTool(setupTestHandler(mockAPI), "auto_annotate", args)
+			require.Nil(t, resp.Error)
+			content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+			var report AutoAnnotateReport
+			require.NoError(t, json.Unmarshal([]byte(content[1]["text"].(string)), &report))
+			mockAPI.AssertExpectations(t)
+			return report
+		}
+
+		// "a" gets no annotation: its only prediction is below min_confidence
+		first := run(t, map[string]interface{}{"dataset_id": "train", "max_inputs": 2}, []string{"a", "b"}, []*pb.Output{
+			prediction("a", &pb.Concept{Id: "cat", Name: "Cat", Value: 0.2}),
+			prediction("b", &pb.Concept{Id: "dog", Name: "Dog", Value: 0.9}),
+		})
+		assert.True(t, first.Truncated)
+		assert.Equal(t, 2, first.NextOffset)
+		assert.Equal(t, 1, first.AnnotatedInputs)
+
+		second := run(t, map[string]interface{}{"dataset_id": "train", "max_inputs": 2, "offset": first.NextOffset}, []string{"c", "d"}, []*pb.Output{
+			prediction("c", &pb.Concept{Id: "cat", Name: "Cat", Value: 0.9}),
+			prediction("d"),
+		})
+		assert.False(t, second.Truncated)
+		assert.Equal(t, 0, second.NextOffset)
+		assert.Equal(t, 2, second.Inputs)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		testCases := []struct {
+			args    map[string]interface{}
+			message string
+		}{
+			{map[string]interface{}{}, "give either 'input_ids' or 'dataset_id'/'query'"},
+			{map[string]interface{}{"input_ids": "a", "dataset_id": "train"}, "give either 'input_ids' or 'dataset_id'/'query'"},
+			{map[string]interface{}{"input_ids": "bad id"}, "invalid input ID"},
+			{map[string]interface{}{"query": "status:lost"}, `unknown status "lost"`},
+			{map[string]interface{}{"dataset_id": "train", "min_confidence": 1.5}, "'min_confidence' must be between 0 and 1"},
+			{map[string]interface{}{"dataset_id": "train", "annotation_status": "done"}, "'annotation_status' must be"},
+			{map[string]interface{}{"input_ids": "a,b", "max_inputs": 1}, "more than max_inputs"},
+			{map[string]interface{}{"dataset_id": "train", "offset": -1}, "'offset' must not be negative"},
+			{map[string]interface{}{"input_ids": "a", "offset": 2}, "'offset' can only be used with"},
+		}
+		for _, tc := range testCases {
+			mockAPI := new(MockClarifaiAPIClient)
+			resp := callTool(setupTestHandler(mockAPI), "auto_annotate", tc.args)
+			require.NotNil(t, resp.Error, tc.message)
+			assert.Equal(t, -32602, resp.Error.Code)
+			assert.Contains(t, resp.Error.Message, tc.message)
+			mockAPI.AssertNotCalled(t, "PostModelOutputs", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("Read-only mode", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		handler := setupTestHandler(mockAPI)
+		handler.config.ReadOnly = true
+		resp := callTool(handler, "auto_annotate", map[string]interface{}{"input_ids": "a"})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32000, resp.Error.Code)
+	})
+}
+
+func TestCallExportDataset(t *testing.T) {
+	exportDataset := func(handler *Handler, args map[string]interface{}) mcp.JSONRPCResponse {
+		return *handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-export-dataset",
+			Method:  "tools/call",
+			Params:  mcp.RequestParams{Name: "export_dataset", Arguments: args},
+		})
+	}
+	pngBytes, err := utils.EncodePNG(image.NewRGBA(image.Rect(0, 0, 40, 20)))
+	require.NoError(t, err)
+	imageInput := func(id string) *pb.Input {
+		return &pb.Input{Id: id, Data: &pb.Data{Image: &pb.Image{Base64: pngBytes}}}
+	}
+	boxAnnotation := func(inputID, concept string, value float32) *pb.Annotation {
+		return &pb.Annotation{InputId: inputID, Data: &pb.Data{Regions: []*pb.Region{{
+			RegionInfo: &pb.RegionInfo{BoundingBox: &pb.BoundingBox{TopRow: 0.25, LeftCol: 0.5, BottomRow: 0.75, RightCol: 1}},
+			Data:       &pb.Data{Concepts: []*pb.Concept{{Id: concept, Value: value}}},
+		}}}}
+	}
+
+	t.Run("Resumes a paged export and writes COCO", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("ListInputs", mock.Anything, mock.MatchedBy(func(r *pb.ListInputsRequest) bool {
+			return r.Page == 1 && r.PerPage == 2
+		})).Return(&pb.MultiInputResponse{Status: successStatus(), Inputs: []*pb.Input{
+			imageInput("a"),
+			{Id: "t", Data: &pb.Data{Text: &pb.Text{Raw: "no image"}}},
+		}}, nil).Once()
+		mockAPI.On("ListInputs", mock.Anything, mock.MatchedBy(func(r *pb.ListInputsRequest) bool {
+			return r.Page == 2 && r.PerPage == 2
+		})).Return(&pb.MultiInputResponse{Status: successStatus(), Inputs: []*pb.Input{imageInput("b")}}, nil).Once()
+		mockAPI.On("ListAnnotations", mock.Anything, mock.MatchedBy(func(r *pb.ListAnnotationsRequest) bool {
+			return len(r.InputIds) == 2
+		})).Return(&pb.MultiAnnotationResponse{Status: successStatus(), Annotations: []*pb.Annotation{
+			boxAnnotation("a", "dog", 1),
+			boxAnnotation("a", "cat", 0), // Negative label, not exported
+		}}, nil).Once()
+		mockAPI.On("ListAnnotations", mock.Anything, mock.MatchedBy(func(r *pb.ListAnnotationsRequest) bool {
+			return len(r.InputIds) == 1 && r.InputIds[0] == "b"
+		})).Return(&pb.MultiAnnotationResponse{Status: successStatus(), Annotations: []*pb.Annotation{boxAnnotation("b", "cat", 1)}}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+		outputDir := t.TempDir()
+		args := map[string]interface{}{"format": "coco", "output_dir": outputDir, "per_page": 2, "max_pages": 1, "app_id": "app", "user_id": "me"}
+
+		resp := exportDataset(handler, args)
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Contains(t, content[0]["text"], "Export in progress: 1 image(s)")
+		assert.Contains(t, content[0]["text"], "resume from page 2")
+		assert.Contains(t, content[0]["text"], "Skipped 1 input(s) without an image")
+		assert.FileExists(t, filepath.Join(outputDir, "images", "a.png"))
+		assert.NoFileExists(t, filepath.Join(outputDir, utils.DatasetCOCOFile))
+
+		resp = exportDataset(handler, args)
+		require.Nil(t, resp.Error)
+		content = resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Exported 2 image(s) with 2 bounding box(es) of 2 concept(s) as COCO to "+outputDir+". Skipped 1 input(s) without an image.", content[0]["text"])
+		var report ExportReport
+		require.NoError(t, json.Unmarshal([]byte(content[1]["text"].(string)), &report))
+		assert.True(t, report.Complete)
+		assert.Equal(t, uint32(2), report.ResumedAt)
+		assert.Equal(t, []string{"cat", "dog"}, report.Concepts)
+
+		data, err := os.ReadFile(filepath.Join(outputDir, utils.DatasetCOCOFile))
+		require.NoError(t, err)
+		var coco struct {
+			Images []struct {
+				FileName string `json:"file_name"`
+				Width    int    `json:"width"`
+			} `json:"images"`
+			Annotations []struct {
+				BBox []float64 `json:"bbox"`
+			} `json:"annotations"`
+		}
+		require.NoError(t, json.Unmarshal(data, &coco))
+		require.Len(t, coco.Images, 2)
+		assert.Equal(t, "a.png", coco.Images[0].FileName)
+		assert.Equal(t, 40, coco.Images[0].Width)
+		require.Len(t, coco.Annotations, 2)
+		assert.Equal(t, []float64{20, 5, 20, 10}, coco.Annotations[0].BBox)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Refuses to resume another export", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("ListInputs", mock.Anything, mock.Anything).Return(&pb.MultiInputResponse{Status: successStatus(), Inputs: []*pb.Input{imageInput("a")}}, nil)
+		mockAPI.On("ListAnnotations", mock.Anything, mock.Anything).Return(&pb.MultiAnnotationResponse{Status: successStatus()}, nil)
+		handler := setupTestHandler(mockAPI)
+		outputDir := t.TempDir()
+
+		resp := exportDataset(handler, map[string]interface{}{"format": "yolo", "output_dir": outputDir, "per_page": 1, "max_pages": 1})
+		require.Nil(t, resp.Error)
+		resp = exportDataset(handler, map[string]interface{}{"format": "voc", "output_dir": outputDir})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Contains(t, resp.Error.Message, "unfinished export")
+
+		resp = exportDataset(handler, map[string]interface{}{"format": "voc", "output_dir": outputDir, "per_page": 5, "restart": true})
+		require.Nil(t, resp.Error)
+		assert.FileExists(t, filepath.Join(outputDir, utils.DatasetVOCDir, "a.xml"))
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		handler := setupTestHandler(mockAPI)
+		for message, args := range map[string]map[string]interface{}{
+			"'format' must be 'coco', 'yolo' or 'voc'": {"format": "csv"},
+			"'max_pages' must be at least 1":           {"format": "coco", "max_pages": 0},
+			`unknown status "lost"`:                    {"format": "coco", "query": "status:lost"},
+		} {
+			resp := exportDataset(handler, args)
+			require.NotNil(t, resp.Error, message)
+			assert.Equal(t, -32602, resp.Error.Code)
+			assert.Contains(t, resp.Error.Message, message)
+		}
+		mockAPI.AssertNotCalled(t, "ListInputs", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCallImportDataset(t *testing.T) {
+	importDataset := func(handler *Handler, args map[string]interface{}) mcp.JSONRPCResponse {
+		return *handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-import-dataset",
+			Method:  "tools/call",
+			Params:  mcp.RequestParams{Name: "import_dataset", Arguments: args},
+		})
+	}
+	// writeYOLO creates a YOLO dataset with images a.jpg and b.jpg and the given labels for a.jpg.
+	writeYOLO := func(t *testing.T, labels string) string {
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "images"), 0755))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "labels"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "images", "a.jpg"), []byte("image-a"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "images", "b.jpg"), []byte("image-b"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "classes.txt"), []byte("traffic light\ncar\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "labels", "a.txt"), []byte(labels), 0644))
+		return dir
+	}
+
+	t.Run("Imports images and boxes into a dataset", func(t *testing.T) {
+		dir := writeYOLO(t, "0 0.5 0.5 0.5 0.5\n1 0.2 0.2 0.1 0.1\n")
+		idA, idB := importInputID(dir, "images/a.jpg"), importInputID(dir, "images/b.jpg")
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("ListInputs", mock.Anything, mock.Anything).Return(&pb.MultiInputResponse{Status: successStatus()}, nil).Once()
+		mockAPI.On("PostInputs", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsRequest) bool {
+			return len(r.Inputs) == 2 && r.Inputs[0].Id == idA && string(r.Inputs[0].Data.Image.Base64) == "image-a" && r.Inputs[1].DatasetIds[0] == "train"
+		})).Return(&pb.MultiInputResponse{Status: successStatus()}, nil).Once()
+		mockAPI.On("PostAnnotations", mock.Anything, mock.MatchedBy(func(r *pb.PostAnnotationsRequest) bool {
+			if len(r.Annotations) != 2 || r.Annotations[0].InputId != idA {
+				return false
+			}
+			region := r.Annotations[0].Data.Regions[0]
+			concept := region.Data.Concepts[0]
+			return concept.Id == "traffic-light" && concept.Name == "traffic light" && concept.Value == 1 &&
+				r.Annotations[0].AnnotationInfo.Fields[importBoxInfoKey].GetStringValue() == "images/a.jpg#0" &&
+				region.RegionInfo.BoundingBox.TopRow == 0.25 && region.RegionInfo.BoundingBox.RightCol == 0.75
+		})).Return(&pb.MultiAnnotationResponse{Status: successStatus()}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := importDataset(handler, map[string]interface{}{"path": dir, "dataset_id": "train"})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Imported 2 image(s) with 2 bounding box(es) into dataset train: car 1, traffic-light 1.", content[0]["text"])
+		var report ImportReport
+		require.NoError(t, json.Unmarshal([]byte(content[1]["text"].(string)), &report))
+		assert.Equal(t, "yolo", report.Format)
+		assert.Equal(t, 2, report.Annotations)
+		assert.Equal(t, map[string]string{"traffic light": "traffic-light"}, report.ConceptIDs)
+		assert.Equal(t, []ImportedImage{{File: "images/a.jpg", InputID: idA, Boxes: 2}, {File: "images/b.jpg", InputID: idB}}, report.Inputs)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Re-run creates only the boxes an interrupted import missed", func(t *testing.T) {
+		dir := writeYOLO(t, "0 0.5 0.5 0.5 0.5\n1 0.2 0.2 0.1 0.1\n")
+		idA, idB := importInputID(dir, "images/a.jpg"), importInputID(dir, "images/b.jpg")
+		imported, err := structpb.NewStruct(map[string]interface{}{importBoxInfoKey: "images/a.jpg#0"})
+		require.NoError(t, err)
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("ListInputs", mock.Anything, mock.Anything).Return(&pb.MultiInputResponse{Status: successStatus(), Inputs: []*pb.Input{{Id: idA}}}, nil).Once()
+		mockAPI.On("ListAnnotations", mock.Anything, mock.MatchedBy(func(r *pb.ListAnnotationsRequest) bool {
+			return len(r.InputIds) == 1 && r.InputIds[0] == idA
+		})).Return(&pb.MultiAnnotationResponse{Status: successStatus(), Annotations: []*pb.Annotation{
+			{InputId: idA, Data: &pb.Data{Concepts: []*pb.Concept{{Id: "street", Value: 1}}}}, // Unrelated input-level label
+			{InputId: idA, AnnotationInfo: imported},
+		}}, nil).Once()
+		mockAPI.On("PostInputs", mock.Anything, mock.MatchedBy(func(r *pb.PostInputsRequest) bool {
+			return len(r.Inputs) == 1 && r.Inputs[0].Id == idB
+		})).Return(&pb.MultiInputResponse{Status: successStatus()}, nil).Once()
+		mockAPI.On("PostAnnotations", mock.Anything, mock.MatchedBy(func(r *pb.PostAnnotationsRequest) bool {
+			return len(r.Annotations) == 1 && r.Annotations[0].InputId == idA &&
+				r.Annotations[0].AnnotationInfo.Fields[importBoxInfoKey].GetStringValue() == "images/a.jpg#1"
+		})).Return(&pb.MultiAnnotationResponse{Status: successStatus()}, nil).Once()
+
+		resp := importDataset(setupTestHandler(mockAPI), map[string]interface{}{"path": dir})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Imported 2 image(s) with 2 bounding box(es): car 1, traffic-light 1. 1 image(s) had been imported before and were not uploaded again.", content[0]["text"])
+		var report ImportReport
+		require.NoError(t, json.Unmarshal([]byte(content[1]["text"].(string)), &report))
+		assert.Equal(t, 1, report.Annotations)
+		assert.Equal(t, ImportedImage{File: "images/a.jpg", InputID: idA, Boxes: 2, Existing: true, ExistingBoxes: 1}, report.Inputs[0])
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Unrelated annotations do not count as imported boxes", func(t *testing.T) {
+		dir := writeYOLO(t, "0 0.5 0.5 0.5 0.5\n")
+		idA := importInputID(dir, "images/a.jpg")
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("ListInputs", mock.Anything, mock.Anything).Return(&pb.MultiInputResponse{Status: successStatus(), Inputs: []*pb.Input{{Id: idA}, {Id: importInputID(dir, "images/b.jpg")}}}, nil).Once()
+		mockAPI.On("ListAnnotations", mock.Anything, mock.Anything).Return(&pb.MultiAnnotationResponse{Status: successStatus(), Annotations: []*pb.Annotation{
+			{InputId: idA, Data: &pb.Data{Concepts: []*pb.Concept{{Id: "street", Value: 1}}}},
+		}}, nil).Once()
+		mockAPI.On("PostAnnotations", mock.Anything, mock.MatchedBy(func(r *pb.PostAnnotationsRequest) bool {
+			return len(r.Annotations) == 1 && r.Annotations[0].InputId == idA
+		})).Return(&pb.MultiAnnotationResponse{Status: successStatus()}, nil).Once()
+
+		resp := importDataset(setupTestHandler(mockAPI), map[string]interface{}{"path": dir})
+		require.Nil(t, resp.Error)
+		mockAPI.AssertNotCalled(t, "PostInputs", mock.Anything, mock.Anything)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Malformed labels stop the import", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		handler := setupTestHandler(mockAPI)
+		dir := writeYOLO(t, "0 0.5 0.5 0.5 0.5\n7 0.5 0.5 0.5 0.5\n0 1.5 0.5 0.5 0.5\n")
+
+		resp := importDataset(handler, map[string]interface{}{"path": dir, "format": "yolo"})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Contains(t, resp.Error.Message, "2 malformed label(s) or image(s)")
+		assert.Contains(t, resp.Error.Message, "labels/a.txt line 2: class \"7\"")
+		assert.Contains(t, resp.Error.Message, "labels/a.txt line 3: lies outside the image")
+		mockAPI.AssertNotCalled(t, "PostInputs", mock.Anything, mock.Anything)
+
+		resp = importDataset(handler, map[string]interface{}{"path": dir, "skip_invalid": true, "dry_run": true})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Dry run: would import 2 image(s) with 1 bounding box(es): traffic-light 1. Skipped 2 malformed label(s) or image(s).", content[0]["text"])
+		mockAPI.AssertNotCalled(t, "PostInputs", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		handler := setupTestHandler(mockAPI)
+		for message, args := range map[string]map[string]interface{}{
+			"missing or invalid 'path'":                {},
+			"'path' must be a dataset directory":       {"path": filepath.Join(t.TempDir(), "missing")},
+			"cannot detect the dataset format":         {"path": t.TempDir()},
+			"not a COCO dataset":                       {"path": t.TempDir(), "format": "coco"},
+			"'format' must be 'coco', 'yolo' or 'voc'": {"path": t.TempDir(), "format": "csv"},
+			"'dataset_id' must be":                     {"path": t.TempDir(), "dataset_id": "bad id"},
+		} {
+			resp := importDataset(handler, args)
+			require.NotNil(t, resp.Error, message)
+			assert.Equal(t, -32602, resp.Error.Code)
+			assert.Contains(t, resp.Error.Message, message)
+		}
+	})
+
+	t.Run("Read-only mode", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		handler := setupTestHandler(mockAPI)
+		handler.config.ReadOnly = true
+		resp := importDataset(handler, map[string]interface{}{"path": t.TempDir()})
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32000, resp.Error.Code)
+	})
+}
+
+func TestDatasetTools(t *testing.T) {
+	t.Run("create_dataset creates a dataset with metadata", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostDatasets", mock.Anything, mock.MatchedBy(func(r *pb.PostDatasetsRequest) bool {
+			return len(r.Datasets) == 1 && r.Datasets[0].Id == "train" && r.Datasets[0].Description == "curated" &&
+				r.Datasets[0].Metadata.Fields["source"].GetStringValue() == "review"
+		})).Return(&pb.MultiDatasetResponse{Status: successStatus(), Datasets: []*pb.Dataset{{Id: "train", Description: "curated"}}}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "create_dataset", map[string]interface{}{
+			"dataset_id":  "train",
+			"description": "curated",
+			"metadata":    map[string]interface{}{"source": "review"},
+		})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Created dataset train.", content[0]["text"])
+		var dataset map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(content[1]["text"].(string)), &dataset))
+		assert.Equal(t, "train", dataset["id"])
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("add_inputs_to_dataset adds inputs by ID in batches", func(t *testing.T) {
+		ids := make([]interface{}, maxBulkBatchSize+2)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("in-%d", i)
+		}
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostDatasetInputs", mock.Anything, mock.MatchedBy(func(r *pb.PostDatasetInputsRequest) bool {
+			return r.DatasetId == "train" && len(r.DatasetInputs) == maxBulkBatchSize && r.DatasetInputs[0].Input.Id == "in-0"
+		})).Return(&pb.MultiDatasetInputResponse{Status: successStatus()}, nil).Once()
+		mockAPI.On("PostDatasetInputs", mock.Anything, mock.MatchedBy(func(r *pb.PostDatasetInputsRequest) bool {
+			return len(r.DatasetInputs) == 2 && r.DatasetInputs[1].Input.Id == fmt.Sprintf("in-%d", maxBulkBatchSize+1)
+		})).Return(&pb.MultiDatasetInputResponse{Status: successStatus()}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "add_inputs_to_dataset", map[string]interface{}{"dataset_id": "train", "input_ids": ids})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, fmt.Sprintf("Added %d input(s) to dataset train.", maxBulkBatchSize+2), content[0]["text"])
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("add_inputs_to_dataset adds inputs matching a query", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostDatasetInputs", mock.Anything, mock.MatchedBy(func(r *pb.PostDatasetInputsRequest) bool {
+			return len(r.DatasetInputs) == 0 && r.Search != nil && len(r.Search.Query.Filters) == 1
+		})).Return(&pb.MultiDatasetInputResponse{
+			Status:        successStatus(),
+			BulkOperation: &pb.BulkOperation{Id: "op-1", Status: &statuspb.Status{Code: statuspb.StatusCode_JOB_QUEUED}},
+		}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "add_inputs_to_dataset", map[string]interface{}{"dataset_id": "train", "query": "concept:cat"})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, `Adding the inputs matching "concept:cat" to dataset train as bulk operation op-1 (JOB_QUEUED).`, content[0]["text"])
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("remove_inputs_from_dataset removes inputs", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("DeleteDatasetInputs", mock.Anything, mock.MatchedBy(func(r *pb.DeleteDatasetInputsRequest) bool {
+			return r.DatasetId == "train" && len(r.InputIds) == 2 && r.InputIds[1] == "in-2"
+		})).Return(&statuspb.BaseResponse{Status: successStatus()}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "remove_inputs_from_dataset", map[string]interface{}{"dataset_id": "train", "input_ids": "in-1,in-2,in-1"})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Removed 2 input(s) from dataset train. The inputs themselves were kept.", content[0]["text"])
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("create_dataset_version reports the version status", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostDatasetVersions", mock.Anything, mock.MatchedBy(func(r *pb.PostDatasetVersionsRequest) bool {
+			return r.DatasetId == "train" && len(r.DatasetVersions) == 1 && r.DatasetVersions[0].Id == "v1"
+		})).Return(&pb.MultiDatasetVersionResponse{
+			Status:          successStatus(),
+			DatasetVersions: []*pb.DatasetVersion{{Id: "v1", DatasetId: "train", Status: &statuspb.Status{Code: statuspb.StatusCode_DATASET_VERSION_PENDING}}},
+		}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "create_dataset_version", map[string]interface{}{"dataset_id": "train", "version_id": "v1"})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Created version v1 of dataset train (status DATASET_VERSION_PENDING).", content[0]["text"])
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("API errors are returned", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostDatasets", mock.Anything, mock.Anything).Return(&pb.MultiDatasetResponse{
+			Status: &statuspb.Status{Code: statuspb.StatusCode_CONN_DOES_NOT_EXIST, Description: "dataset exists"},
+		}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "create_dataset", map[string]interface{}{"dataset_id": "train"})
+		require.NotNil(t, resp.Error)
+		assert.Contains(t, resp.Error.Message, "dataset exists")
+	})
+
+	t.Run("Invalid arguments are rejected", func(t *testing.T) {
+		handler := setupTestHandler(new(MockClarifaiAPIClient))
+		for message, call := range map[string]struct {
+			tool string
+			args map[string]interface{}
+		}{
+			"'dataset_id'":                  {"create_dataset", map[string]interface{}{"dataset_id": "bad id"}},
+			"'metadata' must be an object":  {"create_dataset", map[string]interface{}{"dataset_id": "train", "metadata": "x"}},
+			"either 'input_ids' or 'query'": {"add_inputs_to_dataset", map[string]interface{}{"dataset_id": "train"}},
+			"only contain filter terms":     {"add_inputs_to_dataset", map[string]interface{}{"dataset_id": "train", "query": "red car"}},
+			"'input_ids'":                   {"remove_inputs_from_dataset", map[string]interface{}{"dataset_id": "train"}},
+			"'version_id'":                  {"create_dataset_version", map[string]interface{}{"dataset_id": "train", "version_id": "v 1"}},
+		} {
+			resp := callTool(handler, call.tool, call.args)
+			require.NotNil(t, resp.Error, message)
+			assert.Equal(t, -32602, resp.Error.Code, message)
+			assert.Contains(t, resp.Error.Message, message)
+		}
+	})
+
+	t.Run("Read-only mode refuses dataset changes", func(t *testing.T) {
+		handler := setupTestHandler(new(MockClarifaiAPIClient))
+		handler.config.ReadOnly = true
+		for _, tool := range []string{"create_dataset", "add_inputs_to_dataset", "remove_inputs_from_dataset", "create_dataset_version"} {
+			resp := callTool(handler, tool, map[string]interface{}{"dataset_id": "train", "input_ids": []interface{}{"in-1"}})
+			require.NotNil(t, resp.Error, tool)
+			assert.Equal(t, -32000, resp.Error.Code, tool)
+		}
+	})
+}
+
+func TestModelTrainingTools(t *testing.T) {
+	previousInterval := modelPollInterval
+	modelPollInterval = time.Millisecond
+	t.Cleanup(func() { modelPollInterval = previousInterval })
+	modelStatus := func(code statuspb.StatusCode) *statuspb.Status {
+		return &statuspb.Status{Code: code}
+	}
+
+	t.Run("create_model creates a classifier with concepts", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostModels", mock.Anything, mock.MatchedBy(func(r *pb.PostModelsRequest) bool {
+			model := r.Models[0]
+			return model.Id == "pets" && model.ModelTypeId == "visual-classifier" && len(model.OutputInfo.Data.Concepts) == 2 &&
+				model.OutputInfo.Data.Concepts[1].Id == "dog" && model.OutputInfo.OutputConfig.ConceptsMutuallyExclusive
+		})).Return(&pb.SingleModelResponse{Status: successStatus(), Model: &pb.Model{Id: "pets", ModelTypeId: "visual-classifier"}}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "create_model", map[string]interface{}{
+			"model_id":                    "pets",
+			"concepts":                    []interface{}{"cat", "dog"},
+			"concepts_mutually_exclusive": true,
+		})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Created visual-classifier model pets. Train a version of it with train_model_version.", content[0]["text"])
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("train_model_version starts training and waits until it is done", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostModelVersions", mock.Anything, mock.MatchedBy(func(r *pb.PostModelVersionsRequest) bool {
+			params := r.ModelVersions[0].TrainInfo.Params.Fields
+			return r.ModelId == "pets" && params["dataset_id"].GetStringValue() == "train" &&
+				params["dataset_version_id"].GetStringValue() == "v1" && params["template"].GetStringValue() == "MMClassification_ResNet_50_RSB_A1" &&
+				params["num_epochs"].GetNumberValue() == 5 && r.ModelVersions[0].OutputInfo.Data.Concepts[0].Id == "cat"
+		})).Return(&pb.SingleModelResponse{
+			Status: successStatus(),
+			Model:  &pb.Model{Id: "pets", ModelVersion: &pb.ModelVersion{Id: "mv1", Status: modelStatus(statuspb.StatusCode_MODEL_QUEUED_FOR_TRAINING)}},
+		}, nil).Once()
+		mockAPI.On("GetModelVersion", mock.Anything, mock.MatchedBy(func(r *pb.GetModelVersionRequest) bool {
+			return r.ModelId == "pets" && r.VersionId == "mv1"
+		})).Return(&pb.SingleModelVersionResponse{Status: successStatus(), ModelVersion: &pb.ModelVersion{Id: "mv1", Status: modelStatus(statuspb.StatusCode_MODEL_TRAINING)}}, nil).Once()
+		mockAPI.On("GetModelVersion", mock.Anything, mock.Anything).Return(&pb.SingleModelVersionResponse{
+			Status:       successStatus(),
+			ModelVersion: &pb.ModelVersion{Id: "mv1", Status: modelStatus(statuspb.StatusCode_MODEL_TRAINED), TotalInputCount: 40, ActiveConceptCount: 2},
+		}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "train_model_version", map[string]interface{}{
+			"model_id":           "pets",
+			"dataset_id":         "train",
+			"dataset_version_id": "v1",
+			"template":           "MMClassification_ResNet_50_RSB_A1",
+			"concepts":           "cat,dog",
+			"hyperparameters":    map[string]interface{}{"num_epochs": float64(5)},
+			"wait":               true,
+		})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Model pets version mv1: done (MODEL_TRAINED).", content[0]["text"])
+		var status TrainingStatus
+		require.NoError(t, json.Unmarshal([]byte(content[1]["text"].(string)), &status))
+		assert.Equal(t, TrainingStatus{ModelID: "pets", VersionID: "mv1", State: "done", Code: "MODEL_TRAINED", TotalInputCount: 40, ActiveConceptCount: 2}, status)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("get_training_status reports a failed training", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("GetModelVersion", mock.Anything, mock.Anything).Return(&pb.SingleModelVersionResponse{
+			Status: successStatus(),
+			ModelVersion: &pb.ModelVersion{Id: "mv1", Status: &statuspb.Status{
+				Code: statuspb.StatusCode_MODEL_TRAINING_FAILED, Description: "Training failed", Details: "no positive examples of dog",
+			}},
+		}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "get_training_status", map[string]interface{}{"model_id": "pets", "version_id": "mv1", "wait": true})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Model pets version mv1: failed (MODEL_TRAINING_FAILED). Training failed: no positive examples of dog", content[0]["text"])
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("get_training_status reports training still running after the wait", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("GetModelVersion", mock.Anything, mock.Anything).Return(&pb.SingleModelVersionResponse{
+			Status: successStatus(), ModelVersion: &pb.ModelVersion{Id: "mv1", Status: modelStatus(statuspb.StatusCode_MODEL_TRAINING)},
+		}, nil)
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "get_training_status", map[string]interface{}{"model_id": "pets", "version_id": "mv1", "wait": true, "wait_timeout": 0.05})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Model pets version mv1: pending (MODEL_TRAINING). Still training after 50ms; check again with get_training_status.", content[0]["text"])
+	})
+
+	t.Run("evaluate_model_version waits for the metrics", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostModelVersionEvaluations", mock.Anything, mock.MatchedBy(func(r *pb.PostModelVersionEvaluationsRequest) bool {
+			dataset := r.EvalMetrics[0].GroundTruthDataset
+			return r.ModelId == "pets" && r.ModelVersionId == "mv1" && dataset.Id == "test" && dataset.Version.Id == "v2"
+		})).Return(&pb.MultiEvalMetricsResponse{
+			Status:      successStatus(),
+			EvalMetrics: []*pb.EvalMetrics{{Id: "eval-1", Status: modelStatus(statuspb.StatusCode_MODEL_QUEUED_FOR_EVALUATION)}},
+		}, nil).Once()
+		mockAPI.On("GetModelVersionEvaluation", mock.Anything, mock.MatchedBy(func(r *pb.GetModelVersionEvaluationRequest) bool {
+			return r.EvaluationId == "eval-1" && r.Fields.MetricsByClass
+		})).Return(&pb.SingleEvalMetricsResponse{Status: successStatus(), EvalMetrics: &pb.EvalMetrics{
+			Id:      "eval-1",
+			Status:  modelStatus(statuspb.StatusCode_MODEL_EVALUATED),
+			Summary: &pb.MetricsSummary{Top1Accuracy: 0.9, MacroAvgF1Score: 0.85},
+			MetricsByClass: []*pb.BinaryMetrics{
+				{Concept: &pb.Concept{Id: "dog"}, NumPos: 10, F1: 0.8, RocAuc: 0.9},
+				{Concept: &pb.Concept{Id: "cat"}, NumPos: 12, F1: 0.9, RocAuc: 0.95},
+			},
+		}}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "evaluate_model_version", map[string]interface{}{
+			"model_id": "pets", "version_id": "mv1", "dataset_id": "test", "dataset_version_id": "v2", "wait": true,
+		})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Evaluation eval-1 of model pets version mv1: done (MODEL_EVALUATED). macroAvgF1 0.850, top1Accuracy 0.900.", content[0]["text"])
+		var report EvaluationReport
+		require.NoError(t, json.Unmarshal([]byte(content[1]["text"].(string)), &report))
+		assert.Equal(t, []ConceptMetrics{{Concept: "cat", Positives: 12, F1: 0.9, RocAuc: 0.95}, {Concept: "dog", Positives: 10, F1: 0.8, RocAuc: 0.9}}, report.Concepts)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("get_training_status reports an evaluation", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("GetModelVersionEvaluation", mock.Anything, mock.Anything).Return(&pb.SingleEvalMetricsResponse{
+			Status: successStatus(), EvalMetrics: &pb.EvalMetrics{Id: "eval-1", Status: modelStatus(statuspb.StatusCode_MODEL_EVALUATING)},
+		}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := callTool(handler, "get_training_status", map[string]interface{}{"model_id": "pets", "version_id": "mv1", "evaluation_id": "eval-1"})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		assert.Equal(t, "Evaluation eval-1 of model pets version mv1: pending (MODEL_EVALUATING). Check progress with get_training_status and evaluation_id.", content[0]["text"])
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Invalid arguments are rejected", func(t *testing.T) {
+		handler := setupTestHandler(new(MockClarifaiAPIClient))
+		for message, call := range map[string]struct {
+			tool string
+			args map[string]interface{}
+		}{
+			"'model_id'":                    {"create_model", map[string]interface{}{}},
+			"invalid concept ID":            {"create_model", map[string]interface{}{"model_id": "pets", "concepts": []interface{}{"a cat"}}},
+			"'dataset_id'":                  {"train_model_version", map[string]interface{}{"model_id": "pets"}},
+			"may not set 'dataset_id'":      {"train_model_version", map[string]interface{}{"model_id": "pets", "dataset_id": "train", "hyperparameters": map[string]interface{}{"dataset_id": "other"}}},
+			"'hyperparameters' must be an":  {"train_model_version", map[string]interface{}{"model_id": "pets", "dataset_id": "train", "hyperparameters": "fast"}},
+			"'wait_timeout'":                {"get_training_status", map[string]interface{}{"model_id": "pets", "version_id": "mv1", "wait_timeout": float64(-1)}},
+			"'version_id'":                  {"evaluate_model_version", map[string]interface{}{"model_id": "pets", "dataset_id": "test"}},
+			"'evaluation_id' must be 1-255": {"evaluate_model_version", map[string]interface{}{"model_id": "pets", "version_id": "mv1", "dataset_id": "test", "evaluation_id": "e 1"}},
+		} {
+			resp := callTool(handler, call.tool, call.args)
+			require.NotNil(t, resp.Error, message)
+			assert.Equal(t, -32602, resp.Error.Code, message)
+			assert.Contains(t, resp.Error.Message, message)
+		}
+	})
+
+	t.Run("Read-only mode refuses training but reports status", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("GetModelVersion", mock.Anything, mock.Anything).Return(&pb.SingleModelVersionResponse{
+			Status: successStatus(), ModelVersion: &pb.ModelVersion{Id: "mv1", Status: modelStatus(statuspb.StatusCode_MODEL_TRAINED)},
+		}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+		handler.config.ReadOnly = true
+		args := map[string]interface{}{"model_id": "pets", "version_id": "mv1", "dataset_id": "train"}
+		for _, tool := range []string{"create_model", "train_model_version", "evaluate_model_version"} {
+			resp := callTool(handler, tool, args)
+			require.NotNil(t, resp.Error, tool)
+			assert.Equal(t, -32000, resp.Error.Code, tool)
+		}
+		resp := callTool(handler, "get_training_status", args)
+		require.Nil(t, resp.Error)
+		mockAPI.AssertExpectations(t)
+	})
+}
+
+func TestCallCompareModels(t *testing.T) {
+	compareModels := func(handler *Handler, args map[string]interface{}) mcp.JSONRPCResponse {
+		return *handler.HandleRequest(mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      "req-compare-models",
+			Method:  "tools/call",
+			Params:  mcp.RequestParams{Name: "compare_models", Arguments: args},
+		})
+	}
+	conceptOutput := func(concepts ...*pb.Concept) *pb.MultiOutputResponse {
+		return &pb.MultiOutputResponse{Status: successStatus(), Outputs: []*pb.Output{{Data: &pb.Data{Concepts: concepts}}}}
+	}
+
+	t.Run("Compares an image across models and saves the report", func(t *testing.T) {
+		dir := t.TempDir()
+		imagePath := filepath.Join(dir, "cat.jpg")
+		require.NoError(t, os.WriteFile(imagePath, []byte("image-bytes"), 0644))
+		reportPath := filepath.Join(dir, "reports", "compare.json")
+
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostModelOutputs", mock.Anything, mock.MatchedBy(func(r *pb.PostModelOutputsRequest) bool {
+			return r.ModelId == "general-image-recognition" && r.UserAppId.UserId == "clarifai" && r.UserAppId.AppId == "main" &&
+				string(r.Inputs[0].Data.Image.Base64) == "image-bytes"
+		})).Return(conceptOutput(
+			&pb.Concept{Name: "cat", Value: 0.98}, &pb.Concept{Name: "pet", Value: 0.95},
+			&pb.Concept{Name: "animal", Value: 0.9}, &pb.Concept{Name: "dog", Value: 0.1},
+		), nil).Once()
+		mockAPI.On("PostModelOutputs", mock.Anything, mock.MatchedBy(func(r *pb.PostModelOutputsRequest) bool {
+			return r.ModelId == "pets" && r.UserAppId.UserId == "test-user" && r.UserAppId.AppId == "test-app" && r.VersionId == ""
+		})).Return(conceptOutput(&pb.Concept{Id: "cat", Value: 0.91}), nil).Once()
+		mockAPI.On("PostModelOutputs", mock.Anything, mock.MatchedBy(func(r *pb.PostModelOutputsRequest) bool {
+			return r.ModelId == "detector" && r.UserAppId.UserId == "acme" && r.VersionId == "v2"
+		})).Return(&pb.MultiOutputResponse{Status: &statuspb.Status{Code: statuspb.StatusCode_MODEL_DOES_NOT_EXIST, Description: "Model does not exist"}}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := compareModels(handler, map[string]interface{}{
+			"model_ids":   []interface{}{"clarifai/main/general-image-recognition", "pets", "https://clarifai.com/acme/vision/models/detector/versions/v2"},
+			"filepath":    imagePath,
+			"report_path": reportPath,
+			"user_id":     "test-user",
+			"app_id":      "test-app",
+		})
+		require.Nil(t, resp.Error)
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]any)
+		table := content[0]["text"].(string)
+		assert.Regexp(t, `\| clarifai/main/general-image-recognition \| \d+ ms \| cat 0\.98, pet 0\.95, animal 0\.90 \|`, table)
+		assert.Regexp(t, `\| pets \| \d+ ms \| cat 0\.91 \|`, table)
+		assert.Regexp(t, `\| https://clarifai.com/acme/vision/models/detector/versions/v2 \| \d+ ms \| Error: .*Model does not exist`, table)
+		assert.Contains(t, table, "Fastest: ")
+		assert.Equal(t, "Full report: "+reportPath, content[1]["text"])
+
+		reportJSON, err := os.ReadFile(reportPath)
+		require.NoError(t, err)
+		var report CompareReport
+		require.NoError(t, json.Unmarshal(reportJSON, &report))
+		assert.Equal(t, "image", report.InputType)
+		assert.Equal(t, imagePath, report.Input)
+		require.Len(t, report.Models, 3)
+		assert.Len(t, report.Models[0].Summary.Concepts, 3)
+		assert.Contains(t, string(report.Models[0].Output), `"dog"`, "the report keeps the full output")
+		assert.Equal(t, "acme", report.Models[2].UserID)
+		assert.Equal(t, "v2", report.Models[2].VersionID)
+		assert.Contains(t, report.Models[2].Error, "Model does not exist")
+		assert.Nil(t, report.Models[2].Summary)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Compares text outputs", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostModelOutputs", mock.Anything, mock.MatchedBy(func(r *pb.PostModelOutputsRequest) bool {
+			return r.ModelId == "summarizer" && r.Inputs[0].Data.Text.Raw == "A long article"
+		})).Return(&pb.MultiOutputResponse{Status: successStatus(), Outputs: []*pb.Output{{Data: &pb.Data{Text: &pb.Text{Raw: "Short | summary"}}}}}, nil).Once()
+		mockAPI.On("PostModelOutputs", mock.Anything, mock.MatchedBy(func(r *pb.PostModelOutputsRequest) bool {
+			return r.ModelId == "embedder"
+		})).Return(&pb.MultiOutputResponse{Status: successStatus(), Outputs: []*pb.Output{{Data: &pb.Data{Embeddings: []*pb.Embedding{{Vector: []float32{1, 0, 0}}}}}}}, nil).Once()
+		handler := setupTestHandler(mockAPI)
+
+		resp := compareModels(handler, map[string]interface{}{
+			"model_ids":       "summarizer,embedder",
+			"text":            "A long article",
+			"max_concurrency": float64(1),
+			"report_path":     filepath.Join(t.TempDir(), "report.json"),
+			"user_id":         "test-user",
+			"app_id":          "test-app",
+		})
+		require.Nil(t, resp.Error)
+		table := resp.Result.(map[string]interface{})["content"].([]map[string]any)[0]["text"].(string)
+		assert.Regexp(t, `\| summarizer \| \d+ ms \| "Short \\\| summary" \|`, table)
+		assert.Regexp(t, `\| embedder \| \d+ ms \| embedding \(3 dims\) \|`, table)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Default report is saved under the output path", func(t *testing.T) {
+		mockAPI := new(MockClarifaiAPIClient)
+		mockAPI.On("PostModelOutputs", mock.Anything, mock.Anything).Return(conceptOutput(&pb.Concept{Name: "cat", Value: 0.9}), nil).Twice()
+		handler := setupTestHandler(mockAPI)
+		handler.outputPath = t.TempDir()
+
+		resp := compareModels(handler, map[string]interface{}{"model_ids": "u/p/a,u/p/b", "text": "x"})
+		require.Nil(t, resp.Error)
+		reportText := resp.Result.(map[string]interface{})["content"].([]map[string]any)[1]["text"].(string)
+		reportPath := strings.TrimPrefix(reportText, "Full report: ")
+		assert.Equal(t, handler.outputPath, filepath.Dir(reportPath))
+		assert.Regexp(t, `^compare_models_\d+_[0-9a-f]+\.json$`, filepath.Base(reportPath))
+		assert.FileExists(t, reportPath)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Existing report_path is only replaced with overwrite_report", func(t *testing.T) {
+		reportPath := filepath.Join(t.TempDir(), "report.json")
+		require.NoError(t, os.WriteFile(reportPath, []byte("keep me"), 0644))
+		mockAPI := new(MockClarifaiAPIClient)
+		handler := setupTestHandler(mockAPI)
+		args := map[string]interface{}{"model_ids": "u/p/a,u/p/b", "text": "x", "report_path": reportPath}
+
+		resp := compareModels(handler, args)
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, -32602, resp.Error.Code)
+		assert.Contains(t, resp.Error.Message, "already exists")
+		kept, err := os.ReadFile(reportPath)
+		require.NoError(t, err)
+		assert.Equal(t, "keep me", string(kept))
+		mockAPI.AssertNotCalled(t, "PostModelOutputs", mock.Anything, mock.Anything)
+
+		mockAPI.On("PostModelOutputs", mock.Anything, mock.Anything).Return(conceptOutput(&pb.Concept{Name: "cat", Value: 0.9}), nil).Twice()
+		args["overwrite_report"] = true
+		resp = compareModels(handler, args)
+		require.Nil(t, resp.Error)
+		reportJSON, err := os.ReadFile(reportPath)
+		require.NoError(t, err)
+		var report CompareReport
+		require.NoError(t, json.Unmarshal(reportJSON, &report))
+		assert.Len(t, report.Models, 2)
+		mockAPI.AssertExpectations(t)
+	})
+
+	t.Run("Invalid arguments are rejected", func(t *testing.T) {
+		handler := setupTestHandler(new(MockClarifaiAPIClient))
+		for message, args := range map[string]map[string]interface{}{
+			"must list 2-10 models":      {"model_ids": []interface{}{"a"}, "text": "x"},
+			"without an app":             {"model_ids": []interface{}{"a", "b"}, "text": "x"},
+			"more than once":             {"model_ids": []interface{}{"u/p/a", "u/p/a"}, "text": "x"},
+			"is not 'model_id'":          {"model_ids": []interface{}{"u/p/a", "user/model"}, "text": "x"},
+			"invalid user, app or model": {"model_ids": []interface{}{"u/p/a", "u/p/bad model"}, "text": "x"},
+			"exactly one of":             {"model_ids": []interface{}{"u/p/a", "u/p/b"}, "text": "x", "image_url": "https://example.com/a.jpg"},
+			"'max_concurrency'":          {"model_ids": []interface{}{"u/p/a", "u/p/b"}, "text": "x", "max_concurrency": float64(0)},
+			"'top_k'":                    {"model_ids": []interface{}{"u/p/a", "u/p/b"}, "text": "x", "top_k": float64(0)},
+			"must be an absolute path":   {"model_ids": []interface{}{"u/p/a", "u/p/b"}, "text": "x", "report_path": "report.json"},
+			"must end in .json":          {"model_ids": []interface{}{"u/p/a", "u/p/b"}, "text": "x", "report_path": "/tmp/report.txt"},
+		} {
+			resp := compareModels(handler, args)
+			require.NotNil(t, resp.Error, message)
+			assert.Equal(t, -32602, resp.Error.Code, message)
+			assert.Contains(t, resp.Error.Message, message)
+		}
+	})
+}
 
 func TestHandleListResource_ListModels_Filtered(t *testing.T) {
 	mockAPI := new(MockClarifaiAPIClient)
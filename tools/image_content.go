@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"clarifai-mcp-server-local/config"
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+)
+
+// Image return modes, see config.ImageReturnModes.
+const (
+	returnModeAuto     = "auto"
+	returnModePath     = "path"
+	returnModeInline   = "inline"
+	returnModeBoth     = "both"
+	returnModeResource = "resource"
+)
+
+// defaultInlineImageMaxBytes is used when the config does not set an inline size limit.
+const defaultInlineImageMaxBytes = 10 * 1024
+
+// imageOutputOptions controls how generated images are encoded and returned.
+type imageOutputOptions struct {
+	ReturnMode string
+	Format     string // Re-encode target ("png", "jpeg", "gif"); empty keeps the model's format
+	Quality    int    // JPEG quality, 0 for the default
+}
+
+// imageOutputArgumentSchema returns the JSON schema properties for choosing how generated images are encoded and returned.
+func imageOutputArgumentSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"return_mode": map[string]interface{}{
+			"type":        "string",
+			"enum":        config.ImageReturnModes,
+			"description": "Optional: How images are returned. 'auto' inlines small images and saves large ones, 'path' saves and returns the file path, 'inline' returns image content only, 'both' returns the path and inline image, 'resource' saves and returns an embedded resource with a file:// URI. Defaults to the server's -image-return-mode.",
+		},
+		"output_format": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{"png", "jpeg", "gif"},
+			"description": "Optional: Re-encode images to this format. Defaults to the format returned by the model.",
+		},
+		"quality": map[string]interface{}{
+			"type":        "integer",
+			"description": fmt.Sprintf("Optional: JPEG quality (1-100) when output_format is 'jpeg'. Defaults to %d.", utils.DefaultJPEGQuality),
+		},
+	}
+}
+
+// imageOutputOptions reads the per-call image output arguments. The return mode falls back
+// to the configured default.
+func (h *Handler) imageOutputOptions(args map[string]interface{}) (imageOutputOptions, *mcp.RPCError) {
+	opts := imageOutputOptions{}
+	if raw, present := args["return_mode"]; present && raw != nil {
+		s, ok := raw.(string)
+		if !ok {
+			return opts, invalidParam("return_mode", "must be a string")
+		}
+		opts.ReturnMode = s
+	}
+	if opts.ReturnMode == "" && h.config != nil {
+		opts.ReturnMode = h.config.ImageReturnMode
+	}
+	if opts.ReturnMode == "" {
+		opts.ReturnMode = returnModeAuto
+	}
+	validMode := false
+	for _, mode := range config.ImageReturnModes {
+		if opts.ReturnMode == mode {
+			validMode = true
+			break
+		}
+	}
+	if !validMode {
+		return opts, invalidParam("return_mode", "must be one of "+strings.Join(config.ImageReturnModes, ", "))
+	}
+
+	format, _ := args["output_format"].(string)
+	normalized, err := utils.NormalizeImageFormat(format)
+	if err != nil {
+		return opts, invalidParam("output_format", "must be one of png, jpeg, gif")
+	}
+	opts.Format = normalized
+
+	quality, ok, rpcErr := intArg(args, "quality")
+	if rpcErr != nil {
+		return opts, rpcErr
+	}
+	if ok {
+		if quality < 1 || quality > 100 {
+			return opts, invalidParam("quality", "must be between 1 and 100")
+		}
+		opts.Quality = quality
+	}
+	return opts, nil
+}
+
+// generatedImageContent builds the MCP content items for one generated image according to opts.
+// Whenever the image is saved, meta is written next to it as a JSON sidecar.
+func (h *Handler) generatedImageContent(imageData []byte, opts imageOutputOptions, meta *GenerationMetadata, errCtx map[string]string) ([]map[string]interface{}, *mcp.RPCError) {
+	imageBytes, mimeType, err := utils.DecodeImageData(imageData)
+	if err != nil {
+		h.logger.Error("Failed to decode generated image", "error", err)
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to decode generated image: %v", err), Data: errCtx}
+	}
+	if opts.Format != "" {
+		imageBytes, err = utils.ReencodeImage(imageBytes, opts.Format, opts.Quality)
+		if err != nil {
+			h.logger.Error("Failed to re-encode generated image", "format", opts.Format, "error", err)
+			return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to re-encode generated image: %v", err), Data: errCtx}
+		}
+		mimeType = utils.DetectImageMIMEType(imageBytes)
+	}
+
+	mode := opts.ReturnMode
+	if mode == returnModeAuto {
+		maxInline := defaultInlineImageMaxBytes
+		if h.config != nil && h.config.InlineImageMaxBytes > 0 {
+			maxInline = h.config.InlineImageMaxBytes
+		}
+		mode = returnModeInline
+		if h.outputPath != "" && len(imageBytes) > maxInline {
+			mode = returnModePath
+		}
+		h.logger.Debug("Resolved auto image return mode", "size_bytes", len(imageBytes), "max_inline_bytes", maxInline, "mode", mode)
+	}
+
+	inline := map[string]interface{}{
+		"type":     "image",
+		"data":     base64.StdEncoding.EncodeToString(imageBytes),
+		"mimeType": mimeType,
+	}
+	if mode == returnModeInline {
+		return []map[string]interface{}{inline}, nil
+	}
+
+	if h.outputPath == "" {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Return mode '%s' requires an output path; start the server with -output-path", mode), Data: errCtx}
+	}
+	nameTemplate := utils.DefaultOutputNameTemplate
+	if h.config != nil && h.config.OutputNameTemplate != "" {
+		nameTemplate = h.config.OutputNameTemplate
+	}
+	nameFields := utils.OutputNameFields{Model: meta.ModelID, Prompt: meta.Prompt, Index: meta.Index, NoSeed: meta.Seed == nil}
+	if meta.Seed != nil {
+		nameFields.Seed = *meta.Seed
+	}
+	savedPath, saveErr := utils.SaveNamedImage(h.outputPath, nameTemplate, nameFields, imageBytes)
+	if saveErr != nil {
+		h.logger.Error("Error saving image using utility function", "error", saveErr)
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to save generated image to disk: %v", saveErr), Data: errCtx}
+	}
+	h.logger.Debug("Successfully saved image to disk via utility function", "path", savedPath)
+	meta.ImageFile = savedPath
+	sidecarPath, sidecarErr := utils.WriteSidecar(savedPath, meta)
+	if sidecarErr != nil {
+		h.logger.Error("Error writing generation metadata", "path", savedPath, "error", sidecarErr)
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to save generation metadata: %v", sidecarErr), Data: errCtx}
+	}
+	pathText := map[string]interface{}{
+		"type": "text",
+		"text": fmt.Sprintf("Image saved to: %s\nMetadata: %s", savedPath, sidecarPath),
+	}
+
+	switch mode {
+	case returnModeBoth:
+		return []map[string]interface{}{pathText, inline}, nil
+	case returnModeResource:
+		absPath, err := filepath.Abs(savedPath)
+		if err != nil {
+			absPath = savedPath
+		}
+		fileURI := (&url.URL{Scheme: "file", Path: filepath.ToSlash(absPath)}).String()
+		return []map[string]interface{}{{
+			"type": "resource",
+			"resource": map[string]interface{}{
+				"uri":      fileURI,
+				"mimeType": mimeType,
+				"blob":     inline["data"],
+			},
+		}}, nil
+	default:
+		return []map[string]interface{}{pathText}, nil
+	}
+}
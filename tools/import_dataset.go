@@ -0,0 +1,388 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// importBoxInfoKey tags every annotation import_dataset creates with its box ("<file>#<index>")
+// in annotation_info, so a re-run only creates the boxes an earlier import did not.
+const importBoxInfoKey = "import_dataset_box"
+
+// ImportReport summarises an import_dataset call.
+type ImportReport struct {
+	Path        string            `json:"path"`
+	Format      string            `json:"format"`
+	DatasetID   string            `json:"datasetId,omitempty"`
+	DryRun      bool              `json:"dryRun,omitempty"`
+	Images      int               `json:"images"`
+	Boxes       int               `json:"boxes"`
+	Annotations int               `json:"annotations"` // Boxes stored as annotations
+	PerConcept  map[string]int    `json:"perConcept"`
+	ConceptIDs  map[string]string `json:"conceptIds,omitempty"` // Label names that were not valid concept IDs, and the ID used
+	Problems    []string          `json:"problems,omitempty"`   // Malformed labels that were skipped
+	Existing    int               `json:"existing,omitempty"`   // Images whose inputs an earlier import had created
+	Inputs      []ImportedImage   `json:"inputs,omitempty"`
+	Failures    []InputFailure    `json:"failures,omitempty"`
+}
+
+// ImportedImage links a local image to the input created for it.
+type ImportedImage struct {
+	File          string `json:"file"`
+	InputID       string `json:"inputId"`
+	Boxes         int    `json:"boxes"`
+	Existing      bool   `json:"existing,omitempty"`      // Created by an earlier import and not uploaded again
+	ExistingBoxes int    `json:"existingBoxes,omitempty"` // Boxes an earlier import annotated, counted in Boxes
+}
+
+// importDatasetArgumentSchema returns the JSON schema properties of import_dataset.
+func importDatasetArgumentSchema() map[string]interface{} {
+	return mergeProperties(map[string]interface{}{
+		"path": map[string]interface{}{
+			"type":        "string",
+			"description": "Absolute path of the local dataset directory.",
+		},
+		"format": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{utils.DatasetFormatCOCO, utils.DatasetFormatYOLO, utils.DatasetFormatVOC},
+			"description": "Optional: 'coco' (annotations.json), 'yolo' (classes.txt, images/ and labels/) or 'voc' (Annotations/*.xml). Detected from the directory by default.",
+		},
+		"dataset_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Dataset to add the new inputs to.",
+		},
+		"skip_invalid": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Optional: Import the valid images and labels and skip malformed ones instead of refusing the whole import. Defaults to false.",
+		},
+		"dry_run": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Optional: Only validate the dataset and report what would be imported. Defaults to false.",
+		},
+	}, appContextArgumentSchema())
+}
+
+// callImportDataset uploads a local COCO, YOLO or VOC detection dataset: every image becomes an
+// input and every bounding box an annotation. Concepts missing from the app are created by
+// Clarifai with the annotations. The whole dataset is validated first; malformed labels stop the
+// import before anything is sent unless skip_invalid is set.
+func (h *Handler) callImportDataset(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callImportDataset tool")
+
+	path, ok := args["path"].(string)
+	if !ok || path == "" {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: missing or invalid 'path'"}
+	}
+	format, _ := args["format"].(string)
+	if format != "" && format != utils.DatasetFormatCOCO && format != utils.DatasetFormatYOLO && format != utils.DatasetFormatVOC {
+		return nil, invalidParam("format", "must be 'coco', 'yolo' or 'voc'")
+	}
+	datasetID, _ := args["dataset_id"].(string)
+	if raw, present := args["dataset_id"]; present && raw != nil && (datasetID == "" || !clarifaiIDPattern.MatchString(datasetID)) {
+		return nil, invalidParam("dataset_id", "must be 1-255 letters, digits, '-' or '_'")
+	}
+	skipInvalid, rpcErr := boolArg(args, "skip_invalid")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	dryRun, rpcErr := boolArg(args, "dry_run")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":   "import_dataset",
+		"path":   path,
+		"userID": userAppIDSet.UserId,
+		"appID":  userAppIDSet.AppId,
+	}
+
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return nil, &mcp.RPCError{Code: -32602, Message: fmt.Sprintf("Invalid params: 'path' must be a dataset directory: %s", path), Data: errCtx}
+	}
+	if format == "" {
+		detected, err := utils.DetectDatasetFormat(path)
+		if err != nil {
+			return nil, &mcp.RPCError{Code: -32602, Message: fmt.Sprintf("Invalid params: cannot detect the dataset format, set 'format': %v", err), Data: errCtx}
+		}
+		format = detected
+	}
+	errCtx["format"] = format
+
+	images, problems, err := utils.ReadDataset(path, format)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, &mcp.RPCError{Code: -32602, Message: fmt.Sprintf("Invalid params: not a %s dataset: %v", strings.ToUpper(format), err), Data: errCtx}
+		}
+		return nil, &mcp.RPCError{Code: -32602, Message: fmt.Sprintf("Invalid params: invalid %s dataset: %v", strings.ToUpper(format), err), Data: errCtx}
+	}
+	conceptIDs, conceptProblems := datasetConceptIDs(images)
+	problems = append(problems, conceptProblems...)
+	if len(problems) > 0 && !skipInvalid {
+		message := fmt.Sprintf("Invalid params: %d malformed label(s) or image(s) in %s, nothing was imported (set 'skip_invalid' to import the rest):", len(problems), path)
+		for i, problem := range problems {
+			if i == maxManifestErrors {
+				message += fmt.Sprintf("\n... and %d more", len(problems)-maxManifestErrors)
+				break
+			}
+			message += "\n" + problem
+		}
+		return nil, &mcp.RPCError{Code: -32602, Message: message, Data: errCtx}
+	}
+
+	report := ImportReport{Path: path, Format: format, DatasetID: datasetID, DryRun: dryRun, Images: len(images), PerConcept: map[string]int{}, Problems: problems}
+	for name, id := range conceptIDs {
+		if name != id {
+			if report.ConceptIDs == nil {
+				report.ConceptIDs = map[string]string{}
+			}
+			report.ConceptIDs[name] = id
+		}
+	}
+	for _, img := range images {
+		for _, box := range img.Boxes {
+			if conceptIDs[box.Concept] != "" {
+				report.Boxes++
+				report.PerConcept[conceptIDs[box.Concept]]++
+			}
+		}
+	}
+
+	if !dryRun {
+		for start := 0; start < len(images); start += defaultBulkBatchSize {
+			end := start + defaultBulkBatchSize
+			if end > len(images) {
+				end = len(images)
+			}
+			if rpcErr := h.importImages(userAppIDSet, path, datasetID, images[start:end], conceptIDs, &report, errCtx); rpcErr != nil {
+				if start > 0 {
+					rpcErr.Message += fmt.Sprintf(" (%d of %d images were imported before the failure; run the import again to resume)", start, len(images))
+				}
+				return nil, rpcErr
+			}
+		}
+		h.logger.Info("Dataset import finished", "path", path, "format", format, "images", len(report.Inputs), "annotations", report.Annotations, "failures", len(report.Failures))
+	}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to marshal report: %v", err), Data: errCtx}
+	}
+	return map[string]interface{}{
+		"content": []map[string]any{
+			{"type": "text", "text": importSummary(report)},
+			{"type": "text", "text": string(reportJSON)},
+		},
+	}, nil
+}
+
+// importImages uploads one batch of images as inputs and annotates them with their boxes.
+// Input IDs are derived from the image paths, so a re-run after a failure finds the inputs created
+// before: they are not uploaded again and only get the boxes an earlier import did not create.
+// Failed annotation calls are recorded in the report; a failed upload stops the import.
+func (h *Handler) importImages(userAppIDSet *pb.UserAppIDSet, dir, datasetID string, images []utils.DatasetImage, conceptIDs map[string]string, report *ImportReport, errCtx map[string]string) *mcp.RPCError {
+	ids := make([]string, len(images))
+	for i, img := range images {
+		ids[i] = importInputID(dir, img.File)
+	}
+	existing, importedBoxes, rpcErr := h.existingImportInputs(userAppIDSet, ids, errCtx)
+	if rpcErr != nil {
+		return rpcErr
+	}
+
+	var inputs []*pb.Input
+	for i, img := range images {
+		if existing[ids[i]] {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(img.File)))
+		if err != nil {
+			return &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to read image file: %v", err), Data: errCtx}
+		}
+		input := &pb.Input{Id: ids[i], Data: &pb.Data{Image: &pb.Image{Base64: data}}}
+		if datasetID != "" {
+			input.DatasetIds = []string{datasetID}
+		}
+		inputs = append(inputs, input)
+	}
+	if len(inputs) > 0 {
+		h.logger.Debug("Making gRPC call to PostInputs (import)", "inputs", len(inputs))
+		if err := h.postBulkInputs(userAppIDSet, inputs); err != nil {
+			return utils.HandleApiError(err, errCtx, h.logger)
+		}
+	}
+
+	var annotations []*pb.Annotation
+	for i, img := range images {
+		imported := ImportedImage{File: img.File, InputID: ids[i], Existing: existing[ids[i]]}
+		if imported.Existing {
+			report.Existing++
+		}
+		for j, box := range img.Boxes {
+			conceptID := conceptIDs[box.Concept]
+			if conceptID == "" {
+				continue // Concept name without a usable ID, reported as a problem
+			}
+			imported.Boxes++
+			boxKey := fmt.Sprintf("%s#%d", img.File, j)
+			if importedBoxes[ids[i]][boxKey] {
+				imported.ExistingBoxes++
+				continue
+			}
+			info, err := structpb.NewStruct(map[string]interface{}{importBoxInfoKey: boxKey})
+			if err != nil {
+				return &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to build annotation info: %v", err), Data: errCtx}
+			}
+			annotations = append(annotations, &pb.Annotation{InputId: ids[i], AnnotationInfo: info, Data: &pb.Data{Regions: []*pb.Region{{
+				RegionInfo: &pb.RegionInfo{BoundingBox: &pb.BoundingBox{
+					TopRow:    float32(box.TopRow),
+					LeftCol:   float32(box.LeftCol),
+					BottomRow: float32(box.BottomRow),
+					RightCol:  float32(box.RightCol),
+				}},
+				Data: &pb.Data{Concepts: []*pb.Concept{{Id: conceptID, Name: box.Concept, Value: 1}}},
+			}}}})
+		}
+		report.Inputs = append(report.Inputs, imported)
+	}
+	for start := 0; start < len(annotations); start += maxBulkBatchSize {
+		end := start + maxBulkBatchSize
+		if end > len(annotations) {
+			end = len(annotations)
+		}
+		chunk := annotations[start:end]
+		ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+		if rpcErr != nil {
+			rpcErr.Data = errCtx
+			return rpcErr
+		}
+		_, err := h.clarifaiClient.PostAnnotations(ctx, userAppIDSet, chunk, h.logger)
+		cancel()
+		if err != nil {
+			h.logger.Warn("Creating annotations failed", "annotations", len(chunk), "error", err)
+			failed := map[string]bool{}
+			for _, annotation := range chunk {
+				if !failed[annotation.GetInputId()] {
+					failed[annotation.GetInputId()] = true
+					report.Failures = append(report.Failures, InputFailure{InputID: annotation.GetInputId(), Error: fmt.Sprintf("creating annotations failed: %v", err)})
+				}
+			}
+			continue
+		}
+		report.Annotations += len(chunk)
+	}
+	return nil
+}
+
+// existingImportInputs looks up which of ids already exist and, for each of those, the boxes an
+// earlier import annotated (by their importBoxInfoKey tag). Other annotations, e.g. from users
+// or other tools, are ignored.
+func (h *Handler) existingImportInputs(userAppIDSet *pb.UserAppIDSet, ids []string, errCtx map[string]string) (map[string]bool, map[string]map[string]bool, *mcp.RPCError) {
+	inputs, err := h.listInputsByID(userAppIDSet, ids)
+	if err != nil {
+		return nil, nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+	existing := map[string]bool{}
+	boxes := map[string]map[string]bool{}
+	if len(inputs) == 0 {
+		return existing, boxes, nil
+	}
+	existingIDs := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		existing[input.GetId()] = true
+		existingIDs = append(existingIDs, input.GetId())
+	}
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, nil, rpcErr
+	}
+	defer cancel()
+	annotations, err := h.clarifaiClient.ListInputAnnotations(ctx, userAppIDSet, existingIDs, h.logger)
+	if err != nil {
+		return nil, nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+	for _, annotation := range annotations {
+		key := annotation.GetAnnotationInfo().GetFields()[importBoxInfoKey].GetStringValue()
+		if key == "" {
+			continue
+		}
+		if boxes[annotation.GetInputId()] == nil {
+			boxes[annotation.GetInputId()] = map[string]bool{}
+		}
+		boxes[annotation.GetInputId()][key] = true
+	}
+	return existing, boxes, nil
+}
+
+// importInputID derives a stable input ID for an image of the dataset in dir. The hash covers
+// the absolute path, as relative names such as images/0001.jpg repeat across datasets.
+func importInputID(dir, file string) string {
+	return stableInputID(file, filepath.Join(dir, filepath.FromSlash(file)))
+}
+
+// datasetConceptIDs maps every label name of the dataset to a Clarifai concept ID. Names that are
+// valid IDs are kept; others, such as "traffic light", are slugified to "traffic-light". Names
+// without letters or digits have no ID and are reported as problems.
+func datasetConceptIDs(images []utils.DatasetImage) (map[string]string, []string) {
+	ids := map[string]string{}
+	var problems []string
+	for _, name := range utils.DatasetConcepts(images) {
+		id := name
+		if !clarifaiIDPattern.MatchString(id) {
+			id = utils.Slugify(name, 255)
+		}
+		if id == "" {
+			problems = append(problems, fmt.Sprintf("label %q cannot be used as a concept ID", name))
+		}
+		ids[name] = id
+	}
+	return ids, problems
+}
+
+// importSummary renders e.g. "Imported 10 image(s) with 25 bounding box(es): car 20, person 5."
+func importSummary(report ImportReport) string {
+	verb := "Imported"
+	if report.DryRun {
+		verb = "Dry run: would import"
+	}
+	summary := fmt.Sprintf("%s %d image(s) with %d bounding box(es)", verb, report.Images, report.Boxes)
+	if report.DatasetID != "" {
+		summary += " into dataset " + report.DatasetID
+	}
+	concepts := make([]string, 0, len(report.PerConcept))
+	for id := range report.PerConcept {
+		concepts = append(concepts, id)
+	}
+	sort.Strings(concepts)
+	for i, id := range concepts {
+		concepts[i] = fmt.Sprintf("%s %d", id, report.PerConcept[id])
+	}
+	if len(concepts) > 0 {
+		summary += ": " + strings.Join(concepts, ", ")
+	}
+	summary += "."
+	if len(report.Problems) > 0 {
+		summary += fmt.Sprintf(" Skipped %d malformed label(s) or image(s).", len(report.Problems))
+	}
+	if report.Existing > 0 {
+		summary += fmt.Sprintf(" %d image(s) had been imported before and were not uploaded again.", report.Existing)
+	}
+	if len(report.Failures) > 0 {
+		summary += fmt.Sprintf(" Annotating %d input(s) failed.", len(report.Failures))
+	}
+	return summary
+}
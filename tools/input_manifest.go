@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Upload manifest formats.
+const (
+	manifestFormatCSV   = "csv"
+	manifestFormatJSONL = "jsonl"
+)
+
+// manifestOptionColumns are the manifest columns passed on to parseInputOptions for each row.
+var manifestOptionColumns = []string{"input_id", "concepts", "negative_concepts", "metadata", "dataset_id"}
+
+// manifestRow is one input described by an upload manifest: either a URL or a local path, plus
+// optional per-row input fields keyed like the tool arguments.
+type manifestRow struct {
+	Line    int
+	URL     string
+	Path    string
+	Options map[string]interface{}
+}
+
+// manifestFormat returns the format of the manifest at path: the explicit format if given,
+// otherwise the one implied by its extension.
+func manifestFormat(path, format string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			return manifestFormatCSV, nil
+		case ".jsonl", ".ndjson":
+			return manifestFormatJSONL, nil
+		}
+		return "", errors.New("cannot tell the manifest format from its extension; set format to 'csv' or 'jsonl'")
+	}
+	format = strings.ToLower(format)
+	if format != manifestFormatCSV && format != manifestFormatJSONL {
+		return "", fmt.Errorf("unsupported manifest format %q; use 'csv' or 'jsonl'", format)
+	}
+	return format, nil
+}
+
+// readInputManifest parses a CSV or JSONL upload manifest. Relative paths in the manifest are
+// resolved against the manifest's directory.
+func readInputManifest(path, format string) ([]manifestRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rows []manifestRow
+	if format == manifestFormatCSV {
+		rows, err = parseCSVManifest(f)
+	} else {
+		rows, err = parseJSONLManifest(f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("manifest has no rows")
+	}
+	baseDir := filepath.Dir(path)
+	for i := range rows {
+		if rows[i].Path != "" && !filepath.IsAbs(rows[i].Path) {
+			rows[i].Path = filepath.Join(baseDir, rows[i].Path)
+		}
+	}
+	return rows, nil
+}
+
+// parseCSVManifest reads a CSV manifest with a header row. It needs a url or path column;
+// input_id, concepts, negative_concepts, metadata (a JSON object) and dataset_id are optional
+// and other columns are ignored. Concept lists may be separated by ',', ';' or '|'.
+func parseCSVManifest(r io.Reader) ([]manifestRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	_, hasURL := columns["url"]
+	_, hasPath := columns["path"]
+	if !hasURL && !hasPath {
+		return nil, errors.New("manifest header needs a 'url' or 'path' column")
+	}
+	cell := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []manifestRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		row := manifestRow{Line: line, URL: cell(record, "url"), Path: cell(record, "path"), Options: map[string]interface{}{}}
+		for _, name := range manifestOptionColumns {
+			value := cell(record, name)
+			if value == "" {
+				continue
+			}
+			switch name {
+			case "metadata":
+				var metadata map[string]interface{}
+				if err := json.Unmarshal([]byte(value), &metadata); err != nil {
+					return nil, fmt.Errorf("line %d: metadata must be a JSON object: %v", line, err)
+				}
+				row.Options[name] = metadata
+			case "concepts", "negative_concepts":
+				row.Options[name] = normalizeConceptList(value)
+			default:
+				row.Options[name] = value
+			}
+		}
+		if err := row.validate(); err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseJSONLManifest reads a manifest with one JSON object per line using the same field names
+// as the CSV columns. Concepts may be arrays or separated strings; blank lines are skipped.
+func parseJSONLManifest(r io.Reader) ([]manifestRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	var rows []manifestRow
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &fields); err != nil {
+			return nil, fmt.Errorf("line %d: not a JSON object: %v", line, err)
+		}
+		row := manifestRow{Line: line, Options: map[string]interface{}{}}
+		for key, target := range map[string]*string{"url": &row.URL, "path": &row.Path} {
+			if raw, ok := fields[key]; ok && raw != nil {
+				s, ok := raw.(string)
+				if !ok {
+					return nil, fmt.Errorf("line %d: %s must be a string", line, key)
+				}
+				*target = strings.TrimSpace(s)
+			}
+		}
+		for _, name := range manifestOptionColumns {
+			raw, ok := fields[name]
+			if !ok || raw == nil {
+				continue
+			}
+			if s, isString := raw.(string); isString && (name == "concepts" || name == "negative_concepts") {
+				raw = normalizeConceptList(s)
+			}
+			row.Options[name] = raw
+		}
+		if err := row.validate(); err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return rows, nil
+}
+
+// validate checks that the row names exactly one source.
+func (r manifestRow) validate() error {
+	switch {
+	case r.URL == "" && r.Path == "":
+		return fmt.Errorf("line %d: needs a url or a path", r.Line)
+	case r.URL != "" && r.Path != "":
+		return fmt.Errorf("line %d: has both a url and a path; use one per row", r.Line)
+	}
+	return nil
+}
+
+// normalizeConceptList turns ';' and '|' separated concept lists into the comma-separated form
+// accepted by stringListArg.
+func normalizeConceptList(s string) string {
+	return strings.NewReplacer(";", ",", "|", ",").Replace(s)
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadInputManifest(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		path := write("inputs.csv", "\ufeffURL,path,input_id,concepts,metadata,notes\n"+
+			"https://example.com/cat.jpg,,cat-1,cat;pet,\"{\"\"source\"\": \"\"web\"\"}\",ignored\n"+
+			",images/dog.png,,dog|pet,,\n")
+		rows, err := readInputManifest(path, manifestFormatCSV)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+
+		assert.Equal(t, 2, rows[0].Line)
+		assert.Equal(t, "https://example.com/cat.jpg", rows[0].URL)
+		assert.Equal(t, "cat-1", rows[0].Options["input_id"])
+		assert.Equal(t, "cat,pet", rows[0].Options["concepts"])
+		assert.Equal(t, map[string]interface{}{"source": "web"}, rows[0].Options["metadata"])
+		assert.NotContains(t, rows[0].Options, "notes")
+
+		assert.Equal(t, filepath.Join(dir, "images", "dog.png"), rows[1].Path, "relative paths resolve against the manifest")
+		assert.Equal(t, "dog,pet", rows[1].Options["concepts"])
+		assert.NotContains(t, rows[1].Options, "metadata")
+	})
+
+	t.Run("JSONL", func(t *testing.T) {
+		path := write("inputs.jsonl", `{"url": "https://example.com/a.mp3", "concepts": ["speech"], "metadata": {"n": 1}}`+"\n\n"+
+			`{"path": "/data/b.txt", "negative_concepts": "spam; ads", "dataset_id": "train"}`+"\n")
+		rows, err := readInputManifest(path, manifestFormatJSONL)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		assert.Equal(t, []interface{}{"speech"}, rows[0].Options["concepts"])
+		assert.Equal(t, 3, rows[1].Line)
+		assert.Equal(t, "/data/b.txt", rows[1].Path)
+		assert.Equal(t, "spam, ads", rows[1].Options["negative_concepts"])
+		assert.Equal(t, "train", rows[1].Options["dataset_id"])
+	})
+
+	errorCases := []struct {
+		name    string
+		file    string
+		content string
+		format  string
+		errText string
+	}{
+		{"CSV without source column", "a.csv", "input_id,concepts\nx,y\n", manifestFormatCSV, "'url' or 'path' column"},
+		{"CSV row without source", "b.csv", "url,path\n,\n", manifestFormatCSV, "line 2: needs a url or a path"},
+		{"CSV invalid metadata", "c.csv", "url,metadata\nhttps://x.io/a.jpg,not-json\n", manifestFormatCSV, "line 2: metadata must be a JSON object"},
+		{"JSONL row with both sources", "d.jsonl", `{"url": "https://x.io/a.jpg", "path": "a.jpg"}`, manifestFormatJSONL, "line 1: has both a url and a path"},
+		{"JSONL invalid line", "e.jsonl", "{\"url\": \"https://x.io/a.jpg\"}\n[1, 2]\n", manifestFormatJSONL, "line 2: not a JSON object"},
+		{"Empty manifest", "f.csv", "url\n", manifestFormatCSV, "no rows"},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := readInputManifest(write(tc.file, tc.content), tc.format)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.errText)
+		})
+	}
+}
+
+func TestManifestFormat(t *testing.T) {
+	format, err := manifestFormat("/tmp/inputs.CSV", "")
+	require.NoError(t, err)
+	assert.Equal(t, manifestFormatCSV, format)
+
+	format, err = manifestFormat("/tmp/inputs.ndjson", "")
+	require.NoError(t, err)
+	assert.Equal(t, manifestFormatJSONL, format)
+
+	format, err = manifestFormat("/tmp/inputs.txt", "JSONL")
+	require.NoError(t, err)
+	assert.Equal(t, manifestFormatJSONL, format)
+
+	_, err = manifestFormat("/tmp/inputs.txt", "")
+	assert.Error(t, err)
+	_, err = manifestFormat("/tmp/inputs.csv", "xlsx")
+	assert.Error(t, err)
+}
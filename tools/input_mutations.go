@@ -0,0 +1,508 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"clarifai-mcp-server-local/clarifai"
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	defaultDeleteMaxInputs = 100
+	maxDeleteMaxInputs     = 1000
+	searchPageSize         = 100
+)
+
+// Actions accepted by patch_input, as understood by PatchInputs.
+const (
+	patchActionMerge     = "merge"
+	patchActionOverwrite = "overwrite"
+	patchActionRemove    = "remove"
+)
+
+// deleteOperation is what a delete_inputs confirmation token authorises.
+type deleteOperation struct {
+	UserID   string   `json:"userId"`
+	AppID    string   `json:"appId"`
+	InputIDs []string `json:"inputIds"`
+}
+
+// patchOperation is what a patch_input confirmation token authorises. It includes the input's
+// state at preview time, so the token expires if the input changes in between.
+type patchOperation struct {
+	UserID  string       `json:"userId"`
+	AppID   string       `json:"appId"`
+	InputID string       `json:"inputId"`
+	Action  string       `json:"action"`
+	Before  InputSummary `json:"before"`
+	After   InputSummary `json:"after"`
+}
+
+// appContextArgumentSchema returns the user_id and app_id properties of tools that change an app.
+func appContextArgumentSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"app_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: App ID context. Defaults to the app associated with the PAT.",
+		},
+		"user_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: User ID context. Defaults to the user associated with the PAT.",
+		},
+	}
+}
+
+// inputFilterArgumentSchema returns the properties that select inputs by search instead of ID.
+func inputFilterArgumentSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"concepts": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": "Optional: Select inputs labelled with all of these concept IDs.",
+		},
+		"metadata": map[string]interface{}{
+			"type":        "object",
+			"description": "Optional: Select inputs whose metadata contains all of these key/value pairs.",
+		},
+		"dataset_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Select inputs in this dataset.",
+		},
+	}
+}
+
+// inputQueryArgumentSchema returns the query property of tools taking the search syntax.
+func inputQueryArgumentSchema(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"query": map[string]interface{}{
+			"type":        "string",
+			"description": description + " " + querySyntaxDescription,
+		},
+	}
+}
+
+// parseInputFilters turns the concepts, metadata and dataset_id arguments into search filters.
+// Filters are AND-ed, so every metadata key gets its own filter (keys within one are OR-ed).
+func parseInputFilters(args map[string]interface{}) ([]*pb.Filter, *mcp.RPCError) {
+	var filters []*pb.Filter
+	concepts, rpcErr := stringListArg(args, "concepts")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	for _, id := range concepts {
+		if !clarifaiIDPattern.MatchString(id) {
+			return nil, invalidParam("concepts", fmt.Sprintf("contains invalid concept ID %q", id))
+		}
+		filters = append(filters, &pb.Filter{Annotation: &pb.Annotation{Data: &pb.Data{Concepts: []*pb.Concept{{Id: id, Value: 1}}}}})
+	}
+	if raw, present := args["metadata"]; present && raw != nil {
+		fields, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, invalidParam("metadata", "must be an object")
+		}
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			metadata, err := structpb.NewStruct(map[string]interface{}{key: fields[key]})
+			if err != nil {
+				return nil, invalidParam("metadata", fmt.Sprintf("is not valid JSON metadata: %v", err))
+			}
+			filters = append(filters, &pb.Filter{Annotation: &pb.Annotation{Data: &pb.Data{Metadata: metadata}}})
+		}
+	}
+	if raw, present := args["dataset_id"]; present && raw != nil {
+		id, ok := raw.(string)
+		if !ok || !clarifaiIDPattern.MatchString(id) {
+			return nil, invalidParam("dataset_id", "must be 1-255 letters, digits, '-' or '_'")
+		}
+		filters = append(filters, &pb.Filter{Input: &pb.Input{DatasetIds: []string{id}}})
+	}
+	return filters, nil
+}
+
+// callDeleteInputs deletes inputs given by ID or selected by a filter. The first call only
+// previews the inputs that would be deleted and hands out a confirm_token; repeating the call
+// with that token deletes exactly those inputs.
+func (h *Handler) callDeleteInputs(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callDeleteInputs tool")
+
+	ids, rpcErr := stringListArg(args, "input_ids")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	for _, id := range ids {
+		if !clarifaiIDPattern.MatchString(id) {
+			return nil, invalidParam("input_ids", fmt.Sprintf("contains invalid input ID %q", id))
+		}
+	}
+	filters, rpcErr := parseInputFilters(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if rawQuery, present := args["query"]; present && rawQuery != nil && rawQuery != "" {
+		queryString, ok := rawQuery.(string)
+		if !ok {
+			return nil, invalidParam("query", "must be a string")
+		}
+		query, err := clarifai.ParseQuery(queryString)
+		if err != nil {
+			return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: " + err.Error()}
+		}
+		if len(query.Ranks) > 0 {
+			return nil, invalidParam("query", "may only contain filter terms (concept:, metadata., dataset:, status:, geo:); free text and ~ terms rank inputs instead of selecting them")
+		}
+		filters = append(filters, query.Filters...)
+	}
+	if (len(ids) == 0) == (len(filters) == 0) {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: give either 'input_ids' or a filter ('concepts', 'metadata', 'dataset_id', 'query'), not both"}
+	}
+	maxInputs := defaultDeleteMaxInputs
+	if value, ok, rpcErr := intArg(args, "max_inputs"); rpcErr != nil {
+		return nil, rpcErr
+	} else if ok {
+		if value < 1 || value > maxDeleteMaxInputs {
+			return nil, invalidParam("max_inputs", fmt.Sprintf("must be between 1 and %d", maxDeleteMaxInputs))
+		}
+		maxInputs = value
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":   "delete_inputs",
+		"userID": userAppIDSet.UserId,
+		"appID":  userAppIDSet.AppId,
+	}
+
+	var targets []*pb.Input
+	var missing []string
+	if len(ids) > 0 {
+		if len(ids) > maxInputs {
+			return nil, invalidParam("input_ids", fmt.Sprintf("lists %d inputs, more than max_inputs (%d)", len(ids), maxInputs))
+		}
+		var rpcErr *mcp.RPCError
+		targets, missing, rpcErr = h.inputsByID(userAppIDSet, ids, errCtx)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+	} else {
+		var rpcErr *mcp.RPCError
+		targets, rpcErr = h.searchInputs(userAppIDSet, &pb.Query{Filters: filters}, maxInputs+1, errCtx)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		if len(targets) > maxInputs {
+			return nil, &mcp.RPCError{Code: -32602, Message: fmt.Sprintf("Invalid params: the filter matches more than max_inputs (%d) inputs; narrow it or raise max_inputs", maxInputs), Data: errCtx}
+		}
+	}
+
+	if len(targets) == 0 {
+		text := "No matching inputs; nothing to delete."
+		if len(missing) > 0 {
+			text += fmt.Sprintf("\nNot found: %v", missing)
+		}
+		return map[string]interface{}{"content": []map[string]any{{"type": "text", "text": text}}}, nil
+	}
+
+	operation := deleteOperation{UserID: userAppIDSet.UserId, AppID: userAppIDSet.AppId}
+	summaries := make([]InputSummary, len(targets))
+	for i, input := range targets {
+		operation.InputIDs = append(operation.InputIDs, input.GetId())
+		summaries[i] = summarizeInput(input)
+	}
+	sort.Strings(operation.InputIDs)
+
+	confirmed, token, rpcErr := h.checkConfirmation("delete_inputs", operation, args)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, rpcErr
+	}
+	if !confirmed {
+		text := fmt.Sprintf("Preview: %d input(s) would be deleted. Nothing has been deleted yet.\nTo delete them, call delete_inputs again with the same arguments plus confirm_token %q.", len(targets), token)
+		if len(missing) > 0 {
+			text += fmt.Sprintf("\nNot found: %v", missing)
+		}
+		return previewResult(text, summaries)
+	}
+
+	for start := 0; start < len(operation.InputIDs); start += maxBulkBatchSize {
+		end := start + maxBulkBatchSize
+		if end > len(operation.InputIDs) {
+			end = len(operation.InputIDs)
+		}
+		ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+		if rpcErr != nil {
+			rpcErr.Data = errCtx
+			return nil, rpcErr
+		}
+		err := h.clarifaiClient.DeleteInputs(ctx, userAppIDSet, operation.InputIDs[start:end], h.logger)
+		cancel()
+		if err != nil {
+			rpcErr := utils.HandleApiError(err, errCtx, h.logger)
+			if start > 0 {
+				rpcErr.Message += fmt.Sprintf(" (%d of %d inputs were deleted before the failure)", start, len(operation.InputIDs))
+			}
+			return nil, rpcErr
+		}
+	}
+	h.logger.Info("Deleted inputs", "count", len(operation.InputIDs), "user_id", userAppIDSet.UserId, "app_id", userAppIDSet.AppId)
+	return previewResult(fmt.Sprintf("Deleted %d input(s).", len(operation.InputIDs)), summaries)
+}
+
+// callPatchInput changes the concepts and metadata of one input. Like delete_inputs, the first
+// call previews the input before and after the change and hands out a confirm_token.
+func (h *Handler) callPatchInput(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callPatchInput tool")
+
+	inputID, ok := args["input_id"].(string)
+	if !ok || inputID == "" {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: missing or invalid 'input_id'"}
+	}
+	action := patchActionMerge
+	if raw, present := args["action"]; present && raw != nil {
+		action, _ = raw.(string)
+		if action != patchActionMerge && action != patchActionOverwrite && action != patchActionRemove {
+			return nil, invalidParam("action", "must be 'merge', 'overwrite' or 'remove'")
+		}
+	}
+	opts, rpcErr := parseInputOptions(map[string]interface{}{
+		"input_id":          inputID,
+		"concepts":          args["concepts"],
+		"negative_concepts": args["negative_concepts"],
+		"metadata":          args["metadata"],
+	})
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if len(opts.Concepts) == 0 && opts.Metadata == nil {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: give 'concepts', 'negative_concepts' or 'metadata' to change"}
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":    "patch_input",
+		"inputID": inputID,
+		"action":  action,
+		"userID":  userAppIDSet.UserId,
+		"appID":   userAppIDSet.AppId,
+	}
+
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, rpcErr
+	}
+	current, err := h.clarifaiClient.GetInput(ctx, userAppIDSet, inputID, h.logger)
+	cancel()
+	if err != nil {
+		return nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+
+	operation := patchOperation{
+		UserID:  userAppIDSet.UserId,
+		AppID:   userAppIDSet.AppId,
+		InputID: inputID,
+		Action:  action,
+		Before:  summarizeInput(current),
+	}
+	operation.After = summarizeInput(applyPatch(current, opts, action))
+
+	confirmed, token, rpcErr := h.checkConfirmation("patch_input", operation, args)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, rpcErr
+	}
+	if !confirmed {
+		text := fmt.Sprintf("Preview: input %s before and after the %s. Nothing has been changed yet.\nTo apply it, call patch_input again with the same arguments plus confirm_token %q.", inputID, action, token)
+		return previewResult(text, map[string]InputSummary{"before": operation.Before, "after": operation.After})
+	}
+
+	patch := &pb.Input{Id: inputID, Data: &pb.Data{Concepts: opts.Concepts, Metadata: opts.Metadata}}
+	ctx, cancel, rpcErr = utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, rpcErr
+	}
+	defer cancel()
+	resp, err := h.clarifaiClient.PatchInputs(ctx, userAppIDSet, []*pb.Input{patch}, action, h.logger)
+	if err != nil {
+		return nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+	result := operation.After
+	if len(resp.GetInputs()) == 1 {
+		result = summarizeInput(resp.Inputs[0])
+	}
+	h.logger.Info("Patched input", "input_id", inputID, "action", action)
+	return previewResult(fmt.Sprintf("Patched input %s (%s).", inputID, action), result)
+}
+
+// applyPatch predicts the input PatchInputs produces, for the preview. merge adds or updates
+// the given concepts and deep-merges metadata; overwrite replaces the concepts and/or metadata
+// that are given; remove drops the given concept IDs and metadata keys.
+func applyPatch(current *pb.Input, opts inputOptions, action string) *pb.Input {
+	var concepts []*pb.Concept
+	metadata := map[string]interface{}{}
+	if current.GetData().GetMetadata() != nil {
+		metadata = current.GetData().GetMetadata().AsMap()
+	}
+	var patchMetadata map[string]interface{}
+	if opts.Metadata != nil {
+		patchMetadata = opts.Metadata.AsMap()
+	}
+
+	switch action {
+	case patchActionOverwrite:
+		concepts = current.GetData().GetConcepts()
+		if len(opts.Concepts) > 0 {
+			concepts = opts.Concepts
+		}
+		if patchMetadata != nil {
+			metadata = patchMetadata
+		}
+	case patchActionRemove:
+		remove := map[string]bool{}
+		for _, c := range opts.Concepts {
+			remove[c.GetId()] = true
+		}
+		for _, c := range current.GetData().GetConcepts() {
+			if !remove[c.GetId()] {
+				concepts = append(concepts, c)
+			}
+		}
+		removeMetadataKeys(metadata, patchMetadata)
+	default:
+		index := map[string]int{}
+		for _, c := range current.GetData().GetConcepts() {
+			index[c.GetId()] = len(concepts)
+			concepts = append(concepts, c)
+		}
+		for _, c := range opts.Concepts {
+			if i, ok := index[c.GetId()]; ok {
+				concepts[i] = c
+			} else {
+				concepts = append(concepts, c)
+			}
+		}
+		mergeMetadata(metadata, patchMetadata)
+	}
+
+	result := &pb.Input{
+		Id:         current.GetId(),
+		DatasetIds: current.GetDatasetIds(),
+		Status:     current.GetStatus(),
+		CreatedAt:  current.GetCreatedAt(),
+		Data:       &pb.Data{Image: current.GetData().GetImage(), Video: current.GetData().GetVideo(), Audio: current.GetData().GetAudio(), Text: current.GetData().GetText(), Concepts: concepts},
+	}
+	if s, err := structpb.NewStruct(metadata); err == nil {
+		result.Data.Metadata = s
+	}
+	return result
+}
+
+// mergeMetadata deep-merges patch into target.
+func mergeMetadata(target, patch map[string]interface{}) {
+	for key, value := range patch {
+		if patchObj, ok := value.(map[string]interface{}); ok {
+			if targetObj, ok := target[key].(map[string]interface{}); ok {
+				mergeMetadata(targetObj, patchObj)
+				continue
+			}
+		}
+		target[key] = value
+	}
+}
+
+// removeMetadataKeys deletes the keys of patch from target, descending into nested objects.
+func removeMetadataKeys(target, patch map[string]interface{}) {
+	for key, value := range patch {
+		if patchObj, ok := value.(map[string]interface{}); ok && len(patchObj) > 0 {
+			if targetObj, ok := target[key].(map[string]interface{}); ok {
+				removeMetadataKeys(targetObj, patchObj)
+				continue
+			}
+		}
+		delete(target, key)
+	}
+}
+
+// inputsByID fetches the given inputs in order, dropping duplicates. IDs that do not exist are
+// returned as missing.
+func (h *Handler) inputsByID(userAppIDSet *pb.UserAppIDSet, ids []string, errCtx map[string]string) ([]*pb.Input, []string, *mcp.RPCError) {
+	found := map[string]*pb.Input{}
+	for start := 0; start < len(ids); start += maxInputsPerPoll {
+		end := start + maxInputsPerPoll
+		if end > len(ids) {
+			end = len(ids)
+		}
+		inputs, err := h.listInputsByID(userAppIDSet, ids[start:end])
+		if err != nil {
+			return nil, nil, utils.HandleApiError(err, errCtx, h.logger)
+		}
+		for _, input := range inputs {
+			found[input.GetId()] = input
+		}
+	}
+	var inputs []*pb.Input
+	var missing []string
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if input, ok := found[id]; ok {
+			inputs = append(inputs, input)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return inputs, missing, nil
+}
+
+// searchInputs pages through an input search until limit inputs are collected or the results run out.
+func (h *Handler) searchInputs(userAppIDSet *pb.UserAppIDSet, query *pb.Query, limit int, errCtx map[string]string) ([]*pb.Input, *mcp.RPCError) {
+	var inputs []*pb.Input
+	for page := uint32(1); len(inputs) < limit; page++ {
+		ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+		if rpcErr != nil {
+			rpcErr.Data = errCtx
+			return nil, rpcErr
+		}
+		pageInputs, err := h.clarifaiClient.SearchInputs(ctx, userAppIDSet, query, &pb.Pagination{Page: page, PerPage: searchPageSize}, h.logger)
+		cancel()
+		if err != nil {
+			return nil, utils.HandleApiError(err, errCtx, h.logger)
+		}
+		inputs = append(inputs, pageInputs...)
+		if len(pageInputs) < searchPageSize {
+			break
+		}
+	}
+	if len(inputs) > limit {
+		inputs = inputs[:limit]
+	}
+	return inputs, nil
+}
+
+// previewResult formats a summary line plus the JSON of the affected inputs.
+func previewResult(text string, details interface{}) (interface{}, *mcp.RPCError) {
+	detailsJSON, err := json.MarshalIndent(details, "", "  ")
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to marshal result: %v", err)}
+	}
+	return map[string]interface{}{
+		"content": []map[string]any{
+			{"type": "text", "text": text},
+			{"type": "text", "text": string(detailsJSON)},
+		},
+	}, nil
+}
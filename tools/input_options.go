@@ -0,0 +1,173 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+
+	"clarifai-mcp-server-local/mcp"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// clarifaiIDPattern matches the IDs Clarifai accepts for inputs, concepts and datasets.
+var clarifaiIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,255}$`)
+
+// inputOptions holds the optional fields attached to uploaded inputs.
+type inputOptions struct {
+	InputID   string
+	Concepts  []*pb.Concept // Positive concepts have value 1, negative ones value 0
+	Metadata  *structpb.Struct
+	Geo       *pb.Geo
+	DatasetID string
+}
+
+// inputArgumentSchema returns the JSON schema properties for the optional input fields.
+func inputArgumentSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"input_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Custom input ID (letters, digits, '-' and '_'). Generated by Clarifai if omitted.",
+		},
+		"concepts": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": "Optional: Concept IDs present in the input (positive labels).",
+		},
+		"negative_concepts": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": "Optional: Concept IDs known to be absent from the input (negative labels).",
+		},
+		"metadata": map[string]interface{}{
+			"type":        "object",
+			"description": "Optional: Arbitrary JSON metadata stored with the input.",
+		},
+		"latitude": map[string]interface{}{
+			"type":        "number",
+			"description": "Optional: Latitude (-90 to 90). Requires longitude.",
+		},
+		"longitude": map[string]interface{}{
+			"type":        "number",
+			"description": "Optional: Longitude (-180 to 180). Requires latitude.",
+		},
+		"dataset_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Dataset to add the input to.",
+		},
+	}
+}
+
+// sharedInputArgumentSchema returns the input fields that can be applied to many inputs at
+// once, i.e. all but input_id.
+func sharedInputArgumentSchema() map[string]interface{} {
+	props := inputArgumentSchema()
+	delete(props, "input_id")
+	return props
+}
+
+// parseInputOptions reads and validates the optional input fields from tool arguments.
+func parseInputOptions(args map[string]interface{}) (inputOptions, *mcp.RPCError) {
+	var opts inputOptions
+
+	if raw, present := args["input_id"]; present && raw != nil {
+		id, ok := raw.(string)
+		if !ok || !clarifaiIDPattern.MatchString(id) {
+			return opts, invalidParam("input_id", "must be 1-255 letters, digits, '-' or '_'")
+		}
+		opts.InputID = id
+	}
+
+	positive, rpcErr := stringListArg(args, "concepts")
+	if rpcErr != nil {
+		return opts, rpcErr
+	}
+	negative, rpcErr := stringListArg(args, "negative_concepts")
+	if rpcErr != nil {
+		return opts, rpcErr
+	}
+	values := make(map[string]float32, len(positive)+len(negative))
+	for _, id := range positive {
+		if !clarifaiIDPattern.MatchString(id) {
+			return opts, invalidParam("concepts", fmt.Sprintf("contains invalid concept ID %q", id))
+		}
+		if _, dup := values[id]; !dup {
+			values[id] = 1
+			opts.Concepts = append(opts.Concepts, &pb.Concept{Id: id, Value: 1})
+		}
+	}
+	for _, id := range negative {
+		if !clarifaiIDPattern.MatchString(id) {
+			return opts, invalidParam("negative_concepts", fmt.Sprintf("contains invalid concept ID %q", id))
+		}
+		if value, dup := values[id]; dup {
+			if value == 1 {
+				return opts, invalidParam("negative_concepts", fmt.Sprintf("contains %q, which is also a positive concept", id))
+			}
+			continue
+		}
+		values[id] = 0
+		opts.Concepts = append(opts.Concepts, &pb.Concept{Id: id, Value: 0})
+	}
+
+	if raw, present := args["metadata"]; present && raw != nil {
+		fields, ok := raw.(map[string]interface{})
+		if !ok {
+			return opts, invalidParam("metadata", "must be an object")
+		}
+		metadata, err := structpb.NewStruct(fields)
+		if err != nil {
+			return opts, invalidParam("metadata", fmt.Sprintf("is not valid JSON metadata: %v", err))
+		}
+		opts.Metadata = metadata
+	}
+
+	lat, hasLat, rpcErr := floatArg(args, "latitude")
+	if rpcErr != nil {
+		return opts, rpcErr
+	}
+	lon, hasLon, rpcErr := floatArg(args, "longitude")
+	if rpcErr != nil {
+		return opts, rpcErr
+	}
+	if hasLat != hasLon {
+		return opts, &mcp.RPCError{Code: -32602, Message: "Invalid params: 'latitude' and 'longitude' must be given together"}
+	}
+	if hasLat {
+		if lat < -90 || lat > 90 {
+			return opts, invalidParam("latitude", "must be between -90 and 90")
+		}
+		if lon < -180 || lon > 180 {
+			return opts, invalidParam("longitude", "must be between -180 and 180")
+		}
+		opts.Geo = &pb.Geo{GeoPoint: &pb.GeoPoint{Latitude: float32(lat), Longitude: float32(lon)}}
+	}
+
+	if raw, present := args["dataset_id"]; present && raw != nil {
+		id, ok := raw.(string)
+		if !ok || !clarifaiIDPattern.MatchString(id) {
+			return opts, invalidParam("dataset_id", "must be 1-255 letters, digits, '-' or '_'")
+		}
+		opts.DatasetID = id
+	}
+	return opts, nil
+}
+
+// apply sets the options on input. input.Data must not be nil.
+func (o inputOptions) apply(input *pb.Input) {
+	if o.InputID != "" {
+		input.Id = o.InputID
+	}
+	if len(o.Concepts) > 0 {
+		input.Data.Concepts = o.Concepts
+	}
+	if o.Metadata != nil {
+		input.Data.Metadata = o.Metadata
+	}
+	if o.Geo != nil {
+		input.Data.Geo = o.Geo
+	}
+	if o.DatasetID != "" {
+		input.DatasetIds = []string{o.DatasetID}
+	}
+}
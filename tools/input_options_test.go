@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"testing"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInputOptions(t *testing.T) {
+	t.Run("All options", func(t *testing.T) {
+		opts, rpcErr := parseInputOptions(map[string]interface{}{
+			"input_id":          "img-001",
+			"concepts":          []interface{}{"cat", "pet", "cat"},
+			"negative_concepts": "dog, car",
+			"metadata":          map[string]interface{}{"source": "camera-3", "tags": []interface{}{"a", "b"}},
+			"latitude":          52.52,
+			"longitude":         13.405,
+			"dataset_id":        "train_v1",
+		})
+		require.Nil(t, rpcErr)
+
+		input := &pb.Input{Data: &pb.Data{}}
+		opts.apply(input)
+		assert.Equal(t, "img-001", input.Id)
+		assert.Equal(t, []string{"train_v1"}, input.DatasetIds)
+		require.Len(t, input.Data.Concepts, 4, "duplicate positive concepts are dropped")
+		assert.Equal(t, "cat", input.Data.Concepts[0].Id)
+		assert.Equal(t, float32(1), input.Data.Concepts[0].Value)
+		assert.Equal(t, "dog", input.Data.Concepts[2].Id)
+		assert.Equal(t, float32(0), input.Data.Concepts[2].Value)
+		assert.Equal(t, "camera-3", input.Data.Metadata.AsMap()["source"])
+		assert.InDelta(t, 52.52, input.Data.Geo.GeoPoint.Latitude, 0.0001)
+		assert.InDelta(t, 13.405, input.Data.Geo.GeoPoint.Longitude, 0.0001)
+	})
+
+	t.Run("No options leave the input untouched", func(t *testing.T) {
+		opts, rpcErr := parseInputOptions(map[string]interface{}{})
+		require.Nil(t, rpcErr)
+		input := &pb.Input{Data: &pb.Data{}}
+		opts.apply(input)
+		assert.Empty(t, input.Id)
+		assert.Nil(t, input.Data.Concepts)
+		assert.Nil(t, input.Data.Metadata)
+		assert.Nil(t, input.Data.Geo)
+		assert.Nil(t, input.DatasetIds)
+	})
+
+	invalid := []struct {
+		name string
+		args map[string]interface{}
+		msg  string
+	}{
+		{"Input ID with spaces", map[string]interface{}{"input_id": "my input"}, "'input_id'"},
+		{"Invalid concept ID", map[string]interface{}{"concepts": []interface{}{"a/b"}}, "'concepts' contains invalid concept ID"},
+		{"Concept both positive and negative", map[string]interface{}{"concepts": "cat", "negative_concepts": "cat"}, "also a positive concept"},
+		{"Metadata not an object", map[string]interface{}{"metadata": "k=v"}, "'metadata' must be an object"},
+		{"Latitude without longitude", map[string]interface{}{"latitude": 10.0}, "must be given together"},
+		{"Latitude out of range", map[string]interface{}{"latitude": 91.0, "longitude": 0.0}, "'latitude' must be between"},
+		{"Longitude out of range", map[string]interface{}{"latitude": 0.0, "longitude": -181.0}, "'longitude' must be between"},
+		{"Dataset ID not a string", map[string]interface{}{"dataset_id": 5.0}, "'dataset_id'"},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			_, rpcErr := parseInputOptions(tc.args)
+			require.NotNil(t, rpcErr)
+			assert.Equal(t, -32602, rpcErr.Code)
+			assert.Contains(t, rpcErr.Message, tc.msg)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	statuspb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api/status"
+)
+
+// Processing states of an uploaded input.
+const (
+	inputStateProcessed = "processed"
+	inputStateFailed    = "failed"
+	inputStatePending   = "pending" // Still processing when the wait timed out
+)
+
+const (
+	defaultWaitTimeout = 60 * time.Second
+	maxWaitTimeout     = 30 * time.Minute
+	maxInputsPerPoll   = 128
+)
+
+// inputPollInterval is the delay between status polls. Tests shorten it.
+var inputPollInterval = 2 * time.Second
+
+// waitOptions controls whether upload tools wait for Clarifai to finish processing new inputs.
+type waitOptions struct {
+	Enabled bool
+	Timeout time.Duration
+}
+
+// InputStatus is the processing outcome of one uploaded input.
+type InputStatus struct {
+	InputID     string `json:"inputId"`
+	State       string `json:"state"`
+	Code        string `json:"code,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// waitArgumentSchema returns the JSON schema properties of the wait options.
+func waitArgumentSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"wait": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Optional: Wait until Clarifai has processed the new inputs and report each input's final status. Defaults to false.",
+		},
+		"wait_timeout": map[string]interface{}{
+			"type":        "number",
+			"description": fmt.Sprintf("Optional: Seconds to wait for processing (max %d). Inputs still processing afterwards are reported as pending. Defaults to %d.", int(maxWaitTimeout.Seconds()), int(defaultWaitTimeout.Seconds())),
+		},
+	}
+}
+
+// parseWaitOptions reads the wait and wait_timeout arguments.
+func parseWaitOptions(args map[string]interface{}) (waitOptions, *mcp.RPCError) {
+	opts := waitOptions{Timeout: defaultWaitTimeout}
+	enabled, rpcErr := boolArg(args, "wait")
+	if rpcErr != nil {
+		return opts, rpcErr
+	}
+	opts.Enabled = enabled
+	seconds, ok, rpcErr := floatArg(args, "wait_timeout")
+	if rpcErr != nil {
+		return opts, rpcErr
+	}
+	if ok {
+		if seconds <= 0 || seconds > maxWaitTimeout.Seconds() {
+			return opts, invalidParam("wait_timeout", fmt.Sprintf("must be between 0 and %d seconds", int(maxWaitTimeout.Seconds())))
+		}
+		opts.Timeout = time.Duration(seconds * float64(time.Second))
+	}
+	return opts, nil
+}
+
+// waitForInputs polls the status of the given inputs until each one is processed or failed, or
+// the timeout passes. Polling errors are retried until the timeout; inputs whose status is still
+// unknown then are reported as pending with the last error.
+func (h *Handler) waitForInputs(userAppIDSet *pb.UserAppIDSet, ids []string, timeout time.Duration) map[string]InputStatus {
+	statuses := make(map[string]InputStatus, len(ids))
+	pending := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, seen := statuses[id]; !seen && id != "" {
+			statuses[id] = InputStatus{InputID: id, State: inputStatePending}
+			pending = append(pending, id)
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for len(pending) > 0 {
+		var lastErr error
+		for start := 0; start < len(pending); start += maxInputsPerPoll {
+			end := start + maxInputsPerPoll
+			if end > len(pending) {
+				end = len(pending)
+			}
+			inputs, err := h.listInputsByID(userAppIDSet, pending[start:end])
+			if err != nil {
+				h.logger.Warn("Polling input status failed", "error", err)
+				lastErr = err
+				continue
+			}
+			for _, input := range inputs {
+				if _, tracked := statuses[input.GetId()]; tracked {
+					statuses[input.GetId()] = inputStatusFromProto(input)
+				}
+			}
+		}
+
+		stillPending := pending[:0]
+		for _, id := range pending {
+			if statuses[id].State == inputStatePending {
+				stillPending = append(stillPending, id)
+			}
+		}
+		pending = stillPending
+		if len(pending) == 0 {
+			break
+		}
+		if time.Now().Add(inputPollInterval).After(deadline) {
+			for _, id := range pending {
+				status := statuses[id]
+				if lastErr != nil && status.Description == "" {
+					status.Description = fmt.Sprintf("status unknown: %v", lastErr)
+				}
+				statuses[id] = status
+			}
+			h.logger.Debug("Timed out waiting for input processing", "pending", len(pending), "timeout", timeout)
+			break
+		}
+		time.Sleep(inputPollInterval)
+	}
+	return statuses
+}
+
+// listInputsByID runs one status poll with its own timeout.
+func (h *Handler) listInputsByID(userAppIDSet *pb.UserAppIDSet, ids []string) ([]*pb.Input, error) {
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		return nil, fmt.Errorf("%s", rpcErr.Message)
+	}
+	defer cancel()
+	return h.clarifaiClient.ListInputsByID(ctx, userAppIDSet, ids, h.logger)
+}
+
+// inputStatusFromProto maps an input's status code to a processing state.
+func inputStatusFromProto(input *pb.Input) InputStatus {
+	status := InputStatus{
+		InputID:     input.GetId(),
+		Code:        input.GetStatus().GetCode().String(),
+		Description: input.GetStatus().GetDescription(),
+	}
+	if details := input.GetStatus().GetDetails(); details != "" {
+		status.Description += ": " + details
+	}
+	switch input.GetStatus().GetCode() {
+	case statuspb.StatusCode_INPUT_DOWNLOAD_SUCCESS:
+		status.State = inputStateProcessed
+	case statuspb.StatusCode_INPUT_DOWNLOAD_PENDING, statuspb.StatusCode_INPUT_DOWNLOAD_IN_PROGRESS, statuspb.StatusCode_ZERO:
+		status.State = inputStatePending
+	default:
+		status.State = inputStateFailed
+	}
+	return status
+}
+
+// combinedInputState folds the states of several inputs (e.g. the pages of a PDF) into one:
+// failed if any failed, else pending if any is pending, else processed. The description of the
+// first input that decided the state is returned with it.
+func combinedInputState(statuses map[string]InputStatus, ids []string) (string, string) {
+	state, description := inputStateProcessed, ""
+	for _, id := range ids {
+		status, ok := statuses[id]
+		if !ok {
+			status = InputStatus{State: inputStatePending, Description: "input ID unknown"}
+		}
+		switch {
+		case status.State == inputStateFailed && state != inputStateFailed:
+			state, description = inputStateFailed, status.Description
+		case status.State == inputStatePending && state == inputStateProcessed:
+			state, description = inputStatePending, status.Description
+		}
+	}
+	return state, description
+}
+
+// inputStatusSummary counts the final states, e.g. "Processing: 3 processed, 1 failed, 0 pending."
+func inputStatusSummary(statuses map[string]InputStatus, timeout time.Duration) string {
+	counts := map[string]int{}
+	for _, status := range statuses {
+		counts[status.State]++
+	}
+	summary := fmt.Sprintf("Processing: %d processed, %d failed, %d pending.", counts[inputStateProcessed], counts[inputStateFailed], counts[inputStatePending])
+	if counts[inputStatePending] > 0 {
+		summary += fmt.Sprintf(" Pending inputs were still processing after %s.", timeout)
+	}
+	return summary
+}
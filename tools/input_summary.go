@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"time"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+)
+
+// InputSummary is a compact, LLM-friendly view of an input.
+type InputSummary struct {
+	ID               string                 `json:"id"`
+	Type             string                 `json:"type,omitempty"`
+	URL              string                 `json:"url,omitempty"`
+	Concepts         []string               `json:"concepts,omitempty"`
+	NegativeConcepts []string               `json:"negativeConcepts,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+	DatasetIDs       []string               `json:"datasetIds,omitempty"`
+	Status           string                 `json:"status,omitempty"`
+	CreatedAt        string                 `json:"createdAt,omitempty"`
+}
+
+// summarizeInput extracts the fields of input that matter when reviewing or changing it.
+func summarizeInput(input *pb.Input) InputSummary {
+	summary := InputSummary{
+		ID:         input.GetId(),
+		DatasetIDs: input.GetDatasetIds(),
+	}
+	data := input.GetData()
+	switch {
+	case data.GetImage() != nil:
+		summary.Type, summary.URL = "image", data.GetImage().GetUrl()
+	case data.GetVideo() != nil:
+		summary.Type, summary.URL = "video", data.GetVideo().GetUrl()
+	case data.GetAudio() != nil:
+		summary.Type, summary.URL = "audio", data.GetAudio().GetUrl()
+	case data.GetText() != nil:
+		summary.Type, summary.URL = "text", data.GetText().GetUrl()
+	}
+	for _, concept := range data.GetConcepts() {
+		if concept.GetValue() > 0 {
+			summary.Concepts = append(summary.Concepts, concept.GetId())
+		} else {
+			summary.NegativeConcepts = append(summary.NegativeConcepts, concept.GetId())
+		}
+	}
+	if data.GetMetadata() != nil && len(data.GetMetadata().GetFields()) > 0 {
+		summary.Metadata = data.GetMetadata().AsMap()
+	}
+	if input.GetStatus() != nil {
+		summary.Status = input.GetStatus().GetCode().String()
+	}
+	if input.GetCreatedAt() != nil {
+		summary.CreatedAt = input.GetCreatedAt().AsTime().Format(time.RFC3339)
+	}
+	return summary
+}
@@ -0,0 +1,667 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	statuspb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Training and evaluation states of a model version.
+const (
+	modelStateDone    = "done"
+	modelStateFailed  = "failed"
+	modelStatePending = "pending" // Still queued or running when the wait timed out
+)
+
+const defaultModelTypeID = "visual-classifier"
+
+// modelPollInterval is the delay between training and evaluation status polls. Tests shorten it.
+var modelPollInterval = 10 * time.Second
+
+// trainParamKeys are the train_info params the tool sets itself; hyperparameters may not override them.
+var trainParamKeys = []string{"dataset_id", "dataset_version_id", "template"}
+
+// TrainingStatus is the training state of one model version.
+type TrainingStatus struct {
+	ModelID            string `json:"modelId"`
+	VersionID          string `json:"versionId"`
+	State              string `json:"state"`
+	Code               string `json:"code"`
+	Description        string `json:"description,omitempty"`
+	TotalInputCount    uint32 `json:"totalInputCount,omitempty"`
+	ActiveConceptCount uint32 `json:"activeConceptCount,omitempty"`
+	CompletedAt        string `json:"completedAt,omitempty"`
+	TrainLog           string `json:"trainLog,omitempty"`
+}
+
+// EvaluationReport is the state and, once done, the metrics of a model version evaluation.
+type EvaluationReport struct {
+	ModelID      string             `json:"modelId"`
+	VersionID    string             `json:"versionId"`
+	EvaluationID string             `json:"evaluationId"`
+	State        string             `json:"state"`
+	Code         string             `json:"code"`
+	Description  string             `json:"description,omitempty"`
+	Summary      map[string]float32 `json:"summary,omitempty"`
+	Concepts     []ConceptMetrics   `json:"concepts,omitempty"`
+}
+
+// ConceptMetrics are the evaluation metrics of one concept.
+type ConceptMetrics struct {
+	Concept      string  `json:"concept"`
+	Positives    uint32  `json:"positives"`
+	F1           float32 `json:"f1"`
+	RocAuc       float32 `json:"rocAuc"`
+	AvgPrecision float32 `json:"avgPrecision,omitempty"`
+}
+
+// modelVersionArgumentSchema returns the properties identifying a model version.
+func modelVersionArgumentSchema() map[string]interface{} {
+	return mergeProperties(map[string]interface{}{
+		"model_id": map[string]interface{}{
+			"type":        "string",
+			"description": "ID of the model.",
+		},
+		"version_id": map[string]interface{}{
+			"type":        "string",
+			"description": "ID of the model version.",
+		},
+	}, appContextArgumentSchema())
+}
+
+// modelWaitArgumentSchema returns the wait options of the training and evaluation tools.
+func modelWaitArgumentSchema(job string) map[string]interface{} {
+	return map[string]interface{}{
+		"wait": map[string]interface{}{
+			"type":        "boolean",
+			"description": fmt.Sprintf("Optional: Poll the %s status until it finishes and report the final state. Defaults to false.", job),
+		},
+		"wait_timeout": map[string]interface{}{
+			"type":        "number",
+			"description": fmt.Sprintf("Optional: Seconds to wait (max %d). A %s still running afterwards is reported as pending. Defaults to %d.", int(maxWaitTimeout.Seconds()), job, int(defaultWaitTimeout.Seconds())),
+		},
+	}
+}
+
+// createModelArgumentSchema returns the JSON schema properties of create_model.
+func createModelArgumentSchema() map[string]interface{} {
+	return mergeProperties(map[string]interface{}{
+		"model_id": map[string]interface{}{
+			"type":        "string",
+			"description": "ID of the new model.",
+		},
+		"model_type_id": map[string]interface{}{
+			"type":        "string",
+			"description": fmt.Sprintf("Optional: Model type, e.g. 'visual-classifier', 'visual-detector' or 'text-classifier'. Defaults to '%s'.", defaultModelTypeID),
+		},
+		"concepts": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": "Optional: Concept IDs the model predicts. They can also be chosen per version in train_model_version.",
+		},
+		"concepts_mutually_exclusive": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Optional: Whether each input has exactly one of the concepts (single-label classification). Defaults to false.",
+		},
+	}, describedResourceArgumentSchema("model"), appContextArgumentSchema())
+}
+
+// trainModelVersionArgumentSchema returns the JSON schema properties of train_model_version.
+func trainModelVersionArgumentSchema() map[string]interface{} {
+	return mergeProperties(map[string]interface{}{
+		"model_id": map[string]interface{}{
+			"type":        "string",
+			"description": "ID of the model to train a new version of.",
+		},
+		"dataset_id": map[string]interface{}{
+			"type":        "string",
+			"description": "ID of the training dataset.",
+		},
+		"dataset_version_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Frozen dataset version to train on (see create_dataset_version). Clarifai uses the latest version when omitted.",
+		},
+		"template": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Training template of the model type, e.g. 'MMClassification_ResNet_50_RSB_A1'. Clarifai's default template is used when omitted.",
+		},
+		"concepts": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": "Optional: Concept IDs to train on. Defaults to the concepts of the model.",
+		},
+		"concepts_mutually_exclusive": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Optional: Whether each input has exactly one of the concepts. Defaults to false.",
+		},
+		"hyperparameters": map[string]interface{}{
+			"type":        "object",
+			"description": "Optional: Template hyperparameters passed to training as-is, e.g. {\"num_epochs\": 10, \"per_item_lrate\": 0.001, \"image_size\": [320]}.",
+		},
+		"description": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Description of the new version.",
+		},
+	}, modelWaitArgumentSchema("training"), appContextArgumentSchema())
+}
+
+// evaluateModelVersionArgumentSchema returns the JSON schema properties of evaluate_model_version.
+func evaluateModelVersionArgumentSchema() map[string]interface{} {
+	return mergeProperties(map[string]interface{}{
+		"dataset_id": map[string]interface{}{
+			"type":        "string",
+			"description": "ID of the ground truth dataset to evaluate against.",
+		},
+		"dataset_version_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: Version of the ground truth dataset. Clarifai uses the latest version when omitted.",
+		},
+		"evaluation_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Optional: ID of the new evaluation. Generated when omitted.",
+		},
+	}, modelVersionArgumentSchema(), modelWaitArgumentSchema("evaluation"))
+}
+
+// callCreateModel creates an untrained model that versions can then be trained for.
+func (h *Handler) callCreateModel(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callCreateModel tool")
+
+	modelID, rpcErr := idArg(args, "model_id")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	modelTypeID := defaultModelTypeID
+	if raw, present := args["model_type_id"]; present {
+		if modelTypeID, _ = raw.(string); modelTypeID == "" {
+			return nil, invalidParam("model_type_id", "must be a non-empty string")
+		}
+	}
+	outputInfo, rpcErr := conceptsOutputInfo(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	metadata, rpcErr := structArg(args, "metadata")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	description, _ := args["description"].(string)
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":    "create_model",
+		"modelID": modelID,
+		"userID":  userAppIDSet.UserId,
+		"appID":   userAppIDSet.AppId,
+	}
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, rpcErr
+	}
+	defer cancel()
+	model, err := h.clarifaiClient.CreateModel(ctx, userAppIDSet, &pb.Model{
+		Id:          modelID,
+		ModelTypeId: modelTypeID,
+		Description: description,
+		Metadata:    metadata,
+		OutputInfo:  outputInfo,
+	}, h.logger)
+	if err != nil {
+		return nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+	h.logger.Info("Created model", "model_id", model.GetId(), "model_type_id", model.GetModelTypeId())
+	return protoResult(fmt.Sprintf("Created %s model %s. Train a version of it with train_model_version.", model.GetModelTypeId(), model.GetId()), model, errCtx)
+}
+
+// callTrainModelVersion starts training a new model version on a dataset and optionally waits
+// for the training to finish.
+func (h *Handler) callTrainModelVersion(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callTrainModelVersion tool")
+
+	modelID, rpcErr := idArg(args, "model_id")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	datasetID, rpcErr := idArg(args, "dataset_id")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	params, rpcErr := structArg(args, "hyperparameters")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if params == nil {
+		params = &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	}
+	for _, key := range trainParamKeys {
+		if _, set := params.Fields[key]; set {
+			return nil, invalidParam("hyperparameters", fmt.Sprintf("may not set '%s'; use the tool argument instead", key))
+		}
+	}
+	params.Fields["dataset_id"] = structpb.NewStringValue(datasetID)
+	if _, present := args["dataset_version_id"]; present {
+		datasetVersionID, rpcErr := idArg(args, "dataset_version_id")
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		params.Fields["dataset_version_id"] = structpb.NewStringValue(datasetVersionID)
+	}
+	if raw, present := args["template"]; present {
+		template, _ := raw.(string)
+		if template == "" {
+			return nil, invalidParam("template", "must be a non-empty string")
+		}
+		params.Fields["template"] = structpb.NewStringValue(template)
+	}
+	outputInfo, rpcErr := conceptsOutputInfo(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	wait, rpcErr := parseWaitOptions(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	description, _ := args["description"].(string)
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":      "train_model_version",
+		"modelID":   modelID,
+		"datasetID": datasetID,
+		"userID":    userAppIDSet.UserId,
+		"appID":     userAppIDSet.AppId,
+	}
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, rpcErr
+	}
+	version, err := h.clarifaiClient.TrainModelVersion(ctx, userAppIDSet, modelID, &pb.ModelVersion{
+		Description: description,
+		OutputInfo:  outputInfo,
+		TrainInfo:   &pb.TrainInfo{Params: params},
+	}, h.logger)
+	cancel()
+	if err != nil {
+		return nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+	h.logger.Info("Started training model version", "model_id", modelID, "version_id", version.GetId(), "status", version.GetStatus().GetCode())
+
+	status := trainingStatusFromProto(modelID, version)
+	if wait.Enabled && status.State == modelStatePending {
+		status = h.waitForTraining(userAppIDSet, modelID, version.GetId(), wait.Timeout)
+	}
+	return trainingStatusResult(status, wait)
+}
+
+// callGetTrainingStatus reports the training status of a model version, or of one of its
+// evaluations, optionally polling until it finishes.
+func (h *Handler) callGetTrainingStatus(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callGetTrainingStatus tool")
+
+	modelID, rpcErr := idArg(args, "model_id")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	versionID, rpcErr := idArg(args, "version_id")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	evaluationID := ""
+	if _, present := args["evaluation_id"]; present {
+		if evaluationID, rpcErr = idArg(args, "evaluation_id"); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	wait, rpcErr := parseWaitOptions(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":      "get_training_status",
+		"modelID":   modelID,
+		"versionID": versionID,
+		"userID":    userAppIDSet.UserId,
+		"appID":     userAppIDSet.AppId,
+	}
+	if evaluationID != "" {
+		evaluation, err := h.getEvaluation(userAppIDSet, modelID, versionID, evaluationID)
+		if err != nil {
+			return nil, utils.HandleApiError(err, errCtx, h.logger)
+		}
+		report := evaluationReportFromProto(modelID, versionID, evaluation)
+		if wait.Enabled && report.State == modelStatePending {
+			report = h.waitForEvaluation(userAppIDSet, modelID, versionID, evaluationID, wait.Timeout)
+		}
+		return evaluationResult(report, wait)
+	}
+	version, err := h.getModelVersion(userAppIDSet, modelID, versionID)
+	if err != nil {
+		return nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+	status := trainingStatusFromProto(modelID, version)
+	if wait.Enabled && status.State == modelStatePending {
+		status = h.waitForTraining(userAppIDSet, modelID, versionID, wait.Timeout)
+	}
+	return trainingStatusResult(status, wait)
+}
+
+// callEvaluateModelVersion starts evaluating a trained model version against a ground truth
+// dataset and optionally waits for the metrics.
+func (h *Handler) callEvaluateModelVersion(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callEvaluateModelVersion tool")
+
+	modelID, rpcErr := idArg(args, "model_id")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	versionID, rpcErr := idArg(args, "version_id")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	datasetID, rpcErr := idArg(args, "dataset_id")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	dataset := &pb.Dataset{Id: datasetID}
+	if _, present := args["dataset_version_id"]; present {
+		datasetVersionID, rpcErr := idArg(args, "dataset_version_id")
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		dataset.Version = &pb.DatasetVersion{Id: datasetVersionID}
+	}
+	evaluationID := ""
+	if _, present := args["evaluation_id"]; present {
+		if evaluationID, rpcErr = idArg(args, "evaluation_id"); rpcErr != nil {
+			return nil, rpcErr
+		}
+	}
+	wait, rpcErr := parseWaitOptions(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":      "evaluate_model_version",
+		"modelID":   modelID,
+		"versionID": versionID,
+		"datasetID": datasetID,
+		"userID":    userAppIDSet.UserId,
+		"appID":     userAppIDSet.AppId,
+	}
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, rpcErr
+	}
+	evaluation, err := h.clarifaiClient.EvaluateModelVersion(ctx, userAppIDSet, modelID, versionID, &pb.EvalMetrics{Id: evaluationID, GroundTruthDataset: dataset}, h.logger)
+	cancel()
+	if err != nil {
+		return nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+	h.logger.Info("Started model version evaluation", "model_id", modelID, "version_id", versionID, "evaluation_id", evaluation.GetId())
+
+	report := evaluationReportFromProto(modelID, versionID, evaluation)
+	if wait.Enabled && report.State == modelStatePending {
+		report = h.waitForEvaluation(userAppIDSet, modelID, versionID, evaluation.GetId(), wait.Timeout)
+	}
+
+	return evaluationResult(report, wait)
+}
+
+// conceptsOutputInfo builds the output info selecting the concepts and label mode of a model
+// or model version. It returns nil when neither argument is given.
+func conceptsOutputInfo(args map[string]interface{}) (*pb.OutputInfo, *mcp.RPCError) {
+	concepts, rpcErr := stringListArg(args, "concepts")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	exclusive, rpcErr := boolArg(args, "concepts_mutually_exclusive")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if len(concepts) == 0 && !exclusive {
+		return nil, nil
+	}
+	outputInfo := &pb.OutputInfo{
+		Data:         &pb.Data{},
+		OutputConfig: &pb.OutputConfig{ConceptsMutuallyExclusive: exclusive},
+	}
+	for _, concept := range concepts {
+		if !clarifaiIDPattern.MatchString(concept) {
+			return nil, invalidParam("concepts", fmt.Sprintf("contains invalid concept ID %q", concept))
+		}
+		outputInfo.Data.Concepts = append(outputInfo.Data.Concepts, &pb.Concept{Id: concept})
+	}
+	return outputInfo, nil
+}
+
+// waitForTraining polls a model version until its training finishes or the timeout passes,
+// logging each status change. Polling errors are retried until the timeout; the version is
+// then reported as pending with the last error.
+func (h *Handler) waitForTraining(userAppIDSet *pb.UserAppIDSet, modelID, versionID string, timeout time.Duration) TrainingStatus {
+	status := TrainingStatus{ModelID: modelID, VersionID: versionID, State: modelStatePending}
+	deadline := time.Now().Add(timeout)
+	for {
+		version, err := h.getModelVersion(userAppIDSet, modelID, versionID)
+		if err != nil {
+			h.logger.Warn("Polling training status failed", "model_id", modelID, "version_id", versionID, "error", err)
+			status.Description = fmt.Sprintf("status unknown: %v", err)
+		} else {
+			previous := status.Code
+			status = trainingStatusFromProto(modelID, version)
+			if status.Code != previous {
+				h.logger.Info("Training status", "model_id", modelID, "version_id", versionID, "code", status.Code, "description", status.Description)
+			}
+		}
+		if status.State != modelStatePending || time.Now().Add(modelPollInterval).After(deadline) {
+			return status
+		}
+		time.Sleep(modelPollInterval)
+	}
+}
+
+// waitForEvaluation polls an evaluation until it finishes or the timeout passes, like waitForTraining.
+func (h *Handler) waitForEvaluation(userAppIDSet *pb.UserAppIDSet, modelID, versionID, evaluationID string, timeout time.Duration) EvaluationReport {
+	report := EvaluationReport{ModelID: modelID, VersionID: versionID, EvaluationID: evaluationID, State: modelStatePending}
+	deadline := time.Now().Add(timeout)
+	for {
+		evaluation, err := h.getEvaluation(userAppIDSet, modelID, versionID, evaluationID)
+		if err != nil {
+			h.logger.Warn("Polling evaluation status failed", "model_id", modelID, "evaluation_id", evaluationID, "error", err)
+			report.Description = fmt.Sprintf("status unknown: %v", err)
+		} else {
+			previous := report.Code
+			report = evaluationReportFromProto(modelID, versionID, evaluation)
+			if report.Code != previous {
+				h.logger.Info("Evaluation status", "model_id", modelID, "evaluation_id", evaluationID, "code", report.Code)
+			}
+		}
+		if report.State != modelStatePending || time.Now().Add(modelPollInterval).After(deadline) {
+			return report
+		}
+		time.Sleep(modelPollInterval)
+	}
+}
+
+// getModelVersion runs one training status poll with its own timeout.
+func (h *Handler) getModelVersion(userAppIDSet *pb.UserAppIDSet, modelID, versionID string) (*pb.ModelVersion, error) {
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		return nil, fmt.Errorf("%s", rpcErr.Message)
+	}
+	defer cancel()
+	return h.clarifaiClient.GetModelVersion(ctx, userAppIDSet, modelID, versionID, h.logger)
+}
+
+// getEvaluation runs one evaluation status poll with its own timeout.
+func (h *Handler) getEvaluation(userAppIDSet *pb.UserAppIDSet, modelID, versionID, evaluationID string) (*pb.EvalMetrics, error) {
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		return nil, fmt.Errorf("%s", rpcErr.Message)
+	}
+	defer cancel()
+	return h.clarifaiClient.GetModelVersionEvaluation(ctx, userAppIDSet, modelID, versionID, evaluationID, h.logger)
+}
+
+// trainingStatusFromProto maps a model version's status code to a training state.
+func trainingStatusFromProto(modelID string, version *pb.ModelVersion) TrainingStatus {
+	status := TrainingStatus{
+		ModelID:            modelID,
+		VersionID:          version.GetId(),
+		Code:               version.GetStatus().GetCode().String(),
+		Description:        statusDescription(version.GetStatus()),
+		TotalInputCount:    version.GetTotalInputCount(),
+		ActiveConceptCount: version.GetActiveConceptCount(),
+		TrainLog:           version.GetTrainLog(),
+	}
+	if version.GetCompletedAt() != nil {
+		status.CompletedAt = version.GetCompletedAt().AsTime().Format(time.RFC3339)
+	}
+	switch version.GetStatus().GetCode() {
+	case statuspb.StatusCode_MODEL_TRAINED:
+		status.State = modelStateDone
+	case statuspb.StatusCode_ZERO, statuspb.StatusCode_MODEL_UNTRAINED, statuspb.StatusCode_MODEL_QUEUED_FOR_TRAINING,
+		statuspb.StatusCode_MODEL_TRAINING, statuspb.StatusCode_MODEL_UPLOADING, statuspb.StatusCode_MODEL_BUILDING:
+		status.State = modelStatePending
+	default:
+		status.State = modelStateFailed
+	}
+	return status
+}
+
+// evaluationReportFromProto maps an evaluation's status code to a state and collects its metrics.
+func evaluationReportFromProto(modelID, versionID string, evaluation *pb.EvalMetrics) EvaluationReport {
+	report := EvaluationReport{
+		ModelID:      modelID,
+		VersionID:    versionID,
+		EvaluationID: evaluation.GetId(),
+		Code:         evaluation.GetStatus().GetCode().String(),
+		Description:  statusDescription(evaluation.GetStatus()),
+	}
+	switch evaluation.GetStatus().GetCode() {
+	case statuspb.StatusCode_MODEL_EVALUATED:
+		report.State = modelStateDone
+	case statuspb.StatusCode_ZERO, statuspb.StatusCode_MODEL_NOT_EVALUATED, statuspb.StatusCode_MODEL_QUEUED_FOR_EVALUATION,
+		statuspb.StatusCode_MODEL_EVALUATING:
+		report.State = modelStatePending
+	default:
+		report.State = modelStateFailed
+	}
+	if summary := evaluation.GetSummary(); summary != nil {
+		report.Summary = map[string]float32{}
+		for name, value := range map[string]float32{
+			"top1Accuracy":       summary.GetTop1Accuracy(),
+			"macroAvgF1":         summary.GetMacroAvgF1Score(),
+			"macroAvgPrecision":  summary.GetMacroAvgPrecision(),
+			"macroAvgRecall":     summary.GetMacroAvgRecall(),
+			"macroAvgRocAuc":     summary.GetMacroAvgRocAuc(),
+			"meanAvgPrecision50": summary.GetMeanAvgPrecisionIou_50(),
+		} {
+			if value != 0 {
+				report.Summary[name] = value
+			}
+		}
+	}
+	for _, metrics := range evaluation.GetMetricsByClass() {
+		report.Concepts = append(report.Concepts, ConceptMetrics{
+			Concept:      metrics.GetConcept().GetId(),
+			Positives:    metrics.GetNumPos(),
+			F1:           metrics.GetF1(),
+			RocAuc:       metrics.GetRocAuc(),
+			AvgPrecision: metrics.GetAvgPrecision(),
+		})
+	}
+	sort.Slice(report.Concepts, func(i, j int) bool { return report.Concepts[i].Concept < report.Concepts[j].Concept })
+	return report
+}
+
+// statusDescription joins a status's description and details.
+func statusDescription(status *statuspb.Status) string {
+	description := status.GetDescription()
+	if details := status.GetDetails(); details != "" {
+		description += ": " + details
+	}
+	return description
+}
+
+// trainingStatusResult returns a summary line plus the training status as JSON.
+func trainingStatusResult(status TrainingStatus, wait waitOptions) (interface{}, *mcp.RPCError) {
+	summary := fmt.Sprintf("Model %s version %s: %s (%s).", status.ModelID, status.VersionID, status.State, status.Code)
+	switch {
+	case status.State == modelStateFailed && status.Description != "":
+		summary += " " + status.Description
+	case status.State == modelStatePending && wait.Enabled:
+		summary += fmt.Sprintf(" Still training after %s; check again with get_training_status.", wait.Timeout)
+	case status.State == modelStatePending:
+		summary += " Check progress with get_training_status."
+	}
+	statusJSON, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to encode training status: %v", err)}
+	}
+	return map[string]interface{}{
+		"content": []map[string]any{
+			{"type": "text", "text": summary},
+			{"type": "text", "text": string(statusJSON)},
+		},
+	}, nil
+}
+
+// evaluationResult returns a summary line plus the evaluation report as JSON.
+func evaluationResult(report EvaluationReport, wait waitOptions) (interface{}, *mcp.RPCError) {
+	summary := fmt.Sprintf("Evaluation %s of model %s version %s: %s (%s).", report.EvaluationID, report.ModelID, report.VersionID, report.State, report.Code)
+	switch {
+	case report.State == modelStateDone:
+		summary += " " + evaluationMetricsSummary(report)
+	case report.State == modelStateFailed && report.Description != "":
+		summary += " " + report.Description
+	case report.State == modelStatePending && wait.Enabled:
+		summary += fmt.Sprintf(" Still running after %s; check again with get_training_status and evaluation_id.", wait.Timeout)
+	case report.State == modelStatePending:
+		summary += " Check progress with get_training_status and evaluation_id."
+	}
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to encode evaluation report: %v", err)}
+	}
+	return map[string]interface{}{
+		"content": []map[string]any{
+			{"type": "text", "text": summary},
+			{"type": "text", "text": string(reportJSON)},
+		},
+	}, nil
+}
+
+// evaluationMetricsSummary lists the summary metrics of a finished evaluation, e.g.
+// "macroAvgF1 0.912, top1Accuracy 0.950.".
+func evaluationMetricsSummary(report EvaluationReport) string {
+	names := make([]string, 0, len(report.Summary))
+	for name := range report.Summary {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %.3f", name, report.Summary[name])
+	}
+	if len(parts) == 0 {
+		return "No summary metrics were reported."
+	}
+	return strings.Join(parts, ", ") + "."
+}
@@ -0,0 +1,302 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"clarifai-mcp-server-local/mcp"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	formatSummary = "summary"
+	formatRaw     = "raw"
+
+	defaultTopK = 10
+)
+
+// formatOptions controls how inference results are rendered back to the client.
+type formatOptions struct {
+	MinConfidence float32
+	TopK          int // 0 means no limit
+	Format        string
+}
+
+// formatArgumentSchema returns the JSON schema properties shared by tools that return model outputs.
+func formatArgumentSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"min_confidence": map[string]interface{}{
+			"type":        "number",
+			"description": "Optional: Drop concepts and regions with confidence below this value (0-1). Defaults to 0.",
+		},
+		"top_k": map[string]interface{}{
+			"type":        "integer",
+			"description": fmt.Sprintf("Optional: Maximum number of concepts and regions to return per output. Defaults to %d, 0 returns all.", defaultTopK),
+		},
+		"format": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{formatSummary, formatRaw},
+			"description": "Optional: 'summary' (default) returns condensed concepts, regions, text and embedding stats; 'raw' returns the full API response.",
+		},
+	}
+}
+
+// parseFormatOptions reads min_confidence, top_k and format from tool arguments.
+func parseFormatOptions(args map[string]interface{}) (formatOptions, *mcp.RPCError) {
+	opts := formatOptions{TopK: defaultTopK, Format: formatSummary}
+
+	minConfidence, ok, rpcErr := floatArg(args, "min_confidence")
+	if rpcErr != nil {
+		return opts, rpcErr
+	}
+	if ok {
+		if minConfidence < 0 || minConfidence > 1 {
+			return opts, invalidParam("min_confidence", "must be between 0 and 1")
+		}
+		opts.MinConfidence = float32(minConfidence)
+	}
+
+	topK, ok, rpcErr := intArg(args, "top_k")
+	if rpcErr != nil {
+		return opts, rpcErr
+	}
+	if ok {
+		if topK < 0 {
+			return opts, invalidParam("top_k", "must not be negative")
+		}
+		opts.TopK = topK
+	}
+
+	if format, ok := args["format"].(string); ok && format != "" {
+		if format != formatSummary && format != formatRaw {
+			return opts, invalidParam("format", "must be 'summary' or 'raw'")
+		}
+		opts.Format = format
+	}
+	return opts, nil
+}
+
+// InferenceSummary is the condensed view of a PostModelOutputs response.
+type InferenceSummary struct {
+	ModelID string          `json:"modelId,omitempty"`
+	Outputs []OutputSummary `json:"outputs"`
+}
+
+// OutputSummary holds the useful parts of a single model output.
+type OutputSummary struct {
+	InputID    string             `json:"inputId,omitempty"`
+	Concepts   []ConceptSummary   `json:"concepts,omitempty"`
+	Regions    []RegionSummary    `json:"regions,omitempty"`
+	Text       string             `json:"text,omitempty"`
+	Embeddings []EmbeddingSummary `json:"embeddings,omitempty"`
+}
+
+// ConceptSummary is a concept name with its confidence.
+type ConceptSummary struct {
+	Name       string  `json:"name"`
+	Confidence float32 `json:"confidence"`
+}
+
+// BoundingBoxSummary holds normalized (0-1) box coordinates.
+type BoundingBoxSummary struct {
+	Top    float32 `json:"top"`
+	Left   float32 `json:"left"`
+	Bottom float32 `json:"bottom"`
+	Right  float32 `json:"right"`
+}
+
+// RegionSummary describes a detected region by its best label and location.
+type RegionSummary struct {
+	ID          string              `json:"id,omitempty"`
+	Label       string              `json:"label,omitempty"`
+	Confidence  float32             `json:"confidence"`
+	BoundingBox *BoundingBoxSummary `json:"boundingBox,omitempty"`
+	Text        string              `json:"text,omitempty"`
+}
+
+// EmbeddingSummary reports statistics about an embedding vector instead of the vector itself.
+type EmbeddingSummary struct {
+	Dimensions int     `json:"dimensions"`
+	Norm       float64 `json:"norm"`
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+	Mean       float64 `json:"mean"`
+}
+
+// formatInferenceResponse renders a PostModelOutputs response according to opts.
+func formatInferenceResponse(resp *pb.MultiOutputResponse, opts formatOptions) (string, error) {
+	if opts.Format == formatRaw {
+		m := protojson.MarshalOptions{Indent: "  ", EmitUnpopulated: true}
+		rawResponseJSON, err := m.Marshal(resp)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal raw API response: %w", err)
+		}
+		return string(rawResponseJSON), nil
+	}
+
+	summaryJSON, err := json.MarshalIndent(summarizeOutputs(resp, opts), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal inference summary: %w", err)
+	}
+	return string(summaryJSON), nil
+}
+
+// summarizeOutputs condenses every output in the response.
+func summarizeOutputs(resp *pb.MultiOutputResponse, opts formatOptions) InferenceSummary {
+	summary := InferenceSummary{Outputs: make([]OutputSummary, 0, len(resp.GetOutputs()))}
+	for _, output := range resp.GetOutputs() {
+		if summary.ModelID == "" && output.GetModel() != nil {
+			summary.ModelID = output.GetModel().GetId()
+		}
+		summary.Outputs = append(summary.Outputs, summarizeOutput(output, opts))
+	}
+	return summary
+}
+
+// summarizeOutput extracts concepts, regions, text and embedding stats from one output.
+func summarizeOutput(output *pb.Output, opts formatOptions) OutputSummary {
+	data := output.GetData()
+	summary := OutputSummary{
+		InputID:  output.GetInput().GetId(),
+		Concepts: summarizeConcepts(data.GetConcepts(), opts),
+		Text:     data.GetText().GetRaw(),
+	}
+
+	for _, region := range selectRegions(data.GetRegions(), opts) {
+		summary.Regions = append(summary.Regions, summarizeRegion(region))
+	}
+
+	// OCR models return text per region; expose the full text at output level too,
+	// including regions that were filtered out above.
+	if summary.Text == "" {
+		var regionTexts []string
+		for _, region := range data.GetRegions() {
+			if text := region.GetData().GetText().GetRaw(); text != "" {
+				regionTexts = append(regionTexts, text)
+			}
+		}
+		summary.Text = strings.Join(regionTexts, "\n")
+	}
+
+	for _, embedding := range data.GetEmbeddings() {
+		summary.Embeddings = append(summary.Embeddings, summarizeEmbedding(embedding))
+	}
+	return summary
+}
+
+// summarizeConcepts filters by confidence, sorts descending and truncates to top_k.
+func summarizeConcepts(concepts []*pb.Concept, opts formatOptions) []ConceptSummary {
+	result := make([]ConceptSummary, 0, len(concepts))
+	for _, c := range concepts {
+		if c.GetValue() < opts.MinConfidence {
+			continue
+		}
+		result = append(result, ConceptSummary{Name: conceptName(c), Confidence: c.GetValue()})
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Confidence > result[j].Confidence })
+	if opts.TopK > 0 && len(result) > opts.TopK {
+		result = result[:opts.TopK]
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// selectRegions filters regions by confidence, sorts them descending and truncates to top_k.
+// Both the summary and the annotated image use it so they always show the same regions.
+func selectRegions(regions []*pb.Region, opts formatOptions) []*pb.Region {
+	selected := make([]*pb.Region, 0, len(regions))
+	for _, region := range regions {
+		if regionConfidence(region) < opts.MinConfidence {
+			continue
+		}
+		selected = append(selected, region)
+	}
+	sort.SliceStable(selected, func(i, j int) bool { return regionConfidence(selected[i]) > regionConfidence(selected[j]) })
+	if opts.TopK > 0 && len(selected) > opts.TopK {
+		selected = selected[:opts.TopK]
+	}
+	return selected
+}
+
+// topRegionConcept returns the highest-confidence concept of a region, or nil.
+func topRegionConcept(region *pb.Region) *pb.Concept {
+	var best *pb.Concept
+	for _, c := range region.GetData().GetConcepts() {
+		if best == nil || c.GetValue() > best.GetValue() {
+			best = c
+		}
+	}
+	return best
+}
+
+// regionConfidence uses the region value when set and the top concept value otherwise.
+func regionConfidence(region *pb.Region) float32 {
+	if region.GetValue() != 0 {
+		return region.GetValue()
+	}
+	return topRegionConcept(region).GetValue()
+}
+
+// regionLabel is the name of the top concept of a region.
+func regionLabel(region *pb.Region) string {
+	if best := topRegionConcept(region); best != nil {
+		return conceptName(best)
+	}
+	return ""
+}
+
+// summarizeRegion picks the highest-confidence concept as the region label.
+func summarizeRegion(region *pb.Region) RegionSummary {
+	rs := RegionSummary{
+		ID:         region.GetId(),
+		Label:      regionLabel(region),
+		Confidence: regionConfidence(region),
+		Text:       region.GetData().GetText().GetRaw(),
+	}
+	if box := region.GetRegionInfo().GetBoundingBox(); box != nil {
+		rs.BoundingBox = &BoundingBoxSummary{
+			Top:    box.GetTopRow(),
+			Left:   box.GetLeftCol(),
+			Bottom: box.GetBottomRow(),
+			Right:  box.GetRightCol(),
+		}
+	}
+	return rs
+}
+
+// summarizeEmbedding computes simple statistics over an embedding vector.
+func summarizeEmbedding(embedding *pb.Embedding) EmbeddingSummary {
+	vector := embedding.GetVector()
+	es := EmbeddingSummary{Dimensions: len(vector)}
+	if es.Dimensions == 0 {
+		es.Dimensions = int(embedding.GetNumDimensions())
+		return es
+	}
+	es.Min, es.Max = math.Inf(1), math.Inf(-1)
+	var sum, sumSquares float64
+	for _, v := range vector {
+		f := float64(v)
+		sum += f
+		sumSquares += f * f
+		es.Min = math.Min(es.Min, f)
+		es.Max = math.Max(es.Max, f)
+	}
+	es.Mean = sum / float64(len(vector))
+	es.Norm = math.Sqrt(sumSquares)
+	return es
+}
+
+// conceptName prefers the human-readable name and falls back to the concept ID.
+func conceptName(c *pb.Concept) string {
+	if c.GetName() != "" {
+		return c.GetName()
+	}
+	return c.GetId()
+}
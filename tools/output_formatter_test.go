@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormatOptions(t *testing.T) {
+	testCases := []struct {
+		name        string
+		args        map[string]interface{}
+		expected    formatOptions
+		expectedErr string
+	}{
+		{
+			name:     "Defaults",
+			args:     map[string]interface{}{},
+			expected: formatOptions{TopK: defaultTopK, Format: formatSummary},
+		},
+		{
+			name:     "All options",
+			args:     map[string]interface{}{"min_confidence": 0.5, "top_k": float64(3), "format": "raw"},
+			expected: formatOptions{MinConfidence: 0.5, TopK: 3, Format: formatRaw},
+		},
+		{
+			name:        "Confidence out of range",
+			args:        map[string]interface{}{"min_confidence": 1.5},
+			expectedErr: "'min_confidence' must be between 0 and 1",
+		},
+		{
+			name:        "Fractional top_k",
+			args:        map[string]interface{}{"top_k": 2.5},
+			expectedErr: "'top_k' must be an integer",
+		},
+		{
+			name:        "Unknown format",
+			args:        map[string]interface{}{"format": "xml"},
+			expectedErr: "'format' must be 'summary' or 'raw'",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts, rpcErr := parseFormatOptions(tc.args)
+			if tc.expectedErr != "" {
+				require.NotNil(t, rpcErr)
+				assert.Equal(t, -32602, rpcErr.Code)
+				assert.Contains(t, rpcErr.Message, tc.expectedErr)
+				return
+			}
+			assert.Nil(t, rpcErr)
+			assert.Equal(t, tc.expected, opts)
+		})
+	}
+}
+
+func TestSummarizeOutputs(t *testing.T) {
+	resp := &pb.MultiOutputResponse{
+		Status: successStatus(),
+		Outputs: []*pb.Output{{
+			Model: &pb.Model{Id: "general-image-detection"},
+			Input: &pb.Input{Id: "input-1"},
+			Data: &pb.Data{
+				Concepts: []*pb.Concept{
+					{Id: "c1", Name: "cat", Value: 0.6},
+					{Id: "c2", Name: "animal", Value: 0.9},
+					{Id: "c3", Value: 0.2}, // No name, falls back to ID
+				},
+				Regions: []*pb.Region{
+					{
+						Id:         "r1",
+						RegionInfo: &pb.RegionInfo{BoundingBox: &pb.BoundingBox{TopRow: 0.1, LeftCol: 0.2, BottomRow: 0.5, RightCol: 0.6}},
+						Data:       &pb.Data{Concepts: []*pb.Concept{{Name: "dog", Value: 0.3}, {Name: "cat", Value: 0.8}}},
+					},
+					{
+						Id:    "r2",
+						Value: 0.4,
+						Data:  &pb.Data{Text: &pb.Text{Raw: "STOP"}},
+					},
+				},
+				Embeddings: []*pb.Embedding{{Vector: []float32{3, -4}}},
+			},
+		}},
+	}
+
+	t.Run("Top-k and sorting", func(t *testing.T) {
+		summary := summarizeOutputs(resp, formatOptions{TopK: 2, Format: formatSummary})
+		assert.Equal(t, "general-image-detection", summary.ModelID)
+		require.Len(t, summary.Outputs, 1)
+		out := summary.Outputs[0]
+		assert.Equal(t, "input-1", out.InputID)
+		assert.Equal(t, []ConceptSummary{{Name: "animal", Confidence: 0.9}, {Name: "cat", Confidence: 0.6}}, out.Concepts)
+		require.Len(t, out.Regions, 2)
+		assert.Equal(t, "cat", out.Regions[0].Label)
+		assert.Equal(t, float32(0.8), out.Regions[0].Confidence)
+		assert.Equal(t, &BoundingBoxSummary{Top: 0.1, Left: 0.2, Bottom: 0.5, Right: 0.6}, out.Regions[0].BoundingBox)
+		assert.Equal(t, "STOP", out.Text, "OCR region text should be lifted to output text")
+		require.Len(t, out.Embeddings, 1)
+		assert.Equal(t, EmbeddingSummary{Dimensions: 2, Norm: 5, Min: -4, Max: 3, Mean: -0.5}, out.Embeddings[0])
+	})
+
+	t.Run("Min confidence", func(t *testing.T) {
+		summary := summarizeOutputs(resp, formatOptions{MinConfidence: 0.5, Format: formatSummary})
+		out := summary.Outputs[0]
+		assert.Len(t, out.Concepts, 2)
+		require.Len(t, out.Regions, 1)
+		assert.Equal(t, "r1", out.Regions[0].ID)
+		assert.Equal(t, "STOP", out.Text, "filtered regions still contribute OCR text")
+	})
+
+	t.Run("Name falls back to ID", func(t *testing.T) {
+		summary := summarizeOutputs(resp, formatOptions{Format: formatSummary})
+		assert.Equal(t, "c3", summary.Outputs[0].Concepts[2].Name)
+	})
+}
+
+func TestFormatInferenceResponse(t *testing.T) {
+	resp := &pb.MultiOutputResponse{
+		Status:  successStatus(),
+		Outputs: []*pb.Output{{Data: &pb.Data{Concepts: []*pb.Concept{{Name: "cat", Value: 0.9}}}}},
+	}
+
+	t.Run("Summary", func(t *testing.T) {
+		text, err := formatInferenceResponse(resp, formatOptions{Format: formatSummary})
+		require.NoError(t, err)
+		var summary InferenceSummary
+		require.NoError(t, json.Unmarshal([]byte(text), &summary))
+		assert.Equal(t, "cat", summary.Outputs[0].Concepts[0].Name)
+		assert.NotContains(t, text, "status")
+	})
+
+	t.Run("Raw", func(t *testing.T) {
+		text, err := formatInferenceResponse(resp, formatOptions{Format: formatRaw})
+		require.NoError(t, err)
+		assert.Contains(t, text, `"status"`)
+		assert.Contains(t, text, `"cat"`)
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"strings"
 
+	"clarifai-mcp-server-local/clarifai"
 	"clarifai-mcp-server-local/mcp"
 	"clarifai-mcp-server-local/utils"
 
@@ -65,7 +66,7 @@ var resourceTemplates = []map[string]interface{}{
 	{
 		"uriTemplate": "clarifai://{user_id}/{app_id}/inputs?query={search_term}",
 		"name":        "Search Clarifai Inputs",
-		"description": "Search for inputs within a specific Clarifai app using a query string. Supports pagination.",
+		"description": "Search for inputs within a specific Clarifai app. The query combines terms such as 'concept:cat', 'NOT concept:dog', 'metadata.source=web', 'dataset:train', 'status:processed', 'geo:within(lat,lon,10km)' and free text. Supports pagination.",
 		"mimeType":    "application/json",
 	},
 	{
@@ -83,7 +84,7 @@ var resourceTemplates = []map[string]interface{}{
 	{
 		"uriTemplate": "clarifai://{user_id}/{app_id}/annotations?query={search_term}",
 		"name":        "Search Clarifai Annotations",
-		"description": "Search for annotations within a specific Clarifai app using a query string. Supports pagination.",
+		"description": "Search for annotations within a specific Clarifai app, using the same query syntax as input searches. Supports pagination.",
 		"mimeType":    "application/json",
 	},
 	{
@@ -310,12 +311,22 @@ func (h *Handler) handleListResource(request mcp.JSONRPCRequest, userID, appID,
 		"query":        query,
 	}
 
+	// Inputs and annotations take the structured search syntax of clarifai.ParseQuery.
+	var searchQuery *pb.Query
+	if query != "" && (resourceType == "inputs" || resourceType == "annotations") {
+		parsed, err := clarifai.ParseQuery(query)
+		if err != nil {
+			return mcp.NewErrorResponse(request.ID, -32602, "Invalid params: "+err.Error(), errCtx)
+		}
+		searchQuery = parsed
+	}
+
 	switch resourceType {
 	case "inputs":
 		if parentType != "" {
 			apiErr = fmt.Errorf("listing inputs as sub-resource is not supported")
 		} else {
-			results, nextCursor, apiErr = h.clarifaiClient.ListInputs(ctx, userAppIDSet, pagination, query, h.logger)
+			results, nextCursor, apiErr = h.clarifaiClient.ListInputs(ctx, userAppIDSet, pagination, searchQuery, h.logger)
 		}
 	case "models":
 		if parentType != "" {
@@ -327,6 +338,8 @@ func (h *Handler) handleListResource(request mcp.JSONRPCRequest, userID, appID,
 		if parentType == "inputs" && parentID != "" {
 			// TODO: Implement ListAnnotations for a specific input
 			apiErr = fmt.Errorf("ListAnnotations for Input not yet implemented")
+		} else if parentType == "" && searchQuery != nil {
+			results, nextCursor, apiErr = h.clarifaiClient.SearchAnnotations(ctx, userAppIDSet, pagination, searchQuery, h.logger)
 		} else if parentType == "" {
 			// TODO: Implement ListAnnotations for an app
 			apiErr = fmt.Errorf("ListAnnotations not yet implemented")
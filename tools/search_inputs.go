@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"clarifai-mcp-server-local/clarifai"
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+)
+
+const (
+	defaultSearchPerPage = 20
+	maxSearchPerPage     = 100
+)
+
+// querySyntaxDescription summarises the clarifai.ParseQuery syntax for tool schemas.
+const querySyntaxDescription = "Terms are combined with AND: 'concept:cat', 'metadata.source=web' (nested keys with dots), 'dataset:train', " +
+	"'status:processed' (or pending, in_progress, failed), 'geo:within(lat,lon,10km)', 'geo:box(lat1,lon1,lat2,lon2)', " +
+	"'~concept:cat' (rank by concept) and free text (rank by text similarity). 'NOT' or '-' negates a term; quote values with spaces."
+
+// callSearchInputs runs a structured search over the app's inputs.
+func (h *Handler) callSearchInputs(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callSearchInputs tool")
+
+	rawQuery, ok := args["query"].(string)
+	if !ok || rawQuery == "" {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: missing or invalid 'query'"}
+	}
+	query, err := clarifai.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: " + err.Error()}
+	}
+	page, perPage := 1, defaultSearchPerPage
+	if value, ok, rpcErr := intArg(args, "page"); rpcErr != nil {
+		return nil, rpcErr
+	} else if ok {
+		if value < 1 {
+			return nil, invalidParam("page", "must be at least 1")
+		}
+		page = value
+	}
+	if value, ok, rpcErr := intArg(args, "per_page"); rpcErr != nil {
+		return nil, rpcErr
+	} else if ok {
+		if value < 1 || value > maxSearchPerPage {
+			return nil, invalidParam("per_page", fmt.Sprintf("must be between 1 and %d", maxSearchPerPage))
+		}
+		perPage = value
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":   "search_inputs",
+		"query":  rawQuery,
+		"userID": userAppIDSet.UserId,
+		"appID":  userAppIDSet.AppId,
+	}
+
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, rpcErr
+	}
+	defer cancel()
+	hits, err := h.clarifaiClient.SearchInputHits(ctx, userAppIDSet, query, &pb.Pagination{Page: uint32(page), PerPage: uint32(perPage)}, h.logger)
+	if err != nil {
+		return nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+
+	noun := "matching input(s)"
+	if len(hits) == perPage {
+		noun += fmt.Sprintf(" on page %d; more may follow on page %d", page, page+1)
+	}
+	return inputMatchesResult(hits, (page-1)*perPage+1, userAppIDSet, noun, errCtx)
+}
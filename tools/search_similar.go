@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+)
+
+const (
+	defaultSimilarTopK = 10
+	maxSimilarTopK     = 100
+)
+
+// InputMatch is one hit of an input search.
+type InputMatch struct {
+	Rank  int     `json:"rank"`
+	Score float32 `json:"score"`
+	URI   string  `json:"uri,omitempty"` // Resource URI for resources/read
+	InputSummary
+}
+
+// searchSimilarArgumentSchema returns the JSON schema properties of search_similar_images.
+func searchSimilarArgumentSchema() map[string]interface{} {
+	return mergeProperties(map[string]interface{}{
+		"filepath": map[string]interface{}{
+			"type":        "string",
+			"description": "Absolute path to a local query image. Give either this or 'image_url'.",
+		},
+		"image_url": map[string]interface{}{
+			"type":        "string",
+			"description": "URL of the query image. Give either this or 'filepath'.",
+		},
+		"top_k": map[string]interface{}{
+			"type":        "integer",
+			"description": fmt.Sprintf("Optional: Number of matches to return (max %d). Defaults to %d.", maxSimilarTopK, defaultSimilarTopK),
+		},
+		"min_score": map[string]interface{}{
+			"type":        "number",
+			"description": "Optional: Drop matches scoring below this similarity (0-1).",
+		},
+	}, inputFilterArgumentSchema(), appContextArgumentSchema())
+}
+
+// callSearchSimilarImages ranks the app's inputs by visual similarity to a query image. The
+// optional concept, metadata and dataset filters restrict the inputs that are ranked.
+func (h *Handler) callSearchSimilarImages(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callSearchSimilarImages tool")
+
+	filePath, _ := args["filepath"].(string)
+	imageURL, _ := args["image_url"].(string)
+	if (filePath == "") == (imageURL == "") {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: give either 'filepath' or 'image_url'"}
+	}
+	if imageURL != "" {
+		if parsed, err := url.Parse(imageURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, invalidParam("image_url", "must be an http(s) URL")
+		}
+	}
+	topK := defaultSimilarTopK
+	if value, ok, rpcErr := intArg(args, "top_k"); rpcErr != nil {
+		return nil, rpcErr
+	} else if ok {
+		if value < 1 || value > maxSimilarTopK {
+			return nil, invalidParam("top_k", fmt.Sprintf("must be between 1 and %d", maxSimilarTopK))
+		}
+		topK = value
+	}
+	minScore, hasMinScore, rpcErr := floatArg(args, "min_score")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if hasMinScore && (minScore < 0 || minScore > 1) {
+		return nil, invalidParam("min_score", "must be between 0 and 1")
+	}
+	filters, rpcErr := parseInputFilters(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":   "search_similar_images",
+		"userID": userAppIDSet.UserId,
+		"appID":  userAppIDSet.AppId,
+	}
+
+	image := &pb.Image{Url: imageURL}
+	if filePath != "" {
+		errCtx["filepath"] = filePath
+		imageBytes, err := os.ReadFile(filePath)
+		if err != nil {
+			h.logger.Error("Failed to read image file", "filepath", filePath, "error", err)
+			return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to read image file: %v", err), Data: errCtx}
+		}
+		image = &pb.Image{Base64: imageBytes}
+	} else {
+		errCtx["imageURL"] = imageURL
+	}
+	query := &pb.Query{
+		Ranks:   []*pb.Rank{{Annotation: &pb.Annotation{Data: &pb.Data{Image: image}}}},
+		Filters: filters,
+	}
+
+	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+	if rpcErr != nil {
+		rpcErr.Data = errCtx
+		return nil, rpcErr
+	}
+	defer cancel()
+	hits, err := h.clarifaiClient.SearchInputHits(ctx, userAppIDSet, query, &pb.Pagination{Page: 1, PerPage: uint32(topK)}, h.logger)
+	if err != nil {
+		return nil, utils.HandleApiError(err, errCtx, h.logger)
+	}
+
+	if hasMinScore {
+		kept := hits[:0]
+		for _, hit := range hits {
+			if float64(hit.GetScore()) >= minScore {
+				kept = append(kept, hit)
+			}
+		}
+		hits = kept
+	}
+	return inputMatchesResult(hits, 1, userAppIDSet, "similar input(s)", errCtx)
+}
+
+// inputMatchesResult formats search hits as a count line plus the JSON list of matches, ranked
+// from firstRank.
+func inputMatchesResult(hits []*pb.Hit, firstRank int, userAppIDSet *pb.UserAppIDSet, noun string, errCtx map[string]string) (interface{}, *mcp.RPCError) {
+	matches := make([]InputMatch, 0, len(hits))
+	for i, hit := range hits {
+		match := InputMatch{Rank: firstRank + i, Score: hit.GetScore(), InputSummary: summarizeInput(hit.GetInput())}
+		if userAppIDSet.UserId != "" && userAppIDSet.AppId != "" {
+			match.URI = fmt.Sprintf("clarifai://%s/%s/inputs/%s", userAppIDSet.UserId, userAppIDSet.AppId, match.ID)
+		}
+		matches = append(matches, match)
+	}
+
+	matchesJSON, err := json.MarshalIndent(matches, "", "  ")
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to marshal matches: %v", err), Data: errCtx}
+	}
+	return map[string]interface{}{
+		"content": []map[string]any{
+			{"type": "text", "text": fmt.Sprintf("Found %d %s.", len(matches), noun)},
+			{"type": "text", "text": string(matchesJSON)},
+		},
+	}, nil
+}
@@ -2,8 +2,11 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"clarifai-mcp-server-local/clarifai"
 	"clarifai-mcp-server-local/mcp"
@@ -12,6 +15,7 @@ import (
 	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
 	statuspb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api/status"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // Updated toolsDefinitionMap (Moved from handler.go)
@@ -20,7 +24,7 @@ var toolsDefinitionMap = map[string]interface{}{
 		"description": "Performs inference on a local image file using a specified or default Clarifai model. Defaults to 'general-image-detection' model if none specified.",
 		"inputSchema": map[string]interface{}{
 			"type": "object",
-			"properties": map[string]interface{}{
+			"properties": mergeProperties(map[string]interface{}{
 				"filepath": map[string]interface{}{
 					"type":        "string",
 					"description": "Absolute path to the local image file.",
@@ -37,7 +41,7 @@ var toolsDefinitionMap = map[string]interface{}{
 					"type":        "string",
 					"description": "Optional: User ID context. Defaults to the user associated with the PAT.",
 				},
-			},
+			}, formatArgumentSchema(), annotateArgumentSchema()),
 			"required": []string{"filepath"},
 		},
 	},
@@ -45,7 +49,7 @@ var toolsDefinitionMap = map[string]interface{}{
 		"description": "Performs inference on an image URL using a specified or default Clarifai model.",
 		"inputSchema": map[string]interface{}{
 			"type": "object",
-			"properties": map[string]interface{}{
+			"properties": mergeProperties(map[string]interface{}{
 				"image_url": map[string]interface{}{
 					"type":        "string",
 					"description": "URL of the image file.",
@@ -62,7 +66,7 @@ var toolsDefinitionMap = map[string]interface{}{
 					"type":        "string",
 					"description": "Optional: User ID context. Defaults to the user associated with the PAT.",
 				},
-			},
+			}, formatArgumentSchema(), annotateArgumentSchema()),
 			"required": []string{"image_url"},
 		},
 	},
@@ -70,7 +74,7 @@ var toolsDefinitionMap = map[string]interface{}{
 		"description": "Generates an image based on a text prompt using a specified or default Clarifai text-to-image model. Requires the server to be started with a valid --pat flag.",
 		"inputSchema": map[string]interface{}{
 			"type": "object",
-			"properties": map[string]interface{}{
+			"properties": mergeProperties(map[string]interface{}{
 				"text_prompt": map[string]interface{}{
 					"type":        "string",
 					"description": "Text prompt describing the desired image.",
@@ -87,15 +91,15 @@ var toolsDefinitionMap = map[string]interface{}{
 					"type":        "string",
 					"description": "Optional: User ID context. Defaults to the user associated with the PAT.",
 				},
-			},
+			}, generationArgumentSchema(), imageOutputArgumentSchema()),
 			"required": []string{"text_prompt"},
 		},
 	},
 	"upload_file": map[string]interface{}{
-		"description": "Uploads a local file to Clarifai as an input.",
+		"description": "Uploads a local file to Clarifai as an input, optionally with a custom ID, concept labels, metadata, geo location and dataset.",
 		"inputSchema": map[string]interface{}{
 			"type": "object",
-			"properties": map[string]interface{}{
+			"properties": mergeProperties(map[string]interface{}{
 				"filepath": map[string]interface{}{
 					"type":        "string",
 					"description": "Absolute path to the local file to upload.",
@@ -108,11 +112,384 @@ var toolsDefinitionMap = map[string]interface{}{
 					"type":        "string",
 					"description": "Optional: User ID context. Defaults to the user associated with the PAT.",
 				},
-				// TODO: Add optional input_id, concepts, metadata, geo?
+			}, inputArgumentSchema(), waitArgumentSchema()),
+			"required": []string{"filepath"},
+		},
+	},
+	"upload_urls": map[string]interface{}{
+		"description": "Creates Clarifai inputs from remote http(s) URLs, which Clarifai fetches itself. The input type follows the URL's extension and defaults to image.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": mergeProperties(map[string]interface{}{
+				"urls": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "URLs of the images, text, audio or video files to add.",
+				},
+			}, remoteUploadArgumentSchema(), inputArgumentSchema(), waitArgumentSchema()),
+			"required": []string{"urls"},
+		},
+	},
+	"upload_manifest": map[string]interface{}{
+		"description": "Uploads the inputs listed in a CSV or JSONL manifest. Each row has a url or a local path and optionally input_id, concepts, negative_concepts, metadata (JSON object) and dataset_id. Invalid rows are reported and nothing is uploaded until all rows are valid.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": mergeProperties(map[string]interface{}{
+				"manifest_path": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the manifest. Relative paths inside it are resolved against its directory.",
+				},
+				"format": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{manifestFormatCSV, manifestFormatJSONL},
+					"description": "Optional: Manifest format. Defaults to the file extension (.csv, .jsonl or .ndjson).",
+				},
+			}, remoteUploadArgumentSchema(), sharedInputArgumentSchema(), waitArgumentSchema()),
+			"required": []string{"manifest_path"},
+		},
+	},
+	"bulk_upload": map[string]interface{}{
+		"description": "Uploads every supported file in a local directory to Clarifai in concurrent batches. Progress is kept in a manifest so a rerun resumes where an interrupted or partly failed run stopped. Reports the input IDs and failures per file.",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": bulkUploadArgumentSchema(),
+			"required":   []string{"directory"},
+		},
+	},
+	"search_inputs": map[string]interface{}{
+		"description": "Searches the inputs of a Clarifai app with a structured query that combines concept, metadata, dataset, status and geo filters with concept or text ranking. Returns the matches with scores and resource URIs.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": mergeProperties(map[string]interface{}{
+				"page": map[string]interface{}{
+					"type":        "integer",
+					"description": "Optional: Page of results. Defaults to 1.",
+				},
+				"per_page": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Optional: Results per page (max %d). Defaults to %d.", maxSearchPerPage, defaultSearchPerPage),
+				},
+			}, inputQueryArgumentSchema("Search query."), appContextArgumentSchema()),
+			"required": []string{"query"},
+		},
+	},
+	"search_similar_images": map[string]interface{}{
+		"description": "Finds the inputs in a Clarifai app that look most like a query image (a local file or URL), optionally restricted by concepts, metadata and dataset. Returns the matches with similarity scores and resource URIs.",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": searchSimilarArgumentSchema(),
+		},
+	},
+	"delete_inputs": map[string]interface{}{
+		"description": "Deletes inputs from a Clarifai app, given by ID or selected by concepts, metadata, dataset and/or a search query. The first call only previews the inputs that would be deleted and returns a confirm_token; call again with the same arguments plus that token to delete them.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": mergeProperties(map[string]interface{}{
+				"input_ids": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Optional: IDs of the inputs to delete. Give either this or a filter.",
+				},
+				"max_inputs": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Optional: Refuse to delete more than this many inputs (max %d). Defaults to %d.", maxDeleteMaxInputs, defaultDeleteMaxInputs),
+				},
+			}, inputFilterArgumentSchema(), inputQueryArgumentSchema("Optional: Select inputs with a search query; only filter terms are allowed."), confirmationArgumentSchema(), appContextArgumentSchema()),
+		},
+	},
+	"patch_input": map[string]interface{}{
+		"description": "Changes the concepts and metadata of an existing input. The first call previews the input before and after the change and returns a confirm_token; call again with the same arguments plus that token to apply it.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": mergeProperties(map[string]interface{}{
+				"input_id": map[string]interface{}{
+					"type":        "string",
+					"description": "ID of the input to change.",
+				},
+				"action": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{patchActionMerge, patchActionOverwrite, patchActionRemove},
+					"description": "Optional: 'merge' adds concepts and merges metadata keys, 'overwrite' replaces the given fields, 'remove' deletes the given concepts and metadata keys. Defaults to 'merge'.",
+				},
+				"concepts":          inputArgumentSchema()["concepts"],
+				"negative_concepts": inputArgumentSchema()["negative_concepts"],
+				"metadata":          inputArgumentSchema()["metadata"],
+			}, confirmationArgumentSchema(), appContextArgumentSchema()),
+			"required": []string{"input_id"},
+		},
+	},
+	"create_annotation": map[string]interface{}{
+		"description": "Labels an input in a Clarifai app with concepts, either as a whole or within a bounding box, polygon or text span. Region coordinates are normalized to 0-1.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": mergeProperties(map[string]interface{}{
+				"input_id": map[string]interface{}{
+					"type":        "string",
+					"description": "ID of the input to annotate.",
+				},
+				"annotation_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: ID for the new annotation. Clarifai generates one if omitted.",
+				},
+			}, annotationArgumentSchema(), appContextArgumentSchema()),
+			"required": []string{"input_id"},
+		},
+	},
+	"update_annotation": map[string]interface{}{
+		"description": "Changes the concepts or region of an existing annotation.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": mergeProperties(map[string]interface{}{
+				"input_id": map[string]interface{}{
+					"type":        "string",
+					"description": "ID of the annotated input.",
+				},
+				"annotation_id": map[string]interface{}{
+					"type":        "string",
+					"description": "ID of the annotation to change.",
+				},
+				"action": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{patchActionMerge, patchActionOverwrite, patchActionRemove},
+					"description": "Optional: 'overwrite' replaces the annotation's data, 'merge' adds the given concepts, 'remove' deletes them. Defaults to 'overwrite'.",
+				},
+			}, annotationArgumentSchema(), appContextArgumentSchema()),
+			"required": []string{"input_id", "annotation_id"},
+		},
+	},
+	"delete_annotation": map[string]interface{}{
+		"description": "Deletes one annotation of an input.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": mergeProperties(map[string]interface{}{
+				"input_id": map[string]interface{}{
+					"type":        "string",
+					"description": "ID of the annotated input.",
+				},
+				"annotation_id": map[string]interface{}{
+					"type":        "string",
+					"description": "ID of the annotation to delete.",
+				},
+			}, appContextArgumentSchema()),
+			"required": []string{"input_id", "annotation_id"},
+		},
+	},
+	"auto_annotate": map[string]interface{}{
+		"description": "Pre-labels inputs (by IDs, dataset or search query) with a model's predictions: every concept or region predicted above a confidence threshold becomes an annotation, by default awaiting review. Reports the annotations created per concept.",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": autoAnnotateArgumentSchema(),
+		},
+	},
+	"export_dataset": map[string]interface{}{
+		"description": "Exports the images and bounding box annotations of an app, dataset or search to a local COCO, YOLO or Pascal VOC dataset for local training. Long exports can be split with max_pages and resumed.",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": exportDatasetArgumentSchema(),
+			"required":   []string{"format"},
+		},
+	},
+	"import_dataset": map[string]interface{}{
+		"description": "Imports a local COCO, YOLO or Pascal VOC detection dataset into an app: uploads the images as inputs, creates a bounding box annotation per label and optionally adds the inputs to a dataset. Malformed labels are reported before anything is uploaded.",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": importDatasetArgumentSchema(),
+			"required":   []string{"path"},
+		},
+	},
+	"create_dataset": map[string]interface{}{
+		"description": "Creates an empty dataset in an app. Inputs are then added with add_inputs_to_dataset and frozen with create_dataset_version.",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": mergeProperties(datasetArgumentSchema(), describedResourceArgumentSchema("dataset")),
+			"required":   []string{"dataset_id"},
+		},
+	},
+	"add_inputs_to_dataset": map[string]interface{}{
+		"description": "Adds existing inputs to a dataset, either listed by ID or selected by a search query of filter terms (for example 'concept:cat status:processed').",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": mergeProperties(map[string]interface{}{
+				"input_ids": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "IDs of the inputs to add. Use either this or 'query'.",
+				},
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "Search query selecting the inputs to add. Only filter terms are allowed. Use either this or 'input_ids'.",
+				},
+			}, datasetArgumentSchema()),
+			"required": []string{"dataset_id"},
+		},
+	},
+	"remove_inputs_from_dataset": map[string]interface{}{
+		"description": "Removes inputs from a dataset. The inputs stay in the app.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": mergeProperties(map[string]interface{}{
+				"input_ids": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "IDs of the inputs to remove.",
+				},
+			}, datasetArgumentSchema()),
+			"required": []string{"dataset_id", "input_ids"},
+		},
+	},
+	"create_dataset_version": map[string]interface{}{
+		"description": "Freezes the current inputs and annotations of a dataset as a new dataset version for training and evaluation. The version is built asynchronously; its status is returned.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": mergeProperties(map[string]interface{}{
+				"version_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: ID of the new version. Generated when omitted.",
+				},
+			}, datasetArgumentSchema(), describedResourceArgumentSchema("dataset version")),
+			"required": []string{"dataset_id"},
+		},
+	},
+	"create_model": map[string]interface{}{
+		"description": "Creates an untrained model, by default a visual classifier, to fine-tune with train_model_version.",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": createModelArgumentSchema(),
+			"required":   []string{"model_id"},
+		},
+	},
+	"train_model_version": map[string]interface{}{
+		"description": "Trains a new version of a model on a dataset version with a chosen template, concepts and hyperparameters. Training runs in the background; set wait to poll until it finishes.",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": trainModelVersionArgumentSchema(),
+			"required":   []string{"model_id", "dataset_id"},
+		},
+	},
+	"get_training_status": map[string]interface{}{
+		"description": "Reports the training status of a model version, or the status and metrics of one of its evaluations when evaluation_id is given. Set wait to poll until it finishes.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": mergeProperties(map[string]interface{}{
+				"evaluation_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: Report this evaluation of the version instead of its training.",
+				},
+			}, modelVersionArgumentSchema(), modelWaitArgumentSchema("training or evaluation")),
+			"required": []string{"model_id", "version_id"},
+		},
+	},
+	"evaluate_model_version": map[string]interface{}{
+		"description": "Evaluates a trained model version against a ground truth dataset and reports accuracy, F1, precision, recall and per-concept metrics. Evaluation runs in the background; set wait to poll until it finishes.",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": evaluateModelVersionArgumentSchema(),
+			"required":   []string{"model_id", "version_id", "dataset_id"},
+		},
+	},
+	"compare_models": map[string]interface{}{
+		"description": "Runs the same image or text through several models concurrently and returns a side-by-side table of their top predictions and latencies, saving a full JSON report. Use it to choose a model for a task.",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": compareModelsArgumentSchema(),
+			"required":   []string{"model_ids"},
+		},
+	},
+	"edit_image": map[string]interface{}{
+		"description": "Edits a local image with an image-to-image or inpainting Clarifai model guided by a text prompt. An optional mask image marks the area to repaint.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": mergeProperties(map[string]interface{}{
+				"filepath": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the local source image.",
+				},
+				"text_prompt": map[string]interface{}{
+					"type":        "string",
+					"description": "Text prompt describing the desired edit.",
+				},
+				"model_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Image-to-image or inpainting model ID.",
+				},
+				"mask_filepath": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: Absolute path to a mask image; white areas are repainted.",
+				},
+				"app_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: App ID context. Defaults to the app associated with the PAT.",
+				},
+				"user_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: User ID context. Defaults to the user associated with the PAT.",
+				},
+			}, generationArgumentSchema(), imageOutputArgumentSchema()),
+			"required": []string{"filepath", "text_prompt", "model_id"},
+		},
+	},
+	"crop_regions": map[string]interface{}{
+		"description": "Runs a detection model on a local image and saves every detected region as a separate image file, plus a JSON manifest linking each crop to the source image and bounding box.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"filepath": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the local image file.",
+				},
+				"model_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: Detection model ID to use. Defaults to 'general-image-detection' if omitted.",
+				},
+				"concepts": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Optional: Only crop regions labelled with one of these concept names or IDs.",
+				},
+				"min_confidence": map[string]interface{}{
+					"type":        "number",
+					"description": "Optional: Only crop regions whose concept confidence is at least this value (0-1). Defaults to 0.",
+				},
+				"output_dir": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: Directory for the crops and manifest. Defaults to a new 'crops_<image>_<timestamp>' directory under the output path.",
+				},
+				"app_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: App ID context. Defaults to the app associated with the PAT.",
+				},
+				"user_id": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: User ID context. Defaults to the user associated with the PAT.",
+				},
 			},
 			"required": []string{"filepath"},
 		},
 	},
+	"cleanup_outputs": map[string]interface{}{
+		"description": "Applies the output retention policy to the server's output path: deletes the oldest saved images (and their metadata sidecars) beyond the configured age, count or size limits. Runs as a dry run unless dry_run is false.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"dry_run": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Optional: Only report what would be deleted. Defaults to true.",
+				},
+				"max_age": map[string]interface{}{
+					"type":        "string",
+					"description": "Optional: Delete outputs older than this Go duration, e.g. '72h'. Defaults to -output-max-age.",
+				},
+				"max_files": map[string]interface{}{
+					"type":        "integer",
+					"description": "Optional: Keep at most this many outputs. Defaults to -output-max-files.",
+				},
+				"max_mb": map[string]interface{}{
+					"type":        "number",
+					"description": "Optional: Keep at most this many megabytes of outputs. Defaults to -output-max-mb.",
+				},
+			},
+		},
+	},
 }
 
 // handleListTools lists the available tools. (Moved from handler.go)
@@ -136,6 +513,14 @@ func (h *Handler) handleCallTool(request mcp.JSONRPCRequest) mcp.JSONRPCResponse
 	var toolResult interface{}
 	var toolError *mcp.RPCError
 
+	if h.config != nil && h.config.ReadOnly && mutatingTools[request.Params.Name] {
+		return mcp.JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error:   &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Tool '%s' changes Clarifai data and is disabled because the server runs with -read-only", request.Params.Name)},
+		}
+	}
+
 	switch request.Params.Name {
 	case "clarifai_image_by_path":
 		toolResult, toolError = h.callClarifaiImageByPath(request.Params.Arguments)
@@ -145,6 +530,56 @@ func (h *Handler) handleCallTool(request mcp.JSONRPCRequest) mcp.JSONRPCResponse
 		toolResult, toolError = h.callGenerateImage(request.Params.Arguments)
 	case "upload_file":
 		toolResult, toolError = h.callUploadFile(request.Params.Arguments)
+	case "upload_urls":
+		toolResult, toolError = h.callUploadURLs(request.Params.Arguments)
+	case "upload_manifest":
+		toolResult, toolError = h.callUploadManifest(request.Params.Arguments)
+	case "bulk_upload":
+		toolResult, toolError = h.callBulkUpload(request.Params.Arguments)
+	case "search_inputs":
+		toolResult, toolError = h.callSearchInputs(request.Params.Arguments)
+	case "search_similar_images":
+		toolResult, toolError = h.callSearchSimilarImages(request.Params.Arguments)
+	case "delete_inputs":
+		toolResult, toolError = h.callDeleteInputs(request.Params.Arguments)
+	case "patch_input":
+		toolResult, toolError = h.callPatchInput(request.Params.Arguments)
+	case "create_annotation":
+		toolResult, toolError = h.callCreateAnnotation(request.Params.Arguments)
+	case "update_annotation":
+		toolResult, toolError = h.callUpdateAnnotation(request.Params.Arguments)
+	case "delete_annotation":
+		toolResult, toolError = h.callDeleteAnnotation(request.Params.Arguments)
+	case "auto_annotate":
+		toolResult, toolError = h.callAutoAnnotate(request.Params.Arguments)
+	case "export_dataset":
+		toolResult, toolError = h.callExportDataset(request.Params.Arguments)
+	case "import_dataset":
+		toolResult, toolError = h.callImportDataset(request.Params.Arguments)
+	case "create_dataset":
+		toolResult, toolError = h.callCreateDataset(request.Params.Arguments)
+	case "add_inputs_to_dataset":
+		toolResult, toolError = h.callAddInputsToDataset(request.Params.Arguments)
+	case "remove_inputs_from_dataset":
+		toolResult, toolError = h.callRemoveInputsFromDataset(request.Params.Arguments)
+	case "create_dataset_version":
+		toolResult, toolError = h.callCreateDatasetVersion(request.Params.Arguments)
+	case "create_model":
+		toolResult, toolError = h.callCreateModel(request.Params.Arguments)
+	case "train_model_version":
+		toolResult, toolError = h.callTrainModelVersion(request.Params.Arguments)
+	case "get_training_status":
+		toolResult, toolError = h.callGetTrainingStatus(request.Params.Arguments)
+	case "evaluate_model_version":
+		toolResult, toolError = h.callEvaluateModelVersion(request.Params.Arguments)
+	case "compare_models":
+		toolResult, toolError = h.callCompareModels(request.Params.Arguments)
+	case "edit_image":
+		toolResult, toolError = h.callEditImage(request.Params.Arguments)
+	case "crop_regions":
+		toolResult, toolError = h.callCropRegions(request.Params.Arguments)
+	case "cleanup_outputs":
+		toolResult, toolError = h.callCleanupOutputs(request.Params.Arguments)
 	default:
 		toolError = &mcp.RPCError{Code: -32601, Message: "Tool not found: " + request.Params.Name}
 	}
@@ -157,6 +592,33 @@ func (h *Handler) handleCallTool(request mcp.JSONRPCRequest) mcp.JSONRPCResponse
 	}
 }
 
+// resolveDetectionModelIDs fills in defaults for image inference tools: the public
+// general-image-detection model when no model is given, then the configured user/app.
+func (h *Handler) resolveDetectionModelIDs(userID, appID, modelID string) (string, string, string) {
+	if modelID == "" {
+		modelID = "general-image-detection" // Default model
+		h.logger.Debug("No model_id provided, defaulting", "model_id", modelID)
+	}
+
+	// Special handling for general-image-detection model
+	if modelID == "general-image-detection" && userID == "" && appID == "" {
+		userID = "clarifai"
+		appID = "main"
+		h.logger.Debug("Using default user/app for general-image-detection", "user_id", userID, "app_id", appID)
+	}
+
+	// Use configured defaults if args are empty
+	if userID == "" {
+		userID = h.config.DefaultUserID
+		h.logger.Debug("Using default user ID from config", "user_id", userID)
+	}
+	if appID == "" {
+		appID = h.config.DefaultAppID
+		h.logger.Debug("Using default app ID from config", "app_id", appID)
+	}
+	return userID, appID, modelID
+}
+
 // callClarifaiImageByPath handles inference requests using a local file path. (Moved from handler.go)
 func (h *Handler) callClarifaiImageByPath(args map[string]interface{}) (interface{}, *mcp.RPCError) {
 	h.logger.Debug("Executing callClarifaiImageByPath tool")
@@ -170,32 +632,17 @@ func (h *Handler) callClarifaiImageByPath(args map[string]interface{}) (interfac
 	userID, _ := args["user_id"].(string)
 	appID, _ := args["app_id"].(string)
 
-	// Determine effective user/app/model IDs
-	effectiveUserID := userID
-	effectiveAppID := appID
-	effectiveModelID := modelID
-
-	if effectiveModelID == "" {
-		effectiveModelID = "general-image-detection" // Default model
-		h.logger.Debug("No model_id provided, defaulting", "model_id", effectiveModelID)
+	formatOpts, rpcErr := parseFormatOptions(args)
+	if rpcErr != nil {
+		return nil, rpcErr
 	}
-
-	// Special handling for general-image-detection model
-	if effectiveModelID == "general-image-detection" && effectiveUserID == "" && effectiveAppID == "" {
-		effectiveUserID = "clarifai"
-		effectiveAppID = "main"
-		h.logger.Debug("Using default user/app for general-image-detection", "user_id", effectiveUserID, "app_id", effectiveAppID)
+	annotate, rpcErr := boolArg(args, "annotate")
+	if rpcErr != nil {
+		return nil, rpcErr
 	}
 
-	// Use configured defaults if args are empty
-	if effectiveUserID == "" {
-		effectiveUserID = h.config.DefaultUserID
-		h.logger.Debug("Using default user ID from config", "user_id", effectiveUserID)
-	}
-	if effectiveAppID == "" {
-		effectiveAppID = h.config.DefaultAppID
-		h.logger.Debug("Using default app ID from config", "app_id", effectiveAppID)
-	}
+	// Determine effective user/app/model IDs
+	effectiveUserID, effectiveAppID, effectiveModelID := h.resolveDetectionModelIDs(userID, appID, modelID)
 
 	// Prepare error context map
 	errCtx := map[string]string{
@@ -247,21 +694,27 @@ func (h *Handler) callClarifaiImageByPath(args map[string]interface{}) (interfac
 		return nil, utils.HandleApiError(apiErr, errCtx, h.logger)
 	}
 
-	// Marshal the raw response to JSON
-	m := protojson.MarshalOptions{Indent: "  ", EmitUnpopulated: true}
-	rawResponseJSON, marshalErr := m.Marshal(resp)
-	if marshalErr != nil {
-		h.logger.Error("Failed to marshal raw API response", "error", marshalErr)
-		apiErr := fmt.Errorf("failed to marshal raw API response: %w", marshalErr)
-		return nil, utils.HandleApiError(apiErr, errCtx, h.logger)
+	resultText, formatErr := formatInferenceResponse(resp, formatOpts)
+	if formatErr != nil {
+		h.logger.Error("Failed to format inference response", "error", formatErr)
+		return nil, utils.HandleApiError(formatErr, errCtx, h.logger)
 	}
 
-	h.logger.Debug("Inference successful (by path), returning raw response.")
+	h.logger.Debug("Inference successful (by path), returning formatted response.", "format", formatOpts.Format)
+
+	content := []map[string]any{
+		{"type": "text", "text": resultText},
+	}
+	if annotate {
+		annotatedContent, annotateErr := h.annotatedImageContent(imageBytes, resp, formatOpts, errCtx)
+		if annotateErr != nil {
+			return nil, annotateErr
+		}
+		content = append(content, annotatedContent...)
+	}
 
 	toolResult := map[string]interface{}{
-		"content": []map[string]any{
-			{"type": "text", "text": string(rawResponseJSON)}, // Return raw JSON response
-		},
+		"content": content,
 	}
 	return toolResult, nil
 }
@@ -278,6 +731,15 @@ func (h *Handler) callUploadFile(args map[string]interface{}) (interface{}, *mcp
 	userID, _ := args["user_id"].(string)
 	appID, _ := args["app_id"].(string)
 
+	inputOpts, rpcErr := parseInputOptions(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	waitOpts, rpcErr := parseWaitOptions(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
 	// Determine effective user/app IDs
 	effectiveUserID := userID
 	effectiveAppID := appID
@@ -294,10 +756,12 @@ func (h *Handler) callUploadFile(args map[string]interface{}) (interface{}, *mcp
 
 	// Prepare error context map
 	errCtx := map[string]string{
-		"tool":     "upload_file",
-		"filepath": filepath,
-		"userID":   effectiveUserID,
-		"appID":    effectiveAppID,
+		"tool":      "upload_file",
+		"filepath":  filepath,
+		"userID":    effectiveUserID,
+		"appID":     effectiveAppID,
+		"inputID":   inputOpts.InputID,
+		"datasetID": inputOpts.DatasetID,
 	}
 
 	// Read file content
@@ -309,14 +773,18 @@ func (h *Handler) callUploadFile(args map[string]interface{}) (interface{}, *mcp
 
 	h.logger.Debug("Read file", "filepath", filepath, "original_size", len(fileBytes))
 
-	// Prepare input proto - Assuming image for now.
-	// Pass raw file bytes directly, matching e2e tests. gRPC library handles encoding.
-	inputData := &pb.Input{
-		Data: &pb.Data{
-			Image: &pb.Image{Base64: fileBytes},
-		},
-		// TODO: Add optional fields like ID, concepts, metadata, geo here if provided in args
+	// Pick the input data type from the file content. Raw file bytes are passed directly,
+	// matching e2e tests. gRPC library handles encoding.
+	built, err := buildFileInputs(filepath, fileBytes, inputOpts)
+	if err != nil {
+		h.logger.Error("Cannot upload file", "filepath", filepath, "error", err)
+		if errors.Is(err, utils.ErrUnsupportedContent) {
+			return nil, &mcp.RPCError{Code: -32602, Message: fmt.Sprintf("Invalid params: %v", err), Data: errCtx}
+		}
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to prepare upload: %v", err), Data: errCtx}
 	}
+	errCtx["contentKind"] = built.Kind
+	h.logger.Debug("Prepared upload inputs", "kind", built.Kind, "mime_type", built.MIMEType, "inputs", len(built.Inputs))
 
 	userAppIDSet := &pb.UserAppIDSet{UserId: effectiveUserID, AppId: effectiveAppID}
 
@@ -328,7 +796,7 @@ func (h *Handler) callUploadFile(args map[string]interface{}) (interface{}, *mcp
 	defer cancel()
 
 	h.logger.Debug("Making gRPC call to PostInputs (upload)", "timeout", h.timeoutSec, "user_id", effectiveUserID, "app_id", effectiveAppID)
-	resp, err := h.clarifaiClient.PostInputs(ctx, userAppIDSet, []*pb.Input{inputData}, h.logger) // Use the new API wrapper
+	resp, err := h.clarifaiClient.PostInputs(ctx, userAppIDSet, built.Inputs, h.logger) // Use the new API wrapper
 	h.logger.Debug("gRPC call to PostInputs (upload) finished.")
 
 	if err != nil {
@@ -347,6 +815,27 @@ func (h *Handler) callUploadFile(args map[string]interface{}) (interface{}, *mcp
 	h.logger.Debug("File upload successful.")
 
 	resultText := "File uploaded successfully."
+	if waitOpts.Enabled {
+		ids := createdInputIDs(built.Inputs, resp)
+		statuses := h.waitForInputs(userAppIDSet, ids, waitOpts.Timeout)
+		state, _ := combinedInputState(statuses, ids)
+		resultText = fmt.Sprintf("File uploaded; input processing %s.\n%s", state, inputStatusSummary(statuses, waitOpts.Timeout))
+		for _, id := range ids {
+			status := statuses[id]
+			resultText += fmt.Sprintf("\nInput %s: %s", id, status.State)
+			if status.Description != "" && status.State != inputStateProcessed {
+				resultText += " (" + status.Description + ")"
+			}
+		}
+	}
+	if built.Kind == utils.ContentPDF {
+		resultText += fmt.Sprintf("\nPDF uploaded as %d text input(s), one per page.", len(built.Inputs))
+	} else {
+		resultText += fmt.Sprintf("\nUploaded as %s input (%s).", built.Kind, built.MIMEType)
+	}
+	for _, note := range built.Skipped {
+		resultText += "\nSkipped " + note
+	}
 	if rawResponseJSON != nil {
 		resultText += "\nAPI Response:\n" + string(rawResponseJSON)
 	}
@@ -372,32 +861,17 @@ func (h *Handler) callClarifaiImageByURL(args map[string]interface{}) (interface
 	userID, _ := args["user_id"].(string)
 	appID, _ := args["app_id"].(string)
 
-	// Determine effective user/app/model IDs
-	effectiveUserID := userID
-	effectiveAppID := appID
-	effectiveModelID := modelID
-
-	if effectiveModelID == "" {
-		effectiveModelID = "general-image-detection" // Default model
-		h.logger.Debug("No model_id provided, defaulting", "model_id", effectiveModelID)
+	formatOpts, rpcErr := parseFormatOptions(args)
+	if rpcErr != nil {
+		return nil, rpcErr
 	}
-
-	// Special handling for general-image-detection model
-	if effectiveModelID == "general-image-detection" && effectiveUserID == "" && effectiveAppID == "" {
-		effectiveUserID = "clarifai"
-		effectiveAppID = "main"
-		h.logger.Debug("Using default user/app for general-image-detection", "user_id", effectiveUserID, "app_id", effectiveAppID)
+	annotate, rpcErr := boolArg(args, "annotate")
+	if rpcErr != nil {
+		return nil, rpcErr
 	}
 
-	// Use configured defaults if args are empty
-	if effectiveUserID == "" {
-		effectiveUserID = h.config.DefaultUserID
-		h.logger.Debug("Using default user ID from config", "user_id", effectiveUserID)
-	}
-	if effectiveAppID == "" {
-		effectiveAppID = h.config.DefaultAppID
-		h.logger.Debug("Using default app ID from config", "app_id", effectiveAppID)
-	}
+	// Determine effective user/app/model IDs
+	effectiveUserID, effectiveAppID, effectiveModelID := h.resolveDetectionModelIDs(userID, appID, modelID)
 
 	// Prepare error context map
 	errCtx := map[string]string{
@@ -442,21 +916,32 @@ func (h *Handler) callClarifaiImageByURL(args map[string]interface{}) (interface
 		return nil, utils.HandleApiError(apiErr, errCtx, h.logger)
 	}
 
-	// Marshal the raw response to JSON
-	m := protojson.MarshalOptions{Indent: "  ", EmitUnpopulated: true}
-	rawResponseJSON, marshalErr := m.Marshal(resp)
-	if marshalErr != nil {
-		h.logger.Error("Failed to marshal raw API response", "error", marshalErr)
-		apiErr := fmt.Errorf("failed to marshal raw API response: %w", marshalErr)
-		return nil, utils.HandleApiError(apiErr, errCtx, h.logger)
+	resultText, formatErr := formatInferenceResponse(resp, formatOpts)
+	if formatErr != nil {
+		h.logger.Error("Failed to format inference response", "error", formatErr)
+		return nil, utils.HandleApiError(formatErr, errCtx, h.logger)
 	}
 
-	h.logger.Debug("Inference successful (by URL), returning raw response.")
+	h.logger.Debug("Inference successful (by URL), returning formatted response.", "format", formatOpts.Format)
+
+	content := []map[string]any{
+		{"type": "text", "text": resultText},
+	}
+	if annotate {
+		imageBytes, err := utils.DownloadURL(ctx, imageURL)
+		if err != nil {
+			h.logger.Error("Failed to download image for annotation", "url", imageURL, "error", err)
+			return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to download image for annotation: %v", err), Data: errCtx}
+		}
+		annotatedContent, annotateErr := h.annotatedImageContent(imageBytes, resp, formatOpts, errCtx)
+		if annotateErr != nil {
+			return nil, annotateErr
+		}
+		content = append(content, annotatedContent...)
+	}
 
 	toolResult := map[string]interface{}{
-		"content": []map[string]any{
-			{"type": "text", "text": string(rawResponseJSON)}, // Return raw JSON response
-		},
+		"content": content,
 	}
 	return toolResult, nil
 }
@@ -502,6 +987,15 @@ func (h *Handler) callGenerateImage(args map[string]interface{}) (interface{}, *
 		h.logger.Debug("Using default app ID from config", "app_id", effectiveAppID)
 	}
 
+	genParams, rpcErr := parseGenerationParams(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	outputOpts, rpcErr := h.imageOutputOptions(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
 	// Prepare error context map
 	errCtx := map[string]string{
 		"tool":       "generate_image",
@@ -510,78 +1004,135 @@ func (h *Handler) callGenerateImage(args map[string]interface{}) (interface{}, *
 		"appID":      effectiveAppID,
 		"modelID":    effectiveModelID,
 	}
+	if genParams.SendSeed {
+		errCtx["seed"] = strconv.FormatInt(genParams.Seed, 10)
+	}
 
-	grpcRequest := &pb.PostModelOutputsRequest{
-		UserAppId: &pb.UserAppIDSet{UserId: effectiveUserID, AppId: effectiveAppID},
-		ModelId:   effectiveModelID,
-		Inputs: []*pb.Input{
-			{
-				Data: &pb.Data{
-					Text: &pb.Text{
-						Raw: textPrompt,
+	images, rpcErr := h.generateImages(genParams, errCtx, func(params *structpb.Struct) *pb.PostModelOutputsRequest {
+		return &pb.PostModelOutputsRequest{
+			UserAppId: &pb.UserAppIDSet{UserId: effectiveUserID, AppId: effectiveAppID},
+			ModelId:   effectiveModelID,
+			Inputs: []*pb.Input{
+				{
+					Data: &pb.Data{
+						Text: &pb.Text{
+							Raw: textPrompt,
+						},
 					},
 				},
 			},
+			Model: modelWithParams(params),
+		}
+	})
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	meta := GenerationMetadata{
+		Tool:    "generate_image",
+		Prompt:  textPrompt,
+		ModelID: effectiveModelID,
+		UserID:  effectiveUserID,
+		AppID:   effectiveAppID,
+	}
+	return h.generationResult(images, meta, genParams, outputOpts, errCtx)
+}
+
+// generateImages issues one PostModelOutputs request per requested image so that image N is
+// reproducible on its own with seed+N. buildRequest receives the inference parameters for each image.
+func (h *Handler) generateImages(genParams generationParams, errCtx map[string]string, buildRequest func(params *structpb.Struct) *pb.PostModelOutputsRequest) ([]generatedImage, *mcp.RPCError) {
+	var images []generatedImage
+	for i := 0; i < genParams.NumImages; i++ {
+		modelParams, err := genParams.modelParams(i)
+		if err != nil {
+			return nil, &mcp.RPCError{Code: -32602, Message: fmt.Sprintf("Invalid params: %v", err), Data: errCtx}
+		}
+		generated, requestID, rpcErr := h.postGeneration(buildRequest(modelParams), errCtx)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		for _, data := range generated {
+			images = append(images, generatedImage{
+				Data:      data,
+				Index:     i,
+				Params:    modelParams.AsMap(),
+				RequestID: requestID,
+			})
+		}
+	}
+	return images, nil
+}
+
+// generationResult builds the tool result for generated images: a summary line followed by each image.
+// meta carries the request details shared by all images and is completed per image for its sidecar file.
+func (h *Handler) generationResult(images []generatedImage, meta GenerationMetadata, genParams generationParams, outputOpts imageOutputOptions, errCtx map[string]string) (interface{}, *mcp.RPCError) {
+	summary := fmt.Sprintf("Generated %d image(s) with model %s, seed %d (image N uses seed+N).", len(images), meta.ModelID, genParams.Seed)
+	if !genParams.SendSeed {
+		summary = fmt.Sprintf("Generated %d image(s) with model %s, seeded by the model (pass seed to make results reproducible).", len(images), meta.ModelID)
+	}
+	content := []map[string]interface{}{
+		{
+			"type": "text",
+			"text": summary,
 		},
 	}
+	createdAt := time.Now().UTC().Format(time.RFC3339)
+	for _, img := range images {
+		imageMeta := meta
+		imageMeta.Seed = nil
+		if seed, ok := genParams.seedFor(img.Index); ok {
+			imageMeta.Seed = &seed
+		}
+		imageMeta.Index = img.Index
+		imageMeta.Params = img.Params
+		imageMeta.RequestID = img.RequestID
+		imageMeta.OutputFormat = outputOpts.Format
+		imageMeta.CreatedAt = createdAt
+
+		imageContent, rpcErr := h.generatedImageContent(img.Data, outputOpts, &imageMeta, errCtx)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		content = append(content, imageContent...)
+	}
 
+	toolResult := map[string]interface{}{
+		"content": content,
+	}
+	return toolResult, nil
+}
+
+// postGeneration calls PostModelOutputs for a generation request and returns every image in the
+// response together with the Clarifai request ID.
+func (h *Handler) postGeneration(grpcRequest *pb.PostModelOutputsRequest, errCtx map[string]string) ([][]byte, string, *mcp.RPCError) {
 	ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
 	if rpcErr != nil {
 		rpcErr.Data = errCtx // Add context to initialization errors
-		return nil, rpcErr
+		return nil, "", rpcErr
 	}
 	defer cancel()
 
-	h.logger.Debug("Making gRPC call to PostModelOutputs (generate)", "timeout", h.timeoutSec, "user_id", effectiveUserID, "app_id", effectiveAppID, "model_id", effectiveModelID)
+	h.logger.Debug("Making gRPC call to PostModelOutputs (generate)", "timeout", h.timeoutSec, "user_id", grpcRequest.UserAppId.UserId, "app_id", grpcRequest.UserAppId.AppId, "model_id", grpcRequest.ModelId)
 	resp, err := h.clarifaiClient.API.PostModelOutputs(ctx, grpcRequest)
 	h.logger.Debug("gRPC call to PostModelOutputs (generate) finished.")
 
 	if err != nil {
-		return nil, utils.HandleApiError(err, errCtx, h.logger)
+		return nil, "", utils.HandleApiError(err, errCtx, h.logger)
 	}
 	if resp.GetStatus().GetCode() != statuspb.StatusCode_SUCCESS {
 		apiErr := clarifai.NewAPIStatusError(resp.GetStatus())
-		return nil, utils.HandleApiError(apiErr, errCtx, h.logger)
+		return nil, "", utils.HandleApiError(apiErr, errCtx, h.logger)
 	}
-	if len(resp.Outputs) == 0 || resp.Outputs[0].Data == nil || resp.Outputs[0].Data.Image == nil {
-		apiErr := fmt.Errorf("API response did not contain image data")
-		return nil, utils.HandleApiError(apiErr, errCtx, h.logger)
-	}
-
-	imageBase64Bytes := resp.Outputs[0].Data.Image.Base64
-	h.logger.Debug("Successfully generated image", "size_bytes", len(imageBase64Bytes))
-
-	const imageSizeThreshold = 10 * 1024
 
-	if h.outputPath != "" && len(imageBase64Bytes) > imageSizeThreshold {
-		h.logger.Debug("Image size exceeds threshold, saving to disk", "size_bytes", len(imageBase64Bytes), "threshold", imageSizeThreshold, "output_path", h.outputPath)
-		savedPath, saveErr := utils.SaveImage(h.outputPath, imageBase64Bytes)
-		if saveErr != nil {
-			h.logger.Error("Error saving image using utility function", "error", saveErr)
-			// Consider returning the error instead of a generic message
-			return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to save generated image to disk: %v", saveErr), Data: errCtx}
+	var images [][]byte
+	for _, output := range resp.Outputs {
+		if imageBytes := output.GetData().GetImage().GetBase64(); len(imageBytes) > 0 {
+			images = append(images, imageBytes)
 		}
-		h.logger.Debug("Successfully saved image to disk via utility function", "path", savedPath)
-		toolResult := map[string]interface{}{
-			"content": []map[string]interface{}{
-				{
-					"type": "text",
-					"text": "Image saved to: " + savedPath,
-				},
-			},
-		}
-		return toolResult, nil
 	}
-
-	h.logger.Debug("Image size within threshold or output path not set, returning base64 data", "size_bytes", len(imageBase64Bytes))
-	cleanedBase64String := utils.CleanBase64Data(imageBase64Bytes)
-	toolResult := map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type":  "image",
-				"bytes": cleanedBase64String,
-			},
-		},
+	if len(images) == 0 {
+		apiErr := fmt.Errorf("API response did not contain image data")
+		return nil, "", utils.HandleApiError(apiErr, errCtx, h.logger)
 	}
-	return toolResult, nil
+	h.logger.Debug("Successfully generated images", "count", len(images), "request_id", resp.GetStatus().GetReqId())
+	return images, resp.GetStatus().GetReqId(), nil
 }
@@ -0,0 +1,303 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"clarifai-mcp-server-local/mcp"
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+)
+
+// maxManifestErrors bounds how many invalid manifest rows are listed in one error.
+const maxManifestErrors = 10
+
+// uploadedInput reports one input created by upload_urls or upload_manifest.
+type uploadedInput struct {
+	Source  string `json:"source"` // URL or local path
+	Line    int    `json:"line,omitempty"`
+	Kind    string `json:"kind"`
+	InputID string `json:"inputId"`
+	// Processing outcome, only set when the tool waited for processing
+	State       string `json:"state,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// remoteUploadArgumentSchema returns the properties shared by upload_urls and upload_manifest.
+func remoteUploadArgumentSchema() map[string]interface{} {
+	return mergeProperties(map[string]interface{}{
+		"allow_duplicate_url": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Optional: Allow inputs whose URL already exists in the app. Defaults to false, which makes Clarifai reject duplicates.",
+		},
+	}, appContextArgumentSchema())
+}
+
+// callUploadURLs creates inputs that Clarifai fetches from the given URLs.
+func (h *Handler) callUploadURLs(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callUploadURLs tool")
+
+	urls, rpcErr := stringListArg(args, "urls")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if len(urls) == 0 {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: missing or invalid 'urls'"}
+	}
+	inputOpts, rpcErr := parseInputOptions(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	if inputOpts.InputID != "" && len(urls) > 1 {
+		return nil, invalidParam("input_id", "can only be used with a single URL")
+	}
+	allowDuplicate, rpcErr := boolArg(args, "allow_duplicate_url")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	waitOpts, rpcErr := parseWaitOptions(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	inputs := make([]*pb.Input, 0, len(urls))
+	uploaded := make([]uploadedInput, 0, len(urls))
+	for _, rawURL := range urls {
+		input, kind, err := buildURLInput(rawURL, inputOpts, allowDuplicate)
+		if err != nil {
+			return nil, &mcp.RPCError{Code: -32602, Message: fmt.Sprintf("Invalid params: 'urls' %v", err)}
+		}
+		inputs = append(inputs, input)
+		uploaded = append(uploaded, uploadedInput{Source: rawURL, Kind: kind})
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":      "upload_urls",
+		"userID":    userAppIDSet.UserId,
+		"appID":     userAppIDSet.AppId,
+		"datasetID": inputOpts.DatasetID,
+	}
+	ids, rpcErr := h.postInputsInBatches(userAppIDSet, inputs, errCtx)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	summary := fmt.Sprintf("Uploaded %d input(s) from URLs.", len(uploaded))
+	summary += h.recordUploadedInputs(userAppIDSet, uploaded, ids, waitOpts)
+	return uploadedInputsResult(summary, uploaded)
+}
+
+// callUploadManifest uploads the inputs listed in a CSV or JSONL manifest. Tool arguments such
+// as concepts or dataset_id are defaults that a row's own columns replace. All rows are
+// validated before anything is uploaded.
+func (h *Handler) callUploadManifest(args map[string]interface{}) (interface{}, *mcp.RPCError) {
+	h.logger.Debug("Executing callUploadManifest tool")
+
+	manifestPath, ok := args["manifest_path"].(string)
+	if !ok || manifestPath == "" {
+		return nil, &mcp.RPCError{Code: -32602, Message: "Invalid params: missing or invalid 'manifest_path'"}
+	}
+	formatArg, _ := args["format"].(string)
+	format, err := manifestFormat(manifestPath, formatArg)
+	if err != nil {
+		return nil, invalidParam("format", err.Error())
+	}
+	if _, present := args["input_id"]; present {
+		return nil, invalidParam("input_id", "is not supported; set input IDs in the manifest's input_id column")
+	}
+	if _, rpcErr := parseInputOptions(args); rpcErr != nil {
+		return nil, rpcErr
+	}
+	allowDuplicate, rpcErr := boolArg(args, "allow_duplicate_url")
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	waitOpts, rpcErr := parseWaitOptions(args)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	userAppIDSet := h.uploadUserAppIDSet(args)
+	errCtx := map[string]string{
+		"tool":     "upload_manifest",
+		"manifest": manifestPath,
+		"userID":   userAppIDSet.UserId,
+		"appID":    userAppIDSet.AppId,
+	}
+
+	rows, err := readInputManifest(manifestPath, format)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &mcp.RPCError{Code: -32602, Message: fmt.Sprintf("Invalid params: manifest not found: %v", err), Data: errCtx}
+		}
+		return nil, &mcp.RPCError{Code: -32602, Message: fmt.Sprintf("Invalid params: invalid manifest: %v", err), Data: errCtx}
+	}
+
+	var inputs []*pb.Input
+	var uploaded []uploadedInput
+	var rowErrors []string
+	for _, row := range rows {
+		rowInputs, kind, err := h.manifestRowInputs(row, args, allowDuplicate)
+		if err != nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("line %d: %v", row.Line, err))
+			continue
+		}
+		source := row.URL
+		if source == "" {
+			source = row.Path
+		}
+		for _, input := range rowInputs {
+			inputs = append(inputs, input)
+			uploaded = append(uploaded, uploadedInput{Source: source, Line: row.Line, Kind: kind})
+		}
+	}
+	if len(rowErrors) > 0 {
+		message := fmt.Sprintf("Invalid params: %d manifest row(s) are invalid, nothing was uploaded:", len(rowErrors))
+		for i, rowErr := range rowErrors {
+			if i == maxManifestErrors {
+				message += fmt.Sprintf("\n... and %d more", len(rowErrors)-maxManifestErrors)
+				break
+			}
+			message += "\n" + rowErr
+		}
+		return nil, &mcp.RPCError{Code: -32602, Message: message, Data: errCtx}
+	}
+
+	ids, rpcErr := h.postInputsInBatches(userAppIDSet, inputs, errCtx)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	summary := fmt.Sprintf("Uploaded %d input(s) from %d manifest row(s).", len(uploaded), len(rows))
+	summary += h.recordUploadedInputs(userAppIDSet, uploaded, ids, waitOpts)
+	return uploadedInputsResult(summary, uploaded)
+}
+
+// manifestRowInputs builds the inputs of one manifest row. The row's input fields replace the
+// defaults given as tool arguments.
+func (h *Handler) manifestRowInputs(row manifestRow, defaults map[string]interface{}, allowDuplicate bool) ([]*pb.Input, string, error) {
+	rowArgs := make(map[string]interface{}, len(manifestOptionColumns)+2)
+	for _, name := range []string{"concepts", "negative_concepts", "metadata", "dataset_id", "latitude", "longitude"} {
+		if value, ok := defaults[name]; ok {
+			rowArgs[name] = value
+		}
+	}
+	for name, value := range row.Options {
+		rowArgs[name] = value
+	}
+	opts, rpcErr := parseInputOptions(rowArgs)
+	if rpcErr != nil {
+		return nil, "", fmt.Errorf("%s", strings.TrimPrefix(rpcErr.Message, "Invalid params: "))
+	}
+
+	if row.URL != "" {
+		input, kind, err := buildURLInput(row.URL, opts, allowDuplicate)
+		if err != nil {
+			return nil, "", err
+		}
+		return []*pb.Input{input}, kind, nil
+	}
+	data, err := os.ReadFile(row.Path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
+	}
+	built, err := buildFileInputs(row.Path, data, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	return built.Inputs, built.Kind, nil
+}
+
+// recordUploadedInputs stores the input IDs in uploaded and, with waiting enabled, polls until
+// the inputs are processed and stores their final states. It returns the processing summary
+// to append to the tool's summary, or "" when not waiting.
+func (h *Handler) recordUploadedInputs(userAppIDSet *pb.UserAppIDSet, uploaded []uploadedInput, ids []string, waitOpts waitOptions) string {
+	for i := range uploaded {
+		uploaded[i].InputID = ids[i]
+	}
+	if !waitOpts.Enabled {
+		return ""
+	}
+	statuses := h.waitForInputs(userAppIDSet, ids, waitOpts.Timeout)
+	for i := range uploaded {
+		uploaded[i].State, uploaded[i].Description = combinedInputState(statuses, ids[i:i+1])
+	}
+	return "\n" + inputStatusSummary(statuses, waitOpts.Timeout)
+}
+
+// uploadUserAppIDSet resolves the user_id and app_id arguments against the configured defaults.
+func (h *Handler) uploadUserAppIDSet(args map[string]interface{}) *pb.UserAppIDSet {
+	userID, _ := args["user_id"].(string)
+	appID, _ := args["app_id"].(string)
+	if userID == "" {
+		userID = h.config.DefaultUserID
+	}
+	if appID == "" {
+		appID = h.config.DefaultAppID
+	}
+	return &pb.UserAppIDSet{UserId: userID, AppId: appID}
+}
+
+// postInputsInBatches posts inputs in calls of at most maxBulkBatchSize and returns the ID of
+// each input, as assigned by Clarifai where none was set. A failed call stops the upload; the
+// error says how many inputs were created before it.
+func (h *Handler) postInputsInBatches(userAppIDSet *pb.UserAppIDSet, inputs []*pb.Input, errCtx map[string]string) ([]string, *mcp.RPCError) {
+	ids := make([]string, 0, len(inputs))
+	for start := 0; start < len(inputs); start += maxBulkBatchSize {
+		end := start + maxBulkBatchSize
+		if end > len(inputs) {
+			end = len(inputs)
+		}
+		chunk := inputs[start:end]
+
+		ctx, cancel, rpcErr := utils.PrepareGrpcCall(context.Background(), h.clarifaiClient, h.pat, h.timeoutSec)
+		if rpcErr != nil {
+			rpcErr.Data = errCtx
+			return nil, rpcErr
+		}
+		h.logger.Debug("Making gRPC call to PostInputs (batch)", "inputs", len(chunk), "offset", start)
+		resp, err := h.clarifaiClient.PostInputs(ctx, userAppIDSet, chunk, h.logger)
+		cancel()
+		if err != nil {
+			rpcErr := utils.HandleApiError(err, errCtx, h.logger)
+			if start > 0 {
+				rpcErr.Message += fmt.Sprintf(" (%d of %d inputs were uploaded before the failure)", start, len(inputs))
+			}
+			return nil, rpcErr
+		}
+
+		ids = append(ids, createdInputIDs(chunk, resp)...)
+	}
+	return ids, nil
+}
+
+// createdInputIDs returns the ID of each posted input: the one in the PostInputs response,
+// which includes IDs assigned by Clarifai, or else the ID that was sent.
+func createdInputIDs(posted []*pb.Input, resp *pb.MultiInputResponse) []string {
+	created := resp.GetInputs()
+	ids := make([]string, len(posted))
+	for i, input := range posted {
+		ids[i] = input.GetId()
+		if len(created) == len(posted) && created[i].GetId() != "" {
+			ids[i] = created[i].GetId()
+		}
+	}
+	return ids
+}
+
+// uploadedInputsResult formats a summary line plus the JSON list of created inputs.
+func uploadedInputsResult(summary string, uploaded []uploadedInput) (interface{}, *mcp.RPCError) {
+	uploadedJSON, err := json.MarshalIndent(uploaded, "", "  ")
+	if err != nil {
+		return nil, &mcp.RPCError{Code: -32000, Message: fmt.Sprintf("Failed to marshal upload results: %v", err)}
+	}
+	return map[string]interface{}{
+		"content": []map[string]any{
+			{"type": "text", "text": summary},
+			{"type": "text", "text": string(uploadedJSON)},
+		},
+	}, nil
+}
@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"clarifai-mcp-server-local/utils"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// fileInputs is the result of turning one local file into Clarifai inputs.
+type fileInputs struct {
+	Kind     string // One of the utils.Content* kinds
+	MIMEType string
+	Inputs   []*pb.Input
+	Skipped  []string // Human-readable notes on parts of the file that were not uploaded
+}
+
+// buildFileInputs converts a file into inputs of the matching data type: image, text, audio or
+// video. PDFs become one text input per page with a text layer; each page input gets the
+// page number in its metadata and, with a custom input ID, an ID suffixed with "-page-N".
+func buildFileInputs(path string, data []byte, opts inputOptions) (fileInputs, error) {
+	kind, mimeType, err := utils.DetectContentKind(path, data)
+	if err != nil {
+		return fileInputs{}, err
+	}
+	result := fileInputs{Kind: kind, MIMEType: mimeType}
+
+	newInput := func(d *pb.Data) *pb.Input {
+		input := &pb.Input{Data: d}
+		opts.apply(input)
+		return input
+	}
+
+	switch kind {
+	case utils.ContentImage:
+		result.Inputs = []*pb.Input{newInput(&pb.Data{Image: &pb.Image{Base64: data}})}
+	case utils.ContentText:
+		result.Inputs = []*pb.Input{newInput(&pb.Data{Text: &pb.Text{Raw: string(data)}})}
+	case utils.ContentAudio:
+		result.Inputs = []*pb.Input{newInput(&pb.Data{Audio: &pb.Audio{Base64: data}})}
+	case utils.ContentVideo:
+		result.Inputs = []*pb.Input{newInput(&pb.Data{Video: &pb.Video{Base64: data}})}
+	case utils.ContentPDF:
+		pages, err := utils.ExtractPDFPageText(data)
+		if err != nil {
+			return fileInputs{}, err
+		}
+		for i, text := range pages {
+			pageNum := i + 1
+			if text == "" {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("page %d has no text layer (scanned pages need OCR before upload)", pageNum))
+				continue
+			}
+			metadata, err := pageMetadata(opts.Metadata, filepath.Base(path), pageNum, len(pages))
+			if err != nil {
+				return fileInputs{}, err
+			}
+			input := newInput(&pb.Data{Text: &pb.Text{Raw: text}})
+			input.Data.Metadata = metadata
+			if opts.InputID != "" {
+				input.Id = fmt.Sprintf("%s-page-%d", opts.InputID, pageNum)
+			}
+			result.Inputs = append(result.Inputs, input)
+		}
+		if len(result.Inputs) == 0 {
+			return fileInputs{}, fmt.Errorf("%w: %s has no extractable text", utils.ErrUnsupportedContent, filepath.Base(path))
+		}
+	}
+	return result, nil
+}
+
+// buildURLInput creates an input that Clarifai fetches from rawURL. The data type is taken from
+// the URL's extension and defaults to image. PDFs cannot be fetched remotely because their pages
+// are extracted locally; upload them from a file instead.
+func buildURLInput(rawURL string, opts inputOptions, allowDuplicate bool) (*pb.Input, string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, "", fmt.Errorf("%q is not an http(s) URL", rawURL)
+	}
+	kind, ok := utils.ContentKindFromExtension(parsed.Path)
+	if !ok {
+		kind = utils.ContentImage
+	}
+
+	data := &pb.Data{}
+	switch kind {
+	case utils.ContentImage:
+		data.Image = &pb.Image{Url: rawURL, AllowDuplicateUrl: allowDuplicate}
+	case utils.ContentText:
+		data.Text = &pb.Text{Url: rawURL, AllowDuplicateUrl: allowDuplicate}
+	case utils.ContentAudio:
+		data.Audio = &pb.Audio{Url: rawURL, AllowDuplicateUrl: allowDuplicate}
+	case utils.ContentVideo:
+		data.Video = &pb.Video{Url: rawURL, AllowDuplicateUrl: allowDuplicate}
+	default:
+		return nil, "", fmt.Errorf("%w: %s inputs cannot be imported from a URL", utils.ErrUnsupportedContent, kind)
+	}
+	input := &pb.Input{Data: data}
+	opts.apply(input)
+	return input, kind, nil
+}
+
+// pageMetadata copies the user's metadata and records which PDF page an input came from.
+func pageMetadata(base *structpb.Struct, sourceFile string, page, pageCount int) (*structpb.Struct, error) {
+	fields := map[string]interface{}{}
+	if base != nil {
+		fields = base.AsMap()
+	}
+	fields["source_file"] = sourceFile
+	fields["page"] = page
+	fields["page_count"] = pageCount
+	metadata, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build page metadata: %w", err)
+	}
+	return metadata, nil
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"  // Register GIF decoder
+	_ "image/jpeg" // Register JPEG decoder
+	"image/png"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	_ "golang.org/x/image/webp" // Register WebP decoder
+)
+
+// RegionAnnotation describes one region to draw onto an image.
+type RegionAnnotation struct {
+	BoundingBox *pb.BoundingBox // Normalized (0-1) coordinates, optional
+	Label       string          // Text drawn next to the box, optional
+	Mask        []byte          // Encoded mask image, optional
+}
+
+// annotationPalette holds the colours cycled through for successive regions.
+var annotationPalette = []color.RGBA{
+	{R: 230, G: 25, B: 75, A: 255},
+	{R: 60, G: 180, B: 75, A: 255},
+	{R: 0, G: 130, B: 200, A: 255},
+	{R: 245, G: 130, B: 48, A: 255},
+	{R: 145, G: 30, B: 180, A: 255},
+	{R: 70, G: 240, B: 240, A: 255},
+	{R: 240, G: 50, B: 230, A: 255},
+	{R: 210, G: 245, B: 60, A: 255},
+}
+
+// maskOpacity is the blend factor (out of 255) used when overlaying segmentation masks.
+const maskOpacity = 100
+
+// DecodeImage decodes PNG, JPEG, GIF or WebP data and returns the image and its format name.
+func DecodeImage(data []byte) (image.Image, string, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, format, nil
+}
+
+// EncodePNG encodes an image as PNG bytes.
+func EncodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RegionBounds converts a normalized bounding box into pixel coordinates clamped to bounds.
+func RegionBounds(bounds image.Rectangle, box *pb.BoundingBox) image.Rectangle {
+	w, h := float32(bounds.Dx()), float32(bounds.Dy())
+	rect := image.Rect(
+		bounds.Min.X+int(box.GetLeftCol()*w),
+		bounds.Min.Y+int(box.GetTopRow()*h),
+		bounds.Min.X+int(box.GetRightCol()*w+0.5),
+		bounds.Min.Y+int(box.GetBottomRow()*h+0.5),
+	)
+	return rect.Intersect(bounds)
+}
+
+// AnnotateRegions returns a copy of img with masks, bounding boxes and labels drawn on top.
+// Masks are drawn first so boxes and labels stay readable.
+func AnnotateRegions(img image.Image, regions []RegionAnnotation) (*image.RGBA, error) {
+	bounds := img.Bounds()
+	canvas := image.NewRGBA(bounds)
+	draw.Draw(canvas, bounds, img, bounds.Min, draw.Src)
+
+	for i, region := range regions {
+		if len(region.Mask) == 0 {
+			continue
+		}
+		mask, _, err := DecodeImage(region.Mask)
+		if err != nil {
+			return nil, fmt.Errorf("region %d mask: %w", i, err)
+		}
+		drawMask(canvas, mask, annotationPalette[i%len(annotationPalette)])
+	}
+
+	thickness := max(2, min(bounds.Dx(), bounds.Dy())/300)
+	for i, region := range regions {
+		if region.BoundingBox == nil {
+			continue
+		}
+		c := annotationPalette[i%len(annotationPalette)]
+		rect := RegionBounds(bounds, region.BoundingBox)
+		if rect.Empty() {
+			continue
+		}
+		drawRectOutline(canvas, rect, thickness, c)
+		if region.Label != "" {
+			drawLabel(canvas, rect, region.Label, c)
+		}
+	}
+	return canvas, nil
+}
+
+// drawMask blends c into every canvas pixel whose corresponding mask pixel is set.
+// The mask is scaled to the canvas size with nearest-neighbour sampling.
+func drawMask(canvas *image.RGBA, mask image.Image, c color.RGBA) {
+	cb, mb := canvas.Bounds(), mask.Bounds()
+	if mb.Empty() {
+		return
+	}
+	for y := cb.Min.Y; y < cb.Max.Y; y++ {
+		my := mb.Min.Y + (y-cb.Min.Y)*mb.Dy()/cb.Dy()
+		for x := cb.Min.X; x < cb.Max.X; x++ {
+			mx := mb.Min.X + (x-cb.Min.X)*mb.Dx()/cb.Dx()
+			if !maskPixelSet(mask.At(mx, my)) {
+				continue
+			}
+			dst := canvas.RGBAAt(x, y)
+			canvas.SetRGBA(x, y, color.RGBA{
+				R: blend(dst.R, c.R),
+				G: blend(dst.G, c.G),
+				B: blend(dst.B, c.B),
+				A: dst.A,
+			})
+		}
+	}
+}
+
+// maskPixelSet treats opaque, bright mask pixels as part of the region.
+func maskPixelSet(c color.Color) bool {
+	_, _, _, a := c.RGBA()
+	if a < 0x8000 {
+		return false
+	}
+	gray := color.GrayModel.Convert(c).(color.Gray)
+	return gray.Y >= 128
+}
+
+func blend(dst, src uint8) uint8 {
+	return uint8((int(dst)*(255-maskOpacity) + int(src)*maskOpacity) / 255)
+}
+
+// drawRectOutline draws a rectangle border of the given thickness inside rect.
+func drawRectOutline(canvas *image.RGBA, rect image.Rectangle, thickness int, c color.RGBA) {
+	src := image.NewUniform(c)
+	edges := []image.Rectangle{
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+thickness),
+		image.Rect(rect.Min.X, rect.Max.Y-thickness, rect.Max.X, rect.Max.Y),
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+thickness, rect.Max.Y),
+		image.Rect(rect.Max.X-thickness, rect.Min.Y, rect.Max.X, rect.Max.Y),
+	}
+	for _, edge := range edges {
+		draw.Draw(canvas, edge.Intersect(rect), src, image.Point{}, draw.Src)
+	}
+}
+
+// drawLabel writes label on a filled background just above rect, or inside it when there is no room.
+func drawLabel(canvas *image.RGBA, rect image.Rectangle, label string, background color.RGBA) {
+	face := basicfont.Face7x13
+	const padding = 2
+	width := font.MeasureString(face, label).Ceil() + 2*padding
+	height := face.Metrics().Height.Ceil() + 2*padding
+
+	top := rect.Min.Y - height
+	if top < canvas.Bounds().Min.Y {
+		top = rect.Min.Y
+	}
+	labelRect := image.Rect(rect.Min.X, top, rect.Min.X+width, top+height).Intersect(canvas.Bounds())
+	draw.Draw(canvas, labelRect, image.NewUniform(background), image.Point{}, draw.Src)
+
+	drawer := &font.Drawer{
+		Dst:  canvas,
+		Src:  image.NewUniform(color.White),
+		Face: face,
+		Dot:  fixed.P(labelRect.Min.X+padding, labelRect.Min.Y+padding+face.Metrics().Ascent.Ceil()),
+	}
+	drawer.DrawString(label)
+}
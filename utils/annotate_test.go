@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	pb "github.com/Clarifai/clarifai-go-grpc/proto/clarifai/api"
+)
+
+func solidImage(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestRegionBounds(t *testing.T) {
+	bounds := image.Rect(0, 0, 200, 100)
+	testCases := []struct {
+		name     string
+		box      *pb.BoundingBox
+		expected image.Rectangle
+	}{
+		{"Full image", &pb.BoundingBox{TopRow: 0, LeftCol: 0, BottomRow: 1, RightCol: 1}, bounds},
+		{"Quarter", &pb.BoundingBox{TopRow: 0.5, LeftCol: 0.5, BottomRow: 1, RightCol: 1}, image.Rect(100, 50, 200, 100)},
+		{"Clamped", &pb.BoundingBox{TopRow: -0.1, LeftCol: 0.9, BottomRow: 0.5, RightCol: 1.2}, image.Rect(180, 0, 200, 50)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := RegionBounds(bounds, tc.box); got != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestAnnotateRegions(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	src := solidImage(100, 100, white)
+
+	// Mask covering the left half of a 10x10 grid, which should be scaled to the 100x100 image.
+	maskImg := image.NewGray(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 5; x++ {
+			maskImg.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	maskBytes, err := EncodePNG(maskImg)
+	if err != nil {
+		t.Fatalf("Failed to encode mask: %v", err)
+	}
+
+	annotated, err := AnnotateRegions(src, []RegionAnnotation{
+		{BoundingBox: &pb.BoundingBox{TopRow: 0.5, LeftCol: 0.5, BottomRow: 0.9, RightCol: 0.9}, Label: "cat"},
+		{Mask: maskBytes},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if got := annotated.RGBAAt(70, 89); got != annotationPalette[0] {
+		t.Errorf("Expected box edge to use palette colour %v, got %v", annotationPalette[0], got)
+	}
+	if got := annotated.RGBAAt(70, 70); got != white {
+		t.Errorf("Expected box interior to be untouched, got %v", got)
+	}
+	if got := annotated.RGBAAt(20, 20); got == white {
+		t.Errorf("Expected masked pixel to be tinted, got %v", got)
+	}
+	if got := annotated.RGBAAt(80, 10); got != white {
+		t.Errorf("Expected unmasked pixel to be untouched, got %v", got)
+	}
+	if got := src.RGBAAt(70, 89); got != white {
+		t.Errorf("Expected source image to be left unmodified, got %v", got)
+	}
+
+	t.Run("Invalid mask", func(t *testing.T) {
+		if _, err := AnnotateRegions(src, []RegionAnnotation{{Mask: []byte("not an image")}}); err == nil {
+			t.Error("Expected error for undecodable mask")
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Content kinds an uploaded file can be sent as.
+const (
+	ContentImage = "image"
+	ContentText  = "text"
+	ContentAudio = "audio"
+	ContentVideo = "video"
+	ContentPDF   = "pdf"
+)
+
+// ErrUnsupportedContent is returned for files that cannot be uploaded as any Clarifai input type.
+var ErrUnsupportedContent = errors.New("unsupported file type")
+
+// extensionKinds covers common formats that content sniffing and the system MIME table miss.
+var extensionKinds = map[string]string{
+	".jpg": ContentImage, ".jpeg": ContentImage, ".png": ContentImage, ".gif": ContentImage,
+	".webp": ContentImage, ".bmp": ContentImage, ".tif": ContentImage, ".tiff": ContentImage,
+	".txt": ContentText, ".md": ContentText, ".csv": ContentText, ".tsv": ContentText,
+	".json": ContentText, ".jsonl": ContentText, ".html": ContentText, ".htm": ContentText, ".xml": ContentText,
+	".mp3": ContentAudio, ".wav": ContentAudio, ".flac": ContentAudio, ".m4a": ContentAudio,
+	".aac": ContentAudio, ".ogg": ContentAudio, ".oga": ContentAudio, ".opus": ContentAudio,
+	".mp4": ContentVideo, ".m4v": ContentVideo, ".mov": ContentVideo, ".webm": ContentVideo,
+	".mkv": ContentVideo, ".avi": ContentVideo, ".mpeg": ContentVideo, ".mpg": ContentVideo,
+	".pdf": ContentPDF,
+}
+
+// DetectContentKind decides how a file should be uploaded from its content and, where content
+// sniffing is inconclusive, its extension. It returns the kind (one of the Content* constants)
+// and the best known MIME type, or ErrUnsupportedContent.
+func DetectContentKind(filename string, data []byte) (string, string, error) {
+	sniffed := http.DetectContentType(data)
+	mediaType, _, _ := mime.ParseMediaType(sniffed)
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	switch {
+	case mediaType == "application/pdf":
+		return ContentPDF, mediaType, nil
+	case strings.HasPrefix(mediaType, "image/"):
+		return ContentImage, mediaType, nil
+	case strings.HasPrefix(mediaType, "audio/"):
+		return ContentAudio, mediaType, nil
+	case strings.HasPrefix(mediaType, "video/"):
+		return ContentVideo, mediaType, nil
+	}
+
+	// Sniffing only recognises a handful of media formats; fall back to the extension
+	extMIME := mime.TypeByExtension(ext)
+	if extMIME == "" {
+		extMIME = mediaType
+	}
+	if kind, ok := extensionKinds[ext]; ok {
+		if kind == ContentText && !utf8.Valid(data) {
+			return "", "", fmt.Errorf("%w: %s is not valid UTF-8 text", ErrUnsupportedContent, filepath.Base(filename))
+		}
+		return kind, extMIME, nil
+	}
+	if strings.HasPrefix(mediaType, "text/") && utf8.Valid(data) {
+		return ContentText, mediaType, nil
+	}
+	return "", "", fmt.Errorf("%w: %s (%s); supported are images, text, audio, video and PDF", ErrUnsupportedContent, filepath.Base(filename), mediaType)
+}
+
+// ContentKindFromExtension returns the content kind for a file name or URL path by extension
+// alone, for remote inputs whose content is not available locally.
+func ContentKindFromExtension(name string) (string, bool) {
+	kind, ok := extensionKinds[strings.ToLower(filepath.Ext(name))]
+	return kind, ok
+}
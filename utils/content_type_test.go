@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetectContentKind(t *testing.T) {
+	wav := append([]byte("RIFF\x24\x00\x00\x00WAVEfmt "), make([]byte, 32)...)
+	mp4 := append([]byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), make([]byte, 32)...)
+
+	testCases := []struct {
+		name         string
+		filename     string
+		data         []byte
+		expectedKind string
+		expectedMIME string
+	}{
+		{"PNG", "a.png", readSample(t, "sample.png"), ContentImage, "image/png"},
+		{"JPEG without extension", "photo", readSample(t, "sample.jpg"), ContentImage, "image/jpeg"},
+		{"WebP", "a.webp", readSample(t, "sample.webp"), ContentImage, "image/webp"},
+		{"PDF", "doc.pdf", readSample(t, "sample.pdf"), ContentPDF, "application/pdf"},
+		{"Plain text", "notes.txt", []byte("hello world"), ContentText, "text/plain; charset=utf-8"},
+		{"Text without extension", "README", []byte("hello world"), ContentText, "text/plain"},
+		{"CSV by extension", "rows.csv", []byte("a,b\n1,2\n"), ContentText, ""},
+		{"WAV", "clip.wav", wav, ContentAudio, "audio/wave"},
+		{"MP4", "clip.mp4", mp4, ContentVideo, "video/mp4"},
+		{"FLAC by extension", "song.flac", []byte("fLaC\x00\x00\x00\x22"), ContentAudio, ""},
+		{"MOV by extension", "clip.mov", []byte("\x00\x00\x00\x14ftypqt  "), ContentVideo, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kind, mimeType, err := DetectContentKind(tc.filename, tc.data)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if kind != tc.expectedKind {
+				t.Errorf("Expected kind '%s', got '%s'", tc.expectedKind, kind)
+			}
+			// Extension-based MIME types come from the system MIME table and vary by platform
+			if tc.expectedMIME != "" && mimeType != tc.expectedMIME {
+				t.Errorf("Expected MIME type '%s', got '%s'", tc.expectedMIME, mimeType)
+			}
+		})
+	}
+
+	unsupported := []struct {
+		name     string
+		filename string
+		data     []byte
+	}{
+		{"Binary blob", "data.bin", []byte{0x00, 0x01, 0x02, 0xff, 0xfe}},
+		{"Zip archive", "archive.zip", []byte("PK\x03\x04\x14\x00\x00\x00")},
+		{"Text extension with invalid UTF-8", "notes.txt", []byte{0xff, 0xfe, 0xfd}},
+	}
+	for _, tc := range unsupported {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := DetectContentKind(tc.filename, tc.data); !errors.Is(err, ErrUnsupportedContent) {
+				t.Errorf("Expected ErrUnsupportedContent, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestContentKindFromExtension(t *testing.T) {
+	testCases := map[string]string{
+		"/images/cat.JPG":   ContentImage,
+		"/docs/readme.md":   ContentText,
+		"/audio/speech.mp3": ContentAudio,
+		"/video/clip.webm":  ContentVideo,
+		"/docs/report.pdf":  ContentPDF,
+	}
+	for name, expected := range testCases {
+		if kind, ok := ContentKindFromExtension(name); !ok || kind != expected {
+			t.Errorf("ContentKindFromExtension(%q) = %q, %v; expected %q", name, kind, ok, expected)
+		}
+	}
+	if _, ok := ContentKindFromExtension("/images/photo"); ok {
+		t.Error("Expected no kind for a path without extension")
+	}
+}
+
+func TestExtractPDFPageText(t *testing.T) {
+	pages, err := ExtractPDFPageText(readSample(t, "sample.pdf"))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("Expected 2 pages, got %d", len(pages))
+	}
+	if pages[0] != "Hello page one" {
+		t.Errorf("Expected page 1 text 'Hello page one', got '%s'", pages[0])
+	}
+	if pages[1] != "" {
+		t.Errorf("Expected page 2 to have no text, got '%s'", pages[1])
+	}
+
+	if _, err := ExtractPDFPageText([]byte("%PDF-1.4 truncated")); err == nil {
+		t.Error("Expected an error for a malformed PDF")
+	}
+}
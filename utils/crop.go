@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+)
+
+// CropImage returns the part of img inside rect as a new image with its origin at (0, 0).
+func CropImage(img image.Image, rect image.Rectangle) image.Image {
+	rect = rect.Intersect(img.Bounds())
+	cropped := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(cropped, cropped.Bounds(), img, rect.Min, draw.Src)
+	return cropped
+}
+
+// EncodeJPEG encodes an image as JPEG bytes with the given quality (1-100).
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode JPEG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,521 @@
+package utils
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Local dataset formats understood by the export and import tools.
+const (
+	DatasetFormatCOCO = "coco" // One annotations.json with pixel [x, y, width, height] boxes
+	DatasetFormatYOLO = "yolo" // labels/<image>.txt with normalized "class cx cy w h" lines, plus classes.txt
+	DatasetFormatVOC  = "voc"  // Annotations/<image>.xml with pixel corner boxes
+)
+
+// Directory and file names of the local dataset layout.
+const (
+	DatasetImagesDir       = "images"
+	DatasetCOCOFile        = "annotations.json"
+	DatasetYOLOLabelsDir   = "labels"
+	DatasetYOLOClassesFile = "classes.txt"
+	DatasetVOCDir          = "Annotations"
+	datasetVOCImagesDir    = "JPEGImages" // Image directory of the original VOC layout
+)
+
+// boxTolerance is how far, as a fraction of the image, a box may overshoot the image edge before
+// it counts as malformed rather than as rounding. Smaller overshoots are clamped.
+const boxTolerance = 0.01
+
+// DatasetImage is one image of a local detection dataset.
+type DatasetImage struct {
+	ID     string       `json:"id"`   // Clarifai input ID
+	File   string       `json:"file"` // Path relative to the dataset directory, e.g. images/a.jpg
+	Width  int          `json:"width"`
+	Height int          `json:"height"`
+	Boxes  []DatasetBox `json:"boxes,omitempty"`
+}
+
+// DatasetBox is a labelled bounding box, normalized to 0-1 of the image size like Clarifai regions.
+type DatasetBox struct {
+	Concept   string  `json:"concept"`
+	TopRow    float64 `json:"topRow"`
+	LeftCol   float64 `json:"leftCol"`
+	BottomRow float64 `json:"bottomRow"`
+	RightCol  float64 `json:"rightCol"`
+}
+
+// DatasetConcepts returns the sorted, distinct concepts of all boxes. Its order defines the
+// COCO category IDs (index+1) and YOLO class indices.
+func DatasetConcepts(images []DatasetImage) []string {
+	seen := map[string]bool{}
+	var concepts []string
+	for _, img := range images {
+		for _, box := range img.Boxes {
+			if !seen[box.Concept] {
+				seen[box.Concept] = true
+				concepts = append(concepts, box.Concept)
+			}
+		}
+	}
+	sort.Strings(concepts)
+	return concepts
+}
+
+// WriteDataset writes the annotation files of format into dir and returns their paths. The
+// images themselves are expected under dir already.
+func WriteDataset(dir, format string, images []DatasetImage) ([]string, error) {
+	switch format {
+	case DatasetFormatCOCO:
+		path, err := writeCOCO(dir, images)
+		if err != nil {
+			return nil, err
+		}
+		return []string{path}, nil
+	case DatasetFormatYOLO:
+		return writeYOLO(dir, images)
+	case DatasetFormatVOC:
+		return writeVOC(dir, images)
+	default:
+		return nil, fmt.Errorf("unknown dataset format %q (use coco, yolo or voc)", format)
+	}
+}
+
+// cocoDataset is the subset of the COCO detection format written and read here.
+type cocoDataset struct {
+	Images      []cocoImage      `json:"images"`
+	Annotations []cocoAnnotation `json:"annotations"`
+	Categories  []cocoCategory   `json:"categories"`
+}
+
+type cocoImage struct {
+	ID       int    `json:"id"`
+	FileName string `json:"file_name"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+type cocoAnnotation struct {
+	ID         int       `json:"id"`
+	ImageID    int       `json:"image_id"`
+	CategoryID int       `json:"category_id"`
+	BBox       []float64 `json:"bbox"` // x, y, width, height in pixels
+	Area       float64   `json:"area"`
+	IsCrowd    int       `json:"iscrowd"`
+}
+
+type cocoCategory struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func writeCOCO(dir string, images []DatasetImage) (string, error) {
+	concepts := DatasetConcepts(images)
+	categoryIDs := make(map[string]int, len(concepts))
+	dataset := cocoDataset{Images: []cocoImage{}, Annotations: []cocoAnnotation{}, Categories: make([]cocoCategory, len(concepts))}
+	for i, concept := range concepts {
+		categoryIDs[concept] = i + 1
+		dataset.Categories[i] = cocoCategory{ID: i + 1, Name: concept}
+	}
+	for i, img := range images {
+		imageID := i + 1
+		dataset.Images = append(dataset.Images, cocoImage{ID: imageID, FileName: strings.TrimPrefix(img.File, DatasetImagesDir+"/"), Width: img.Width, Height: img.Height})
+		for _, box := range img.Boxes {
+			x, y := box.LeftCol*float64(img.Width), box.TopRow*float64(img.Height)
+			w, h := (box.RightCol-box.LeftCol)*float64(img.Width), (box.BottomRow-box.TopRow)*float64(img.Height)
+			dataset.Annotations = append(dataset.Annotations, cocoAnnotation{
+				ID:         len(dataset.Annotations) + 1,
+				ImageID:    imageID,
+				CategoryID: categoryIDs[box.Concept],
+				BBox:       []float64{roundPixels(x), roundPixels(y), roundPixels(w), roundPixels(h)},
+				Area:       roundPixels(w * h),
+			})
+		}
+	}
+	data, err := json.MarshalIndent(dataset, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode COCO annotations: %w", err)
+	}
+	path := filepath.Join(dir, DatasetCOCOFile)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write COCO annotations: %w", err)
+	}
+	return path, nil
+}
+
+func writeYOLO(dir string, images []DatasetImage) ([]string, error) {
+	concepts := DatasetConcepts(images)
+	classes := make(map[string]int, len(concepts))
+	for i, concept := range concepts {
+		classes[concept] = i
+	}
+	labelsDir := filepath.Join(dir, DatasetYOLOLabelsDir)
+	if err := os.MkdirAll(labelsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create labels directory: %w", err)
+	}
+	var paths []string
+	for _, img := range images {
+		var lines strings.Builder
+		for _, box := range img.Boxes {
+			fmt.Fprintf(&lines, "%d %.6f %.6f %.6f %.6f\n", classes[box.Concept],
+				(box.LeftCol+box.RightCol)/2, (box.TopRow+box.BottomRow)/2,
+				box.RightCol-box.LeftCol, box.BottomRow-box.TopRow)
+		}
+		// Images without boxes get an empty label file, which YOLO reads as background.
+		path := filepath.Join(labelsDir, datasetFileStem(img.File)+".txt")
+		if err := os.WriteFile(path, []byte(lines.String()), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write YOLO labels: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	classesPath := filepath.Join(dir, DatasetYOLOClassesFile)
+	classList := strings.Join(concepts, "\n")
+	if classList != "" {
+		classList += "\n"
+	}
+	if err := os.WriteFile(classesPath, []byte(classList), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write YOLO classes: %w", err)
+	}
+	return append(paths, classesPath), nil
+}
+
+// vocAnnotation is the subset of the Pascal VOC annotation format written and read here.
+type vocAnnotation struct {
+	XMLName  xml.Name    `xml:"annotation"`
+	Folder   string      `xml:"folder"`
+	Filename string      `xml:"filename"`
+	Size     vocSize     `xml:"size"`
+	Objects  []vocObject `xml:"object"`
+}
+
+type vocSize struct {
+	Width  int `xml:"width"`
+	Height int `xml:"height"`
+	Depth  int `xml:"depth"`
+}
+
+type vocObject struct {
+	Name      string `xml:"name"`
+	Difficult int    `xml:"difficult"`
+	BndBox    vocBox `xml:"bndbox"`
+}
+
+type vocBox struct {
+	XMin float64 `xml:"xmin"`
+	YMin float64 `xml:"ymin"`
+	XMax float64 `xml:"xmax"`
+	YMax float64 `xml:"ymax"`
+}
+
+func writeVOC(dir string, images []DatasetImage) ([]string, error) {
+	annotationsDir := filepath.Join(dir, DatasetVOCDir)
+	if err := os.MkdirAll(annotationsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create annotations directory: %w", err)
+	}
+	var paths []string
+	for _, img := range images {
+		annotation := vocAnnotation{
+			Folder:   DatasetImagesDir,
+			Filename: filepath.Base(img.File),
+			Size:     vocSize{Width: img.Width, Height: img.Height, Depth: 3},
+		}
+		for _, box := range img.Boxes {
+			annotation.Objects = append(annotation.Objects, vocObject{Name: box.Concept, BndBox: vocBox{
+				XMin: roundPixels(box.LeftCol * float64(img.Width)),
+				YMin: roundPixels(box.TopRow * float64(img.Height)),
+				XMax: roundPixels(box.RightCol * float64(img.Width)),
+				YMax: roundPixels(box.BottomRow * float64(img.Height)),
+			}})
+		}
+		data, err := xml.MarshalIndent(annotation, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode VOC annotation: %w", err)
+		}
+		path := filepath.Join(annotationsDir, datasetFileStem(img.File)+".xml")
+		if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write VOC annotation: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// DetectDatasetFormat guesses the format of the dataset in dir from the files it contains.
+func DetectDatasetFormat(dir string) (string, error) {
+	for _, candidate := range []struct{ name, format string }{
+		{DatasetCOCOFile, DatasetFormatCOCO},
+		{DatasetYOLOClassesFile, DatasetFormatYOLO},
+		{DatasetVOCDir, DatasetFormatVOC},
+	} {
+		if _, err := os.Stat(filepath.Join(dir, candidate.name)); err == nil {
+			return candidate.format, nil
+		}
+	}
+	return "", fmt.Errorf("%s has no %s (COCO), %s (YOLO) or %s directory (VOC)", dir, DatasetCOCOFile, DatasetYOLOClassesFile, DatasetVOCDir)
+}
+
+// ReadDataset reads the local dataset of format in dir. Malformed labels do not stop reading:
+// each one is described in problems and left out, together with images that are missing or
+// whose size is invalid, so the caller can report everything before acting on the rest. err is
+// only set when the dataset as a whole cannot be read.
+func ReadDataset(dir, format string) (images []DatasetImage, problems []string, err error) {
+	switch format {
+	case DatasetFormatCOCO:
+		return readCOCO(dir)
+	case DatasetFormatYOLO:
+		return readYOLO(dir)
+	case DatasetFormatVOC:
+		return readVOC(dir)
+	default:
+		return nil, nil, fmt.Errorf("unknown dataset format %q (use coco, yolo or voc)", format)
+	}
+}
+
+func readCOCO(dir string) ([]DatasetImage, []string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, DatasetCOCOFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	var dataset cocoDataset
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %w", DatasetCOCOFile, err)
+	}
+
+	var problems []string
+	categories := map[int]string{}
+	for _, category := range dataset.Categories {
+		if strings.TrimSpace(category.Name) == "" {
+			problems = append(problems, fmt.Sprintf("%s: category %d has no name", DatasetCOCOFile, category.ID))
+			continue
+		}
+		categories[category.ID] = category.Name
+	}
+	var images []DatasetImage
+	imageIndex := map[int]int{}
+	for _, entry := range dataset.Images {
+		where := fmt.Sprintf("%s: image %d (%s)", DatasetCOCOFile, entry.ID, entry.FileName)
+		if _, duplicate := imageIndex[entry.ID]; duplicate {
+			problems = append(problems, where+": duplicate image id")
+			continue
+		}
+		file, err := findDatasetImage(dir, entry.FileName, DatasetImagesDir, "")
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", where, err))
+			continue
+		}
+		if entry.Width <= 0 || entry.Height <= 0 {
+			problems = append(problems, where+": width and height must be positive")
+			continue
+		}
+		imageIndex[entry.ID] = len(images)
+		images = append(images, DatasetImage{File: file, Width: entry.Width, Height: entry.Height})
+	}
+	for _, annotation := range dataset.Annotations {
+		where := fmt.Sprintf("%s: annotation %d", DatasetCOCOFile, annotation.ID)
+		i, ok := imageIndex[annotation.ImageID]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: unknown or invalid image_id %d", where, annotation.ImageID))
+			continue
+		}
+		concept, ok := categories[annotation.CategoryID]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: unknown or invalid category_id %d", where, annotation.CategoryID))
+			continue
+		}
+		if len(annotation.BBox) != 4 {
+			problems = append(problems, where+": bbox must be [x, y, width, height]")
+			continue
+		}
+		w, h := float64(images[i].Width), float64(images[i].Height)
+		x, y, bw, bh := annotation.BBox[0], annotation.BBox[1], annotation.BBox[2], annotation.BBox[3]
+		box, err := normalizedBox(concept, y/h, x/w, (y+bh)/h, (x+bw)/w)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: bbox %v %v", where, annotation.BBox, err))
+			continue
+		}
+		images[i].Boxes = append(images[i].Boxes, box)
+	}
+	return images, problems, nil
+}
+
+func readYOLO(dir string) ([]DatasetImage, []string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, DatasetYOLOClassesFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	var classes []string
+	for _, line := range strings.Split(strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), "\n") {
+		classes = append(classes, strings.TrimSpace(line))
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, DatasetImagesDir))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var images []DatasetImage
+	var problems []string
+	labelled := map[string]bool{}
+	for _, entry := range entries {
+		if kind, _ := ContentKindFromExtension(entry.Name()); entry.IsDir() || kind != ContentImage {
+			continue
+		}
+		img := DatasetImage{File: DatasetImagesDir + "/" + entry.Name()}
+		stem := datasetFileStem(entry.Name())
+		labelFile := DatasetYOLOLabelsDir + "/" + stem + ".txt"
+		labels, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(labelFile)))
+		if errors.Is(err, os.ErrNotExist) {
+			images = append(images, img) // No label file: an image without objects
+			continue
+		} else if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", labelFile, err))
+			continue
+		}
+		labelled[stem] = true
+		for n, line := range strings.Split(string(labels), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			box, err := yoloBox(fields, classes)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s line %d: %v", labelFile, n+1, err))
+				continue
+			}
+			img.Boxes = append(img.Boxes, box)
+		}
+		images = append(images, img)
+	}
+	labelEntries, _ := os.ReadDir(filepath.Join(dir, DatasetYOLOLabelsDir))
+	for _, entry := range labelEntries {
+		if stem := datasetFileStem(entry.Name()); filepath.Ext(entry.Name()) == ".txt" && !labelled[stem] {
+			problems = append(problems, fmt.Sprintf("%s/%s: no matching image in %s", DatasetYOLOLabelsDir, entry.Name(), DatasetImagesDir))
+		}
+	}
+	return images, problems, nil
+}
+
+// yoloBox parses the fields of one "class cx cy w h" label line.
+func yoloBox(fields []string, classes []string) (DatasetBox, error) {
+	if len(fields) != 5 {
+		return DatasetBox{}, fmt.Errorf("expected 5 fields (class cx cy w h), got %d", len(fields))
+	}
+	class, err := strconv.Atoi(fields[0])
+	if err != nil || class < 0 || class >= len(classes) || classes[class] == "" {
+		return DatasetBox{}, fmt.Errorf("class %q is not a line of %s", fields[0], DatasetYOLOClassesFile)
+	}
+	var values [4]float64
+	for i, field := range fields[1:] {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return DatasetBox{}, fmt.Errorf("invalid number %q", field)
+		}
+		values[i] = value
+	}
+	cx, cy, w, h := values[0], values[1], values[2], values[3]
+	return normalizedBox(classes[class], cy-h/2, cx-w/2, cy+h/2, cx+w/2)
+}
+
+func readVOC(dir string) ([]DatasetImage, []string, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, DatasetVOCDir))
+	if err != nil {
+		return nil, nil, err
+	}
+	var images []DatasetImage
+	var problems []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.ToLower(filepath.Ext(entry.Name())) != ".xml" {
+			continue
+		}
+		where := DatasetVOCDir + "/" + entry.Name()
+		data, err := os.ReadFile(filepath.Join(dir, DatasetVOCDir, entry.Name()))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", where, err))
+			continue
+		}
+		var annotation vocAnnotation
+		if err := xml.Unmarshal(data, &annotation); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid XML: %v", where, err))
+			continue
+		}
+		file, err := findDatasetImage(dir, annotation.Filename, DatasetImagesDir, datasetVOCImagesDir, "")
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", where, err))
+			continue
+		}
+		if annotation.Size.Width <= 0 || annotation.Size.Height <= 0 {
+			problems = append(problems, where+": size width and height must be positive")
+			continue
+		}
+		img := DatasetImage{File: file, Width: annotation.Size.Width, Height: annotation.Size.Height}
+		w, h := float64(img.Width), float64(img.Height)
+		for i, object := range annotation.Objects {
+			name := strings.TrimSpace(object.Name)
+			if name == "" {
+				problems = append(problems, fmt.Sprintf("%s: object %d has no name", where, i+1))
+				continue
+			}
+			b := object.BndBox
+			box, err := normalizedBox(name, b.YMin/h, b.XMin/w, b.YMax/h, b.XMax/w)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: object %d (%s) %v", where, i+1, name, err))
+				continue
+			}
+			img.Boxes = append(img.Boxes, box)
+		}
+		images = append(images, img)
+	}
+	return images, problems, nil
+}
+
+// findDatasetImage resolves an image file name against the image directories of a dataset, then
+// the dataset directory itself, and returns its slash-separated path relative to dir.
+func findDatasetImage(dir, name string, imageDirs ...string) (string, error) {
+	if name == "" {
+		return "", errors.New("no image file name")
+	}
+	for _, imageDir := range imageDirs {
+		candidate := filepath.ToSlash(filepath.Join(imageDir, filepath.FromSlash(name)))
+		if strings.HasPrefix(candidate, "../") {
+			break // Keep image paths inside the dataset
+		}
+		if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(candidate))); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("image file %q not found", name)
+}
+
+// normalizedBox validates a box given as normalized edges and clamps overshoots within boxTolerance.
+func normalizedBox(concept string, top, left, bottom, right float64) (DatasetBox, error) {
+	for _, v := range []float64{top, left, bottom, right} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return DatasetBox{}, errors.New("has invalid coordinates")
+		}
+	}
+	if bottom <= top || right <= left {
+		return DatasetBox{}, errors.New("has no area")
+	}
+	if top < -boxTolerance || left < -boxTolerance || bottom > 1+boxTolerance || right > 1+boxTolerance {
+		return DatasetBox{}, errors.New("lies outside the image")
+	}
+	clamp := func(v float64) float64 { return math.Min(1, math.Max(0, v)) }
+	return DatasetBox{Concept: concept, TopRow: clamp(top), LeftCol: clamp(left), BottomRow: clamp(bottom), RightCol: clamp(right)}, nil
+}
+
+// datasetFileStem returns the file name without directory and extension.
+func datasetFileStem(file string) string {
+	base := filepath.Base(file)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// roundPixels rounds pixel coordinates to two decimals to keep the files readable.
+func roundPixels(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,255 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testDatasetImages() []DatasetImage {
+	return []DatasetImage{
+		{ID: "a", File: "images/a.jpg", Width: 200, Height: 100, Boxes: []DatasetBox{
+			{Concept: "dog", TopRow: 0.1, LeftCol: 0.25, BottomRow: 0.5, RightCol: 0.75},
+			{Concept: "cat", TopRow: 0, LeftCol: 0, BottomRow: 1, RightCol: 0.5},
+		}},
+		{ID: "b", File: "images/b.png", Width: 50, Height: 50},
+	}
+}
+
+func TestWriteDataset_COCO(t *testing.T) {
+	dir := t.TempDir()
+	files, err := WriteDataset(dir, DatasetFormatCOCO, testDatasetImages())
+	if err != nil {
+		t.Fatalf("WriteDataset error: %v", err)
+	}
+	if want := []string{filepath.Join(dir, DatasetCOCOFile)}; !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("reading annotations: %v", err)
+	}
+	var dataset cocoDataset
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		t.Fatalf("invalid COCO JSON: %v", err)
+	}
+	wantCategories := []cocoCategory{{ID: 1, Name: "cat"}, {ID: 2, Name: "dog"}}
+	if !reflect.DeepEqual(dataset.Categories, wantCategories) {
+		t.Errorf("categories = %v, want %v", dataset.Categories, wantCategories)
+	}
+	if len(dataset.Images) != 2 || dataset.Images[0].FileName != "a.jpg" || dataset.Images[1].Width != 50 {
+		t.Errorf("unexpected images: %+v", dataset.Images)
+	}
+	wantAnnotations := []cocoAnnotation{
+		{ID: 1, ImageID: 1, CategoryID: 2, BBox: []float64{50, 10, 100, 40}, Area: 4000},
+		{ID: 2, ImageID: 1, CategoryID: 1, BBox: []float64{0, 0, 100, 100}, Area: 10000},
+	}
+	if !reflect.DeepEqual(dataset.Annotations, wantAnnotations) {
+		t.Errorf("annotations = %+v, want %+v", dataset.Annotations, wantAnnotations)
+	}
+}
+
+func TestWriteDataset_YOLO(t *testing.T) {
+	dir := t.TempDir()
+	files, err := WriteDataset(dir, DatasetFormatYOLO, testDatasetImages())
+	if err != nil {
+		t.Fatalf("WriteDataset error: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("files = %v, want two label files and classes.txt", files)
+	}
+	expected := map[string]string{
+		filepath.Join(DatasetYOLOLabelsDir, "a.txt"): "1 0.500000 0.300000 0.500000 0.400000\n0 0.250000 0.500000 0.500000 1.000000\n",
+		filepath.Join(DatasetYOLOLabelsDir, "b.txt"): "",
+		DatasetYOLOClassesFile:                       "cat\ndog\n",
+	}
+	for name, want := range expected {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("reading %s: %v", name, err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+}
+
+func TestWriteDataset_VOC(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := WriteDataset(dir, DatasetFormatVOC, testDatasetImages()); err != nil {
+		t.Fatalf("WriteDataset error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, DatasetVOCDir, "a.xml"))
+	if err != nil {
+		t.Fatalf("reading VOC annotation: %v", err)
+	}
+	for _, want := range []string{
+		"<filename>a.jpg</filename>",
+		"<width>200</width>",
+		"<name>dog</name>",
+		"<xmin>50</xmin>", "<ymin>10</ymin>", "<xmax>150</xmax>", "<ymax>50</ymax>",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("VOC annotation misses %s:\n%s", want, data)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, DatasetVOCDir, "b.xml")); err != nil {
+		t.Errorf("image without boxes has no annotation file: %v", err)
+	}
+}
+
+func TestWriteDataset_UnknownFormat(t *testing.T) {
+	if _, err := WriteDataset(t.TempDir(), "csv", nil); err == nil || !strings.Contains(err.Error(), "unknown dataset format") {
+		t.Errorf("WriteDataset(csv) error = %v, want unknown dataset format", err)
+	}
+}
+
+// writeTestDataset writes the images' placeholder files and annotation files in format.
+func writeTestDataset(t *testing.T, format string, images []DatasetImage) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, DatasetImagesDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, img := range images {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(img.File)), []byte("image"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := WriteDataset(dir, format, images); err != nil {
+		t.Fatalf("WriteDataset error: %v", err)
+	}
+	return dir
+}
+
+func TestReadDataset_RoundTrip(t *testing.T) {
+	for _, format := range []string{DatasetFormatCOCO, DatasetFormatYOLO, DatasetFormatVOC} {
+		t.Run(format, func(t *testing.T) {
+			dir := writeTestDataset(t, format, testDatasetImages())
+			detected, err := DetectDatasetFormat(dir)
+			if err != nil || detected != format {
+				t.Fatalf("DetectDatasetFormat = %q, %v; want %q", detected, err, format)
+			}
+			images, problems, err := ReadDataset(dir, format)
+			if err != nil {
+				t.Fatalf("ReadDataset error: %v", err)
+			}
+			if len(problems) > 0 {
+				t.Errorf("unexpected problems: %v", problems)
+			}
+			want := testDatasetImages()
+			if len(images) != len(want) {
+				t.Fatalf("read %d images, want %d", len(images), len(want))
+			}
+			for i, img := range images {
+				if img.File != want[i].File || len(img.Boxes) != len(want[i].Boxes) {
+					t.Fatalf("image %d = %+v, want %+v", i, img, want[i])
+				}
+				for j, box := range img.Boxes {
+					wantBox := want[i].Boxes[j]
+					if box.Concept != wantBox.Concept || math.Abs(box.TopRow-wantBox.TopRow) > 1e-6 || math.Abs(box.LeftCol-wantBox.LeftCol) > 1e-6 ||
+						math.Abs(box.BottomRow-wantBox.BottomRow) > 1e-6 || math.Abs(box.RightCol-wantBox.RightCol) > 1e-6 {
+						t.Errorf("image %d box %d = %+v, want %+v", i, j, box, wantBox)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestReadDataset_Problems(t *testing.T) {
+	t.Run("COCO", func(t *testing.T) {
+		dir := writeTestDataset(t, DatasetFormatCOCO, testDatasetImages()[:1])
+		coco := `{"images": [{"id": 1, "file_name": "a.jpg", "width": 200, "height": 100}, {"id": 2, "file_name": "gone.jpg", "width": 10, "height": 10}],
+			"categories": [{"id": 1, "name": "dog"}],
+			"annotations": [
+				{"id": 1, "image_id": 1, "category_id": 1, "bbox": [10, 10, 20, 20]},
+				{"id": 2, "image_id": 1, "category_id": 9, "bbox": [10, 10, 20, 20]},
+				{"id": 3, "image_id": 1, "category_id": 1, "bbox": [190, 10, 50, 20]},
+				{"id": 4, "image_id": 1, "category_id": 1, "bbox": [10, 10, 0, 20]},
+				{"id": 5, "image_id": 1, "category_id": 1, "bbox": [10, 10]}
+			]}`
+		if err := os.WriteFile(filepath.Join(dir, DatasetCOCOFile), []byte(coco), 0644); err != nil {
+			t.Fatal(err)
+		}
+		images, problems, err := ReadDataset(dir, DatasetFormatCOCO)
+		if err != nil {
+			t.Fatalf("ReadDataset error: %v", err)
+		}
+		if len(images) != 1 || len(images[0].Boxes) != 1 {
+			t.Errorf("images = %+v, want one image with the one valid box", images)
+		}
+		assertProblems(t, problems, `image 2 (gone.jpg): image file "gone.jpg" not found`, "annotation 2: unknown or invalid category_id 9",
+			"annotation 3: bbox [190 10 50 20] lies outside the image", "annotation 4: bbox [10 10 0 20] has no area", "annotation 5: bbox must be")
+	})
+
+	t.Run("YOLO", func(t *testing.T) {
+		dir := writeTestDataset(t, DatasetFormatYOLO, testDatasetImages())
+		labels := "0 0.5 0.5 0.2 0.2\n5 0.5 0.5 0.2 0.2\n0 0.5 0.5\n0 0.95 0.5 0.2 0.2\n0 x 0.5 0.2 0.2\n"
+		if err := os.WriteFile(filepath.Join(dir, DatasetYOLOLabelsDir, "a.txt"), []byte(labels), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, DatasetYOLOLabelsDir, "orphan.txt"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		images, problems, err := ReadDataset(dir, DatasetFormatYOLO)
+		if err != nil {
+			t.Fatalf("ReadDataset error: %v", err)
+		}
+		if len(images) != 2 || len(images[0].Boxes) != 1 {
+			t.Errorf("images = %+v, want a.jpg with one valid box and b.png", images)
+		}
+		assertProblems(t, problems, `labels/a.txt line 2: class "5" is not a line of classes.txt`, "labels/a.txt line 3: expected 5 fields",
+			"labels/a.txt line 4: lies outside the image", `labels/a.txt line 5: invalid number "x"`, "labels/orphan.txt: no matching image")
+	})
+
+	t.Run("VOC", func(t *testing.T) {
+		dir := writeTestDataset(t, DatasetFormatVOC, testDatasetImages())
+		voc := `<annotation><filename>a.jpg</filename><size><width>200</width><height>100</height></size>
+			<object><name>dog</name><bndbox><xmin>10</xmin><ymin>10</ymin><xmax>5</xmax><ymax>50</ymax></bndbox></object>
+			<object><name></name><bndbox><xmin>10</xmin><ymin>10</ymin><xmax>50</xmax><ymax>50</ymax></bndbox></object>
+			<object><name>cat</name><bndbox><xmin>0</xmin><ymin>0</ymin><xmax>201</xmax><ymax>100</ymax></bndbox></object>
+			</annotation>`
+		if err := os.WriteFile(filepath.Join(dir, DatasetVOCDir, "a.xml"), []byte(voc), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, DatasetVOCDir, "broken.xml"), []byte("<annotation>"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		images, problems, err := ReadDataset(dir, DatasetFormatVOC)
+		if err != nil {
+			t.Fatalf("ReadDataset error: %v", err)
+		}
+		if len(images) != 2 || len(images[0].Boxes) != 1 || images[0].Boxes[0].RightCol != 1 {
+			t.Errorf("images = %+v, want a.jpg with the clamped cat box and b.png", images)
+		}
+		assertProblems(t, problems, "Annotations/a.xml: object 1 (dog) has no area", "Annotations/a.xml: object 2 has no name", "Annotations/broken.xml: invalid XML")
+	})
+
+	t.Run("Missing annotation file", func(t *testing.T) {
+		if _, _, err := ReadDataset(t.TempDir(), DatasetFormatCOCO); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("ReadDataset error = %v, want not exist", err)
+		}
+		if _, err := DetectDatasetFormat(t.TempDir()); err == nil {
+			t.Error("DetectDatasetFormat of an empty directory succeeded")
+		}
+	})
+}
+
+// assertProblems checks that problems has one entry containing each expected text, in order.
+func assertProblems(t *testing.T, problems []string, expected ...string) {
+	t.Helper()
+	if len(problems) != len(expected) {
+		t.Fatalf("got %d problems %q, want %d", len(problems), problems, len(expected))
+	}
+	for i, want := range expected {
+		if !strings.Contains(problems[i], want) {
+			t.Errorf("problem %d = %q, want it to contain %q", i, problems[i], want)
+		}
+	}
+}
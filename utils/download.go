@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// MaxDownloadBytes caps how much data DownloadURL will read from a remote server.
+const MaxDownloadBytes = 100 << 20 // 100 MiB
+
+// DownloadURL fetches the content at rawURL, honouring ctx for cancellation and timeouts.
+// Responses larger than MaxDownloadBytes are rejected.
+func DownloadURL(ctx context.Context, rawURL string) ([]byte, error) {
+	slog.Debug("Downloading URL", "url", rawURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid download URL: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: unexpected status %s", rawURL, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxDownloadBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read download body from %s: %w", rawURL, err)
+	}
+	if len(data) > MaxDownloadBytes {
+		return nil, fmt.Errorf("download from %s exceeds %d bytes", rawURL, MaxDownloadBytes)
+	}
+	slog.Debug("Downloaded URL", "url", rawURL, "size_bytes", len(data))
+	return data, nil
+}
@@ -1,71 +1,62 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
 	"log/slog" // Use slog
-	"math/rand"
-	"os"
 	"strings"
 	"time"
 )
 
 // SaveImage saves image data to a file in the specified directory.
-// It generates a unique filename and returns the full path or an error.
-// It also handles cleaning potential data URI prefixes from base64 data.
-func SaveImage(outputPath string, imageBase64Bytes []byte) (string, error) {
-	slog.Debug("Attempting to save image", "size_bytes", len(imageBase64Bytes), "output_path", outputPath) // Use slog
-
-	// Convert bytes to string for cleaning and prefix check
-	imageBase64String := string(imageBase64Bytes)
+// It generates a unique filename from DefaultOutputNameTemplate and returns the full path or an error.
+// See SaveNamedImage for the accepted data encodings.
+func SaveImage(outputPath string, imageData []byte) (string, error) {
+	return SaveNamedImage(outputPath, DefaultOutputNameTemplate, OutputNameFields{}, imageData)
+}
 
-	// Trim whitespace first
-	imageBase64String = strings.TrimSpace(imageBase64String)
+// SaveNamedImage saves image data under outputPath using a file name rendered from template
+// (see RenderOutputName) and returns the full path or an error.
+// The data may be raw image bytes, base64 or a base64 data URI (see DecodeImageData);
+// the decoded bytes are written with an extension matching the sniffed format.
+func SaveNamedImage(outputPath, template string, fields OutputNameFields, imageData []byte) (string, error) {
+	slog.Debug("Attempting to save image", "size_bytes", len(imageData), "output_path", outputPath) // Use slog
 
-	// Check for and remove data URI prefix if present
-	if commaIndex := strings.Index(imageBase64String, ","); commaIndex != -1 && strings.HasPrefix(imageBase64String, "data:") {
-		slog.Debug("Found and removing data URI prefix before saving.") // Use slog
-		imageBase64String = imageBase64String[commaIndex+1:]
-		// Re-convert cleaned string back to bytes for saving
-		// Note: This assumes the original bytes included the prefix. If not, this step might be unnecessary.
-		// Consider if the input `imageBase64Bytes` is guaranteed to be *just* the base64 data.
-		// For now, we'll work with the potentially cleaned string converted back to bytes.
-		// TODO: Re-evaluate if decoding/re-encoding is needed or if we can save original bytes after check.
-		// imageBase64Bytes = []byte(imageBase64String) // Re-assigning bytes after cleaning string
+	imageBytes, mimeType, err := DecodeImageData(imageData)
+	if err != nil {
+		slog.Error("Error decoding image data", "error", err) // Use slog
+		return "", fmt.Errorf("failed to decode image data: %w", err)
 	}
+	slog.Debug("Detected image format", "mime_type", mimeType, "size_bytes", len(imageBytes)) // Use slog
 
-	// Trim whitespace again after potential prefix removal
-	// imageBase64String = strings.TrimSpace(imageBase64String) // String already trimmed
-
-	// Generate unique filename
-	// TODO: Determine image format properly instead of assuming png.
-	timestamp := time.Now().UnixNano()
-	// Ensure rand is seeded (should be done once at application start)
-	// rand.Seed(time.Now().UnixNano()) // Seeding here is inefficient if called often
-	randomNum := rand.Intn(10000)
-	filename := fmt.Sprintf("generated_image_%d_%d.png", timestamp, randomNum)
-
-	// Construct full path using os.PathSeparator for cross-platform compatibility
-	// Ensure outputPath exists (WriteFile doesn't create intermediate dirs)
-	err := os.MkdirAll(outputPath, 0755) // Ensure the directory exists
+	fullPath, err := SaveNamedFile(outputPath, template, fields, ImageFileExtension(mimeType), imageBytes)
 	if err != nil {
-		slog.Error("Error creating output directory", "path", outputPath, "error", err) // Use slog
-		return "", fmt.Errorf("failed to create output directory: %w", err)
+		slog.Error("Error writing image file", "output_path", outputPath, "error", err) // Use slog
+		return "", fmt.Errorf("failed to save generated image to disk: %w", err)
 	}
-	fullPath := outputPath + string(os.PathSeparator) + filename
-	slog.Debug("Determined full path for saving", "path", fullPath) // Use slog
+	slog.Info("Successfully saved image", "path", fullPath) // Use slog
+	return fullPath, nil
+}
 
-	// Write the raw image bytes directly to the file
-	// Use the potentially modified bytes after cleaning the string representation
-	slog.Debug("Writing raw image bytes directly to file...") // Use slog
-	// err = os.WriteFile(fullPath, imageBase64Bytes, 0644) // Use original bytes
-	// Let's try writing the cleaned string converted back to bytes, assuming prefix removal was needed.
-	// This might be incorrect if the original bytes were pure base64.
-	err = os.WriteFile(fullPath, []byte(imageBase64String), 0644) // Write potentially cleaned bytes
+// SaveNamedFile writes data under outputPath using a file name rendered from template plus ext,
+// never overwriting an existing file (a random suffix is added on collision), and returns the full path.
+func SaveNamedFile(outputPath, template string, fields OutputNameFields, ext string, data []byte) (string, error) {
+	name, err := RenderOutputName(template, fields)
 	if err != nil {
-		slog.Error("Error writing image file", "path", fullPath, "error", err) // Use slog
-		return "", fmt.Errorf("failed to save generated image to disk: %w", err)
+		return "", fmt.Errorf("invalid output name template: %w", err)
 	}
+	return writeUniqueFile(outputPath, name, ext, data)
+}
 
+// SaveAnnotatedImage writes already-encoded PNG bytes of an annotated inference image
+// to the output directory and returns the full path.
+func SaveAnnotatedImage(outputPath string, pngBytes []byte) (string, error) {
+	name := fmt.Sprintf("annotated_image_%d_%s", time.Now().UnixNano(), RandomHex(4))
+	fullPath, err := writeUniqueFile(outputPath, name, ".png", pngBytes)
+	if err != nil {
+		slog.Error("Error writing annotated image", "output_path", outputPath, "error", err) // Use slog
+		return "", err
+	}
 	slog.Info("Successfully saved image", "path", fullPath) // Use slog
 	return fullPath, nil
 }
@@ -82,3 +73,50 @@ func CleanBase64Data(data []byte) string {
 	// Trim again after potential removal
 	return strings.TrimSpace(dataString)
 }
+
+// Slugify lowercases s and replaces every run of non-alphanumeric characters with a single
+// hyphen, producing a string safe to embed in file names. The result is truncated to maxLen
+// characters when maxLen is positive.
+func Slugify(s string, maxLen int) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(r)
+			continue
+		}
+		pendingHyphen = true
+	}
+	slug := b.String()
+	if maxLen > 0 && len(slug) > maxLen {
+		slug = strings.TrimRight(slug[:maxLen], "-")
+	}
+	return slug
+}
+
+// SidecarPath returns the path of the JSON metadata file stored next to filePath. The image's
+// extension is kept (x.png -> x.png.json), so images differing only in extension get their own sidecars.
+func SidecarPath(filePath string) string {
+	return filePath + ".json"
+}
+
+// WriteSidecar marshals metadata as indented JSON into the sidecar file of filePath
+// (see SidecarPath) and returns the sidecar's path. An existing file at that path is never
+// overwritten; an error is returned instead.
+func WriteSidecar(filePath string, metadata interface{}) (string, error) {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	sidecarPath := SidecarPath(filePath)
+	if err := WriteNewFile(sidecarPath, data); err != nil {
+		slog.Error("Error writing sidecar file", "path", sidecarPath, "error", err) // Use slog
+		return "", fmt.Errorf("failed to write metadata file: %w", err)
+	}
+	slog.Debug("Wrote sidecar metadata", "path", sidecarPath) // Use slog
+	return sidecarPath, nil
+}
@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -67,46 +71,62 @@ func TestCleanBase64Data(t *testing.T) {
 
 func TestSaveImage(t *testing.T) {
 	tempDir := t.TempDir()
-	validImageData := []byte("data:image/png;base64,validimagedata")
-	cleanedImageData := "validimagedata" // What should actually be written
 
-	t.Run("Successful save", func(t *testing.T) {
-		savedPath, err := SaveImage(tempDir, validImageData)
-		if err != nil {
-			t.Fatalf("Expected no error, but got: %v", err)
-		}
+	testCases := []struct {
+		name        string
+		sample      string
+		encode      func(raw []byte) []byte
+		expectedExt string
+	}{
+		{"Raw PNG", "sample.png", nil, ".png"},
+		{"Raw JPEG", "sample.jpg", nil, ".jpg"},
+		{"Raw WebP", "sample.webp", nil, ".webp"},
+		{"Raw GIF", "sample.gif", nil, ".gif"},
+		{"Base64 JPEG", "sample.jpg", func(raw []byte) []byte {
+			return []byte(base64.StdEncoding.EncodeToString(raw))
+		}, ".jpg"},
+		{"Data URI PNG with whitespace", "sample.png", func(raw []byte) []byte {
+			return []byte("  data:image/png;base64," + base64.StdEncoding.EncodeToString(raw) + "\n")
+		}, ".png"},
+		{"Unpadded base64 GIF", "sample.gif", func(raw []byte) []byte {
+			return []byte(base64.RawStdEncoding.EncodeToString(raw))
+		}, ".gif"},
+	}
 
-		// Check if path is within the temp dir and has expected prefix/suffix
-		if !strings.HasPrefix(savedPath, tempDir) {
-			t.Errorf("Expected saved path '%s' to be within temp dir '%s'", savedPath, tempDir)
-		}
-		if !strings.HasPrefix(filepath.Base(savedPath), "generated_image_") || !strings.HasSuffix(savedPath, ".png") {
-			t.Errorf("Saved path '%s' does not match expected format 'generated_image_...png'", savedPath)
-		}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw := readSample(t, tc.sample)
+			input := raw
+			if tc.encode != nil {
+				input = tc.encode(raw)
+			}
 
-		// Check file content
-		contentBytes, readErr := os.ReadFile(savedPath)
-		if readErr != nil {
-			t.Fatalf("Failed to read saved file '%s': %v", savedPath, readErr)
-		}
-		if string(contentBytes) != cleanedImageData {
-			t.Errorf("Expected file content '%s', but got '%s'", cleanedImageData, string(contentBytes))
-		}
-		// No need to explicitly remove, t.TempDir() handles cleanup
-	})
+			savedPath, err := SaveImage(tempDir, input)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if !strings.HasPrefix(savedPath, tempDir) {
+				t.Errorf("Expected saved path '%s' to be within temp dir '%s'", savedPath, tempDir)
+			}
+			if !strings.HasPrefix(filepath.Base(savedPath), "generated_image_") || filepath.Ext(savedPath) != tc.expectedExt {
+				t.Errorf("Saved path '%s' does not match expected format 'generated_image_...%s'", savedPath, tc.expectedExt)
+			}
 
-	t.Run("Save with pure base64", func(t *testing.T) {
-		pureImageData := []byte("purebase64data")
-		savedPath, err := SaveImage(tempDir, pureImageData)
-		if err != nil {
-			t.Fatalf("Expected no error for pure base64, but got: %v", err)
-		}
-		contentBytes, readErr := os.ReadFile(savedPath)
-		if readErr != nil {
-			t.Fatalf("Failed to read saved file '%s': %v", savedPath, readErr)
-		}
-		if string(contentBytes) != "purebase64data" {
-			t.Errorf("Expected file content 'purebase64data', but got '%s'", string(contentBytes))
+			contentBytes, readErr := os.ReadFile(savedPath)
+			if readErr != nil {
+				t.Fatalf("Failed to read saved file '%s': %v", savedPath, readErr)
+			}
+			if !bytes.Equal(contentBytes, raw) {
+				t.Errorf("Saved file does not contain the decoded image bytes (%d bytes, expected %d)", len(contentBytes), len(raw))
+			}
+		})
+	}
+
+	t.Run("Unrecognised data", func(t *testing.T) {
+		for _, input := range [][]byte{[]byte("not an image"), []byte(base64.StdEncoding.EncodeToString([]byte("plain text")))} {
+			if _, err := SaveImage(tempDir, input); !errors.Is(err, ErrUnrecognizedImage) {
+				t.Errorf("Expected ErrUnrecognizedImage for %q, got: %v", input, err)
+			}
 		}
 	})
 
@@ -116,3 +136,123 @@ func TestSaveImage(t *testing.T) {
 
 	// Testing WriteFile failure is also tricky without specific OS conditions.
 }
+
+func TestReencodeImage(t *testing.T) {
+	testCases := []struct {
+		name         string
+		sample       string
+		format       string
+		quality      int
+		expectedMIME string
+		unchanged    bool
+	}{
+		{"PNG to JPEG", "sample.png", "jpeg", 75, "image/jpeg", false},
+		{"JPEG to PNG", "sample.jpg", "png", 0, "image/png", false},
+		{"WebP to PNG", "sample.webp", "png", 0, "image/png", false},
+		{"PNG to GIF", "sample.png", "gif", 0, "image/gif", false},
+		{"jpg alias", "sample.gif", "JPG", 0, "image/jpeg", false},
+		{"Same format is kept", "sample.png", "png", 0, "image/png", true},
+		{"Empty format is kept", "sample.webp", "", 0, "image/webp", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw := readSample(t, tc.sample)
+			out, err := ReencodeImage(raw, tc.format, tc.quality)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if got := DetectImageMIMEType(out); got != tc.expectedMIME {
+				t.Errorf("Expected MIME type '%s', got '%s'", tc.expectedMIME, got)
+			}
+			if tc.unchanged != bytes.Equal(out, raw) {
+				t.Errorf("Expected unchanged=%v", tc.unchanged)
+			}
+			srcImg, _, _ := DecodeImage(raw)
+			outImg, _, err := DecodeImage(out)
+			if err != nil {
+				t.Fatalf("Re-encoded image does not decode: %v", err)
+			}
+			if outImg.Bounds() != srcImg.Bounds() {
+				t.Errorf("Expected bounds %v, got %v", srcImg.Bounds(), outImg.Bounds())
+			}
+		})
+	}
+
+	t.Run("Unsupported format", func(t *testing.T) {
+		if _, err := ReencodeImage(readSample(t, "sample.png"), "tiff", 0); err == nil {
+			t.Error("Expected an error for an unsupported format")
+		}
+	})
+}
+
+func TestWriteSidecar(t *testing.T) {
+	imagePath := filepath.Join(t.TempDir(), "generated_image_1_2.png")
+	sidecarPath, err := WriteSidecar(imagePath, map[string]interface{}{"prompt": "a cat", "seed": 7})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if expected := imagePath + ".json"; sidecarPath != expected {
+		t.Errorf("Expected sidecar path '%s', got '%s'", expected, sidecarPath)
+	}
+	data, err := os.ReadFile(sidecarPath)
+	if err != nil {
+		t.Fatalf("Failed to read sidecar: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Sidecar is not valid JSON: %v", err)
+	}
+	if decoded["prompt"] != "a cat" || decoded["seed"] != float64(7) {
+		t.Errorf("Unexpected sidecar content: %s", data)
+	}
+
+	// An image with the same stem but another extension gets its own sidecar
+	otherPath, err := WriteSidecar(strings.TrimSuffix(imagePath, ".png")+".jpg", map[string]interface{}{"prompt": "a dog"})
+	if err != nil {
+		t.Fatalf("Expected no error for a different extension, but got: %v", err)
+	}
+	if otherPath == sidecarPath {
+		t.Errorf("Expected distinct sidecars, both are '%s'", otherPath)
+	}
+
+	// Existing files are never overwritten
+	if _, err := WriteSidecar(imagePath, map[string]interface{}{"prompt": "overwrite"}); err == nil {
+		t.Error("Expected an error when the sidecar already exists")
+	}
+	if after, _ := os.ReadFile(sidecarPath); string(after) != string(data) {
+		t.Errorf("Sidecar was overwritten: %s", after)
+	}
+}
+
+// readSample loads an image from testdata.
+func readSample(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read sample image: %v", err)
+	}
+	return data
+}
+
+func TestSlugify(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		maxLen   int
+		expected string
+	}{
+		{"Simple", "Cat", 0, "cat"},
+		{"Spaces and punctuation", "  A red fox, jumping!  ", 0, "a-red-fox-jumping"},
+		{"Non-ASCII dropped", "café crème", 0, "caf-cr-me"},
+		{"Truncated without trailing hyphen", "hello world again", 6, "hello"},
+		{"Empty", "!!!", 0, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Slugify(tc.input, tc.maxLen); got != tc.expected {
+				t.Errorf("Expected '%s', got '%s'", tc.expected, got)
+			}
+		})
+	}
+}
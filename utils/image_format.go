@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image/gif"
+	"net/http"
+	"strings"
+)
+
+// DefaultJPEGQuality is used when re-encoding to JPEG without an explicit quality.
+const DefaultJPEGQuality = 90
+
+// ErrUnrecognizedImage is returned when data is neither a known image format nor base64 encoding of one.
+var ErrUnrecognizedImage = errors.New("data is not a recognised image")
+
+// imageExtensions maps sniffed MIME types to file extensions.
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+	"image/bmp":  ".bmp",
+}
+
+// DetectImageMIMEType sniffs raw image bytes and returns their MIME type, such as "image/png".
+// It returns an empty string when the data is not a recognised image format.
+func DetectImageMIMEType(data []byte) string {
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return ""
+	}
+	return mimeType
+}
+
+// ImageFileExtension returns the file extension, including the dot, for an image MIME type.
+func ImageFileExtension(mimeType string) string {
+	if ext, ok := imageExtensions[mimeType]; ok {
+		return ext
+	}
+	return ".img"
+}
+
+// DecodeImageData returns the raw image bytes and MIME type for data that is either raw image
+// bytes, base64 text or a base64 data URI. Clarifai returns raw bytes, but some models and
+// clients hand over base64, so all three are accepted.
+func DecodeImageData(data []byte) ([]byte, string, error) {
+	if mimeType := DetectImageMIMEType(data); mimeType != "" {
+		return data, mimeType, nil
+	}
+	cleaned := CleanBase64Data(data)
+	decoded, err := base64.StdEncoding.DecodeString(cleaned)
+	if err != nil {
+		// Some encoders omit padding
+		decoded, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(cleaned, "="))
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: invalid base64: %v", ErrUnrecognizedImage, err)
+	}
+	mimeType := DetectImageMIMEType(decoded)
+	if mimeType == "" {
+		return nil, "", fmt.Errorf("%w: decoded base64 has unknown format", ErrUnrecognizedImage)
+	}
+	return decoded, mimeType, nil
+}
+
+// NormalizeImageFormat maps user-supplied format names to "png", "jpeg" or "gif", the formats
+// ReencodeImage can write. An empty format stays empty, meaning "keep the original".
+func NormalizeImageFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "":
+		return "", nil
+	case "png":
+		return "png", nil
+	case "jpeg", "jpg":
+		return "jpeg", nil
+	case "gif":
+		return "gif", nil
+	default:
+		return "", fmt.Errorf("unsupported image format %q, expected png, jpeg or gif", format)
+	}
+}
+
+// ReencodeImage converts raw image bytes to format ("png", "jpeg" or "gif", see NormalizeImageFormat)
+// at the given JPEG quality (1-100, 0 for DefaultJPEGQuality). An empty format returns data unchanged.
+func ReencodeImage(data []byte, format string, quality int) ([]byte, error) {
+	format, err := NormalizeImageFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		return data, nil
+	}
+	if quality < 0 || quality > 100 {
+		return nil, fmt.Errorf("image quality must be between 1 and 100, got %d", quality)
+	}
+	img, sourceFormat, err := DecodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+	if sourceFormat == format && (format != "jpeg" || quality == 0) {
+		return data, nil
+	}
+
+	switch format {
+	case "jpeg":
+		if quality == 0 {
+			quality = DefaultJPEGQuality
+		}
+		return EncodeJPEG(img, quality)
+	case "gif":
+		var buf bytes.Buffer
+		if err := gif.Encode(&buf, img, nil); err != nil {
+			return nil, fmt.Errorf("failed to encode GIF: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return EncodePNG(img)
+	}
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultOutputNameTemplate reproduces the historical generated_image_<nanos>_<random> file names.
+const DefaultOutputNameTemplate = "generated_image_{timestamp}_{rand}"
+
+// maxNameAttempts bounds how often a colliding file name is retried with a random suffix.
+const maxNameAttempts = 10
+
+// placeholderPattern matches {name} placeholders in output name templates.
+var placeholderPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// OutputNameFields are the values substituted into an output name template.
+type OutputNameFields struct {
+	Model  string    // {model}
+	Prompt string    // {prompt_slug}
+	Seed   int64     // {seed}
+	NoSeed bool      // No seed was sent, so {seed} renders empty
+	Index  int       // {index}
+	Time   time.Time // {date}, {time} and {timestamp}; zero means now
+}
+
+// outputNamePlaceholders renders each supported placeholder. Every value is safe to use in a file name.
+var outputNamePlaceholders = map[string]func(f OutputNameFields) string{
+	"date":        func(f OutputNameFields) string { return f.Time.Format("2006-01-02") },
+	"time":        func(f OutputNameFields) string { return f.Time.Format("150405") },
+	"timestamp":   func(f OutputNameFields) string { return strconv.FormatInt(f.Time.UnixNano(), 10) },
+	"model":       func(f OutputNameFields) string { return Slugify(f.Model, 60) },
+	"prompt_slug": func(f OutputNameFields) string { return Slugify(f.Prompt, 40) },
+	"seed": func(f OutputNameFields) string {
+		if f.NoSeed {
+			return ""
+		}
+		return strconv.FormatInt(f.Seed, 10)
+	},
+	"index": func(f OutputNameFields) string { return strconv.Itoa(f.Index) },
+	"rand":  func(OutputNameFields) string { return RandomHex(4) },
+}
+
+// ValidateOutputNameTemplate checks that a template only uses known placeholders and
+// stays inside the output directory. '/' in a template creates subdirectories.
+func ValidateOutputNameTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return errors.New("output name template is empty")
+	}
+	for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		if _, ok := outputNamePlaceholders[match[1]]; !ok {
+			return fmt.Errorf("unknown placeholder {%s} in output name template", match[1])
+		}
+	}
+	if filepath.IsAbs(template) {
+		return errors.New("output name template must be relative to the output path")
+	}
+	for _, part := range strings.Split(filepath.ToSlash(template), "/") {
+		if part == ".." || part == "" {
+			return errors.New("output name template must not contain empty or '..' path segments")
+		}
+	}
+	return nil
+}
+
+// RenderOutputName expands template with fields and returns a relative path without extension.
+func RenderOutputName(template string, fields OutputNameFields) (string, error) {
+	if err := ValidateOutputNameTemplate(template); err != nil {
+		return "", err
+	}
+	if fields.Time.IsZero() {
+		fields.Time = time.Now()
+	}
+	rendered := placeholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		return outputNamePlaceholders[match[1:len(match)-1]](fields)
+	})
+	// Placeholders may render empty (e.g. a prompt without letters); drop the separators they leave behind
+	parts := strings.Split(filepath.ToSlash(rendered), "/")
+	for i, part := range parts {
+		part = strings.Trim(part, "_-. ")
+		if part == "" {
+			part = "output"
+		}
+		parts[i] = part
+	}
+	return filepath.Join(parts...), nil
+}
+
+// RandomHex returns n bytes of crypto randomness encoded as hex.
+func RandomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand does not fail on supported platforms; fall back to the clock just in case
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// writeUniqueFile writes data to outputPath/name+ext, creating subdirectories as needed.
+// If the file already exists a random suffix is appended, so existing files are never overwritten.
+func writeUniqueFile(outputPath, name, ext string, data []byte) (string, error) {
+	basePath := filepath.Join(outputPath, name)
+	if err := os.MkdirAll(filepath.Dir(basePath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	fullPath := basePath + ext
+	for attempt := 0; attempt < maxNameAttempts; attempt++ {
+		err := WriteNewFile(fullPath, data)
+		if errors.Is(err, os.ErrExist) {
+			fullPath = basePath + "_" + RandomHex(4) + ext
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to write output file: %w", err)
+		}
+		return fullPath, nil
+	}
+	return "", fmt.Errorf("failed to find a free file name for %s after %d attempts", basePath+ext, maxNameAttempts)
+}
+
+// WriteNewFile writes data to path, failing with an error wrapping os.ErrExist if the file
+// already exists. A partly written file is removed.
+func WriteNewFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderOutputName(t *testing.T) {
+	fields := OutputNameFields{
+		Model:  "stable-diffusion-xl",
+		Prompt: "A red fox, jumping over the moon!",
+		Seed:   42,
+		Index:  1,
+		Time:   time.Date(2025, 4, 9, 13, 5, 7, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		name     string
+		template string
+		prompt   string
+		expected string
+	}{
+		{"Date subdirectory", "{date}/{model}_{seed}_{index}", "", filepath.Join("2025-04-09", "stable-diffusion-xl_42_1")},
+		{"Prompt slug", "{prompt_slug}-{time}", "", "a-red-fox-jumping-over-the-moon-130507"},
+		{"Literal text", "renders/{model}/{index}", "", filepath.Join("renders", "stable-diffusion-xl", "1")},
+		{"Empty placeholder falls back", "{prompt_slug}", "!!!", "output"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := fields
+			if tc.prompt != "" {
+				f.Prompt = tc.prompt
+			}
+			got, err := RenderOutputName(tc.template, f)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected '%s', got '%s'", tc.expected, got)
+			}
+		})
+	}
+
+	t.Run("Random and timestamp", func(t *testing.T) {
+		got, err := RenderOutputName(DefaultOutputNameTemplate, fields)
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if !regexp.MustCompile(`^generated_image_\d+_[0-9a-f]{8}$`).MatchString(got) {
+			t.Errorf("Unexpected default name '%s'", got)
+		}
+	})
+}
+
+func TestValidateOutputNameTemplate(t *testing.T) {
+	invalid := map[string]string{
+		"Empty":               " ",
+		"Unknown placeholder": "{date}/{user}",
+		"Absolute":            "/etc/{seed}",
+		"Parent directory":    "../{seed}",
+		"Empty segment":       "{date}//{seed}",
+	}
+	for name, template := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if err := ValidateOutputNameTemplate(template); err == nil {
+				t.Errorf("Expected template '%s' to be rejected", template)
+			}
+		})
+	}
+	if err := ValidateOutputNameTemplate("{date}/{model}_{seed}_{index}_{rand}"); err != nil {
+		t.Errorf("Expected valid template, got: %v", err)
+	}
+}
+
+func TestSaveNamedImage_Collision(t *testing.T) {
+	tempDir := t.TempDir()
+	raw := readSample(t, "sample.png")
+	fields := OutputNameFields{Model: "sdxl", Seed: 7, Time: time.Date(2025, 4, 9, 0, 0, 0, 0, time.UTC)}
+
+	first, err := SaveNamedImage(tempDir, "{date}/{model}_{seed}", fields, raw)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if expected := filepath.Join(tempDir, "2025-04-09", "sdxl_7.png"); first != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, first)
+	}
+
+	second, err := SaveNamedImage(tempDir, "{date}/{model}_{seed}", fields, raw)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if second == first || !strings.HasPrefix(filepath.Base(second), "sdxl_7_") || filepath.Ext(second) != ".png" {
+		t.Errorf("Expected a distinct suffixed name, got '%s'", second)
+	}
+	for _, path := range []string{first, second} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected '%s' to exist: %v", path, err)
+		}
+	}
+}
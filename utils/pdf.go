@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// ExtractPDFPageText returns the plain text of every page of a PDF, in page order.
+// Pages without a text layer, such as scans, yield an empty string.
+func ExtractPDFPageText(data []byte) (pages []string, err error) {
+	// The PDF parser panics on some malformed files; report those as errors instead
+	defer func() {
+		if r := recover(); r != nil {
+			pages = nil
+			err = fmt.Errorf("failed to parse PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	numPages := reader.NumPage()
+	pages = make([]string, 0, numPages)
+	fonts := make(map[string]*pdf.Font)
+	for i := 1; i <= numPages; i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			pages = append(pages, "")
+			continue
+		}
+		for _, name := range page.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := page.Font(name)
+				fonts[name] = &font
+			}
+		}
+		text, err := page.GetPlainText(fonts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract text from PDF page %d: %w", i, err)
+		}
+		pages = append(pages, strings.TrimSpace(text))
+	}
+	return pages, nil
+}
@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// managedImagePrefixes are the file name prefixes the server has always used for its own output.
+var managedImagePrefixes = []string{"generated_image_", "annotated_image_"}
+
+// RetentionPolicy limits how much the output directory may hold. Zero values disable a limit.
+type RetentionPolicy struct {
+	MaxTotalBytes int64
+	MaxAge        time.Duration
+	MaxFiles      int
+}
+
+// Enabled reports whether any limit is set.
+func (p RetentionPolicy) Enabled() bool {
+	return p.MaxTotalBytes > 0 || p.MaxAge > 0 || p.MaxFiles > 0
+}
+
+// RetentionFile is one managed output file, together with its sidecar if it has one.
+type RetentionFile struct {
+	Path    string    `json:"path"`
+	Sidecar string    `json:"sidecar,omitempty"`
+	Size    int64     `json:"size"` // Image plus sidecar
+	ModTime time.Time `json:"modTime"`
+	Reason  string    `json:"reason,omitempty"`
+}
+
+// RetentionReport describes a retention run.
+type RetentionReport struct {
+	DryRun     bool            `json:"dryRun"`
+	Scanned    int             `json:"scanned"`
+	TotalBytes int64           `json:"totalBytes"`
+	Deleted    []RetentionFile `json:"deleted"`
+	FreedBytes int64           `json:"freedBytes"`
+	Remaining  int             `json:"remaining"`
+	Errors     []string        `json:"errors,omitempty"`
+}
+
+// ApplyRetention deletes managed files in dir that break policy, oldest first, and returns what
+// was (or with dryRun, would be) deleted. Only files the server wrote are considered: images with
+// a sidecar written by WriteSidecar or with one of the server's default name prefixes. Everything else in dir
+// is left alone. When dir is the shared system temp directory (the default output path), only its top level is
+// scanned and only images with the server's sidecar count, as other processes may use the same prefixes there.
+func ApplyRetention(dir string, policy RetentionPolicy, dryRun bool, now time.Time) (RetentionReport, error) {
+	report := RetentionReport{DryRun: dryRun, Deleted: []RetentionFile{}}
+	files, err := managedOutputFiles(dir)
+	if err != nil {
+		return report, err
+	}
+	report.Scanned = len(files)
+	for _, f := range files {
+		report.TotalBytes += f.Size
+	}
+
+	// Oldest first, so both age and size/count limits remove the oldest output
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime.Before(files[j].ModTime) })
+
+	remainingBytes := report.TotalBytes
+	remainingFiles := len(files)
+	for _, f := range files {
+		switch {
+		case policy.MaxAge > 0 && now.Sub(f.ModTime) > policy.MaxAge:
+			f.Reason = fmt.Sprintf("older than %s", policy.MaxAge)
+		case policy.MaxFiles > 0 && remainingFiles > policy.MaxFiles:
+			f.Reason = fmt.Sprintf("more than %d files", policy.MaxFiles)
+		case policy.MaxTotalBytes > 0 && remainingBytes > policy.MaxTotalBytes:
+			f.Reason = fmt.Sprintf("over %d bytes in total", policy.MaxTotalBytes)
+		default:
+			continue
+		}
+		if !dryRun {
+			if err := removeOutputFile(f); err != nil {
+				report.Errors = append(report.Errors, err.Error())
+				continue
+			}
+		}
+		report.Deleted = append(report.Deleted, f)
+		report.FreedBytes += f.Size
+		remainingBytes -= f.Size
+		remainingFiles--
+	}
+	report.Remaining = remainingFiles
+
+	if !dryRun {
+		removeEmptyParents(dir, report.Deleted)
+	}
+	return report, nil
+}
+
+// StartRetention applies policy to dir in the background: once right away and then every
+// interval until ctx is cancelled. A non-positive interval only runs the startup pass.
+// Nothing is started when the policy sets no limits.
+func StartRetention(ctx context.Context, dir string, policy RetentionPolicy, interval time.Duration) {
+	if !policy.Enabled() {
+		return
+	}
+	run := func() {
+		report, err := ApplyRetention(dir, policy, false, time.Now())
+		if err != nil {
+			slog.Error("Output retention failed", "dir", dir, "error", err)
+			return
+		}
+		if len(report.Deleted) > 0 || len(report.Errors) > 0 {
+			slog.Info("Output retention applied", "dir", dir, "deleted", len(report.Deleted), "freed_bytes", report.FreedBytes, "errors", len(report.Errors))
+		}
+	}
+	go func() {
+		run()
+		if interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
+
+// managedOutputFiles lists the image files under dir that the server wrote.
+func managedOutputFiles(dir string) ([]RetentionFile, error) {
+	shared := isSharedTempDir(dir)
+	var files []RetentionFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil // Skip unreadable subdirectories
+		}
+		if d.IsDir() {
+			if shared && path != dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isImageFileName(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		file := RetentionFile{Path: path, Size: info.Size(), ModTime: info.ModTime()}
+		if sidecarSize, ok := ownSidecar(path); ok {
+			file.Sidecar = SidecarPath(path)
+			file.Size += sidecarSize
+		} else if shared || !hasManagedPrefix(d.Name()) {
+			return nil
+		}
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan output directory: %w", err)
+	}
+	return files, nil
+}
+
+// isSharedTempDir reports whether dir is the system temp directory, resolving symlinks
+// (e.g. /tmp -> /private/tmp on macOS).
+func isSharedTempDir(dir string) bool {
+	resolve := func(path string) string {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		return filepath.Clean(path)
+	}
+	return resolve(dir) == resolve(os.TempDir())
+}
+
+// isImageFileName reports whether name has one of the extensions SaveImage writes.
+func isImageFileName(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".img" {
+		return true
+	}
+	for _, known := range imageExtensions {
+		if ext == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ownSidecar reports whether imagePath has a sidecar written by the server, i.e. a
+// <image>.json file (see SidecarPath) whose imageFile field names this image, and returns the sidecar's size.
+func ownSidecar(imagePath string) (int64, bool) {
+	data, err := os.ReadFile(SidecarPath(imagePath))
+	if err != nil {
+		return 0, false
+	}
+	var sidecar struct {
+		ImageFile string `json:"imageFile"`
+	}
+	if json.Unmarshal(data, &sidecar) != nil || filepath.Base(sidecar.ImageFile) != filepath.Base(imagePath) {
+		return 0, false
+	}
+	return int64(len(data)), true
+}
+
+// hasManagedPrefix reports whether name starts with one of managedImagePrefixes.
+func hasManagedPrefix(name string) bool {
+	for _, prefix := range managedImagePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeOutputFile deletes an image and its sidecar.
+func removeOutputFile(f RetentionFile) error {
+	if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete %s: %w", f.Path, err)
+	}
+	if f.Sidecar != "" {
+		if err := os.Remove(f.Sidecar); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete %s: %w", f.Sidecar, err)
+		}
+	}
+	return nil
+}
+
+// removeEmptyParents removes the directories of deleted files that are now empty, walking up
+// towards root. Only directories that held deleted files are touched and root itself is kept.
+func removeEmptyParents(root string, deleted []RetentionFile) {
+	root = filepath.Clean(root)
+	for _, f := range deleted {
+		for dir := filepath.Dir(f.Path); dir != root && strings.HasPrefix(dir, root+string(os.PathSeparator)); dir = filepath.Dir(dir) {
+			if entries, err := os.ReadDir(dir); err != nil || len(entries) > 0 {
+				break
+			}
+			if err := os.Remove(dir); err != nil {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeAgedFile writes size bytes to path and sets its modification time to now minus age.
+func writeAgedFile(t *testing.T, path string, size int, age time.Duration, now time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	now := time.Now()
+
+	// setup creates three managed outputs (oldest first) and two files the server did not write.
+	setup := func(t *testing.T) string {
+		dir := t.TempDir()
+		writeAgedFile(t, filepath.Join(dir, "generated_image_1.png"), 100, 72*time.Hour, now)
+		writeAgedFile(t, filepath.Join(dir, "2025-04-09", "sdxl_7.jpg"), 100, 48*time.Hour, now)
+		if _, err := WriteSidecar(filepath.Join(dir, "2025-04-09", "sdxl_7.jpg"), map[string]string{"imageFile": "sdxl_7.jpg"}); err != nil {
+			t.Fatal(err)
+		}
+		writeAgedFile(t, filepath.Join(dir, "annotated_image_3.png"), 100, time.Hour, now)
+		writeAgedFile(t, filepath.Join(dir, "holiday.png"), 100, 96*time.Hour, now)
+		writeAgedFile(t, filepath.Join(dir, "notes.txt"), 100, 96*time.Hour, now)
+		return dir
+	}
+
+	testCases := []struct {
+		name     string
+		policy   RetentionPolicy
+		expected []string
+	}{
+		{"Max age", RetentionPolicy{MaxAge: 24 * time.Hour}, []string{"generated_image_1.png", "sdxl_7.jpg"}},
+		{"Max files", RetentionPolicy{MaxFiles: 1}, []string{"generated_image_1.png", "sdxl_7.jpg"}},
+		{"Max total bytes", RetentionPolicy{MaxTotalBytes: 250}, []string{"generated_image_1.png"}},
+		{"Within limits", RetentionPolicy{MaxFiles: 10, MaxAge: 100 * time.Hour}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := setup(t)
+			report, err := ApplyRetention(dir, tc.policy, false, now)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if report.Scanned != 3 {
+				t.Errorf("Expected 3 managed files, got %d", report.Scanned)
+			}
+			var deleted []string
+			for _, f := range report.Deleted {
+				deleted = append(deleted, filepath.Base(f.Path))
+				if _, err := os.Stat(f.Path); !os.IsNotExist(err) {
+					t.Errorf("Expected '%s' to be deleted", f.Path)
+				}
+				if f.Sidecar != "" {
+					if _, err := os.Stat(f.Sidecar); !os.IsNotExist(err) {
+						t.Errorf("Expected sidecar '%s' to be deleted", f.Sidecar)
+					}
+				}
+			}
+			if len(deleted) != len(tc.expected) {
+				t.Fatalf("Expected deleted %v, got %v", tc.expected, deleted)
+			}
+			for i := range deleted {
+				if deleted[i] != tc.expected[i] {
+					t.Errorf("Expected deleted %v, got %v", tc.expected, deleted)
+				}
+			}
+			for _, untouched := range []string{"holiday.png", "notes.txt"} {
+				if _, err := os.Stat(filepath.Join(dir, untouched)); err != nil {
+					t.Errorf("Expected unmanaged file '%s' to be kept: %v", untouched, err)
+				}
+			}
+		})
+	}
+
+	t.Run("Empty day directory is removed", func(t *testing.T) {
+		dir := setup(t)
+		if _, err := ApplyRetention(dir, RetentionPolicy{MaxAge: 24 * time.Hour}, false, now); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "2025-04-09")); !os.IsNotExist(err) {
+			t.Errorf("Expected empty subdirectory to be removed, got: %v", err)
+		}
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("Expected output directory to be kept: %v", err)
+		}
+	})
+
+	t.Run("Dry run deletes nothing", func(t *testing.T) {
+		dir := setup(t)
+		report, err := ApplyRetention(dir, RetentionPolicy{MaxFiles: 1}, true, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !report.DryRun || len(report.Deleted) != 2 || report.Remaining != 1 {
+			t.Errorf("Unexpected dry run report: %+v", report)
+		}
+		for _, f := range report.Deleted {
+			if _, err := os.Stat(f.Path); err != nil {
+				t.Errorf("Dry run deleted '%s'", f.Path)
+			}
+		}
+	})
+
+	t.Run("Shared temp directory", func(t *testing.T) {
+		dir := setup(t)
+		t.Setenv("TMPDIR", dir)
+		writeAgedFile(t, filepath.Join(dir, "other-process", "generated_image_9.png"), 100, 96*time.Hour, now)
+		writeAgedFile(t, filepath.Join(dir, "own.png"), 100, 96*time.Hour, now)
+		if _, err := WriteSidecar(filepath.Join(dir, "own.png"), map[string]string{"imageFile": "own.png"}); err != nil {
+			t.Fatal(err)
+		}
+		report, err := ApplyRetention(dir, RetentionPolicy{MaxAge: time.Minute}, false, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Scanned != 1 || len(report.Deleted) != 1 || filepath.Base(report.Deleted[0].Path) != "own.png" {
+			t.Errorf("Expected only the top-level image with a sidecar to be managed, got: %+v", report)
+		}
+		for _, kept := range []string{"generated_image_1.png", "annotated_image_3.png", filepath.Join("2025-04-09", "sdxl_7.jpg"), filepath.Join("other-process", "generated_image_9.png")} {
+			if _, err := os.Stat(filepath.Join(dir, kept)); err != nil {
+				t.Errorf("Expected '%s' to be kept: %v", kept, err)
+			}
+		}
+	})
+
+	t.Run("Missing directory", func(t *testing.T) {
+		if _, err := ApplyRetention(filepath.Join(t.TempDir(), "missing"), RetentionPolicy{MaxFiles: 1}, true, now); err == nil {
+			t.Error("Expected an error for a missing output directory")
+		}
+	})
+}